@@ -0,0 +1,103 @@
+package ydb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"gorm.io/gorm"
+)
+
+// HotPartitionFactor is ratio of row updates of partition to mean updates of partitions of table
+// above which partition is reported as hot
+var HotPartitionFactor = 3.0
+
+// PartitionLoad describes load of table partition, counters are accumulated since partition start
+type PartitionLoad struct {
+	Index      uint64  `gorm:"column:PartIdx"`
+	RowCount   uint64  `gorm:"column:RowCount"`
+	DataSize   uint64  `gorm:"column:DataSize"`
+	RowReads   uint64  `gorm:"column:RowReads"`
+	RowUpdates uint64  `gorm:"column:RowUpdates"`
+	CPUCores   float64 `gorm:"column:CPUCores"`
+}
+
+// KeyDistribution describes distribution of writes of table over its partitions
+type KeyDistribution struct {
+	Table      string
+	Partitions []PartitionLoad
+	// Hot indexes of partitions receiving more than HotPartitionFactor times mean updates
+	Hot []uint64
+	// TopWriteShare share of updates received by the busiest partition
+	TopWriteShare float64
+	// Advice how to spread writes, empty when writes are distributed evenly
+	Advice string
+}
+
+// AnalyzeKeyDistribution inspects load of partitions of model's table from .sys/partition_stats
+// and reports partitions concentrating writes, typically caused by monotonically increasing
+// primary keys (auto increment, timestamps) written to the last partition. Skewed distribution
+// is also logged as warning by logger of db
+func AnalyzeKeyDistribution(db *gorm.DB, model interface{}) (*KeyDistribution, error) {
+	nativeDriver, err := Unwrap(db)
+	if err != nil {
+		return nil, err
+	}
+	stmt := &gorm.Statement{DB: db}
+	if err = stmt.Parse(model); err != nil {
+		return nil, err
+	}
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	report := &KeyDistribution{Table: stmt.Table}
+	err = db.Session(&gorm.Session{NewDB: true, Context: ydb.WithQueryMode(ctx, ydb.ScanQueryMode)}).
+		Table(".sys/partition_stats").
+		Select("PartIdx", "RowCount", "DataSize", "RowReads", "RowUpdates", "CPUCores").
+		Where("Path = ?", tablePath(nativeDriver, stmt.Table)).
+		Order("PartIdx").
+		Find(&report.Partitions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var total, top uint64
+	for _, p := range report.Partitions {
+		total += p.RowUpdates
+		if p.RowUpdates > top {
+			top = p.RowUpdates
+		}
+	}
+	if total == 0 {
+		return report, nil
+	}
+	report.TopWriteShare = float64(top) / float64(total)
+	mean := float64(total) / float64(len(report.Partitions))
+	for _, p := range report.Partitions {
+		if len(report.Partitions) > 1 && float64(p.RowUpdates) > HotPartitionFactor*mean {
+			report.Hot = append(report.Hot, p.Index)
+		}
+	}
+
+	key, first := "primary key", "id"
+	if stmt.Schema != nil && len(stmt.Schema.PrimaryFieldDBNames) > 0 {
+		key, first = strings.Join(stmt.Schema.PrimaryFieldDBNames, ", "), stmt.Schema.PrimaryFieldDBNames[0]
+	}
+	switch {
+	case len(report.Hot) > 0:
+		report.Advice = fmt.Sprintf("%.0f%% of writes of %s go to %d of %d partitions, "+
+			"prefix primary key (%s) with hash column, e.g. Digest::MurmurHash(%s) %% %d, or use random ids",
+			report.TopWriteShare*100, stmt.Table, len(report.Hot), len(report.Partitions), key, first,
+			len(report.Partitions)*4)
+	case len(report.Partitions) == 1:
+		report.Advice = fmt.Sprintf("all writes of %s go to single partition, enable AUTO_PARTITIONING_BY_LOAD "+
+			"or set UNIFORM_PARTITIONS with hash prefixed primary key (%s)", stmt.Table, key)
+	}
+	if report.Advice != "" {
+		db.Logger.Warn(ctx, "ydb: %s", report.Advice)
+	}
+	return report, nil
+}
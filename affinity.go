@@ -0,0 +1,68 @@
+package ydb
+
+import (
+	"context"
+	"database/sql"
+
+	"gorm.io/gorm"
+)
+
+type sessionAffinityKey struct{}
+
+// WithSessionAffinity returns context pinning queries made with it through db to one connection
+// of the pool, which keeps one session of the table service, so bursts of related small reads of
+// a request are served by the same node with warm caches instead of sessions spread over the
+// cluster. Writes with default transaction begin it on the pinned connection too, transactions
+// started by db.Transaction and db.Begin are not pinned. The connection is taken from the pool
+// until release is called, so pinned contexts must not outlive requests
+//
+//	ctx, release, err := ydb.WithSessionAffinity(r.Context(), db)
+//	if err != nil {
+//		return err
+//	}
+//	defer release()
+//	db.WithContext(ctx).First(&user, id)
+//	db.WithContext(ctx).Where("user_id = ?", id).Find(&orders)
+func WithSessionAffinity(ctx context.Context, db *gorm.DB) (_ context.Context, release func() error, err error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, nil, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return context.WithValue(ctx, sessionAffinityKey{}, conn), conn.Close, nil
+}
+
+func registerSessionAffinityCallbacks(db *gorm.DB) error {
+	callback := db.Callback()
+	if err := callback.Query().Before("gorm:query").Register("ydb:session_affinity", pinSession); err != nil {
+		return err
+	}
+	if err := callback.Row().Before("gorm:row").Register("ydb:session_affinity", pinSession); err != nil {
+		return err
+	}
+	if err := callback.Raw().Before("gorm:raw").Register("ydb:session_affinity", pinSession); err != nil {
+		return err
+	}
+	if err := callback.Create().Before("gorm:begin_transaction").Register("ydb:session_affinity", pinSession); err != nil {
+		return err
+	}
+	if err := callback.Update().Before("gorm:begin_transaction").Register("ydb:session_affinity", pinSession); err != nil {
+		return err
+	}
+	return callback.Delete().Before("gorm:begin_transaction").Register("ydb:session_affinity", pinSession)
+}
+
+// pinSession replaces connection pool of statement by connection pinned by its context
+func pinSession(db *gorm.DB) {
+	conn, ok := db.Statement.Context.Value(sessionAffinityKey{}).(*sql.Conn)
+	if !ok || db.Error != nil {
+		return
+	}
+	// statements of transactions and of prepared statements mode keep their connections
+	if _, isPool := db.Statement.ConnPool.(*sql.DB); isPool {
+		db.Statement.ConnPool = conn
+	}
+}
@@ -0,0 +1,328 @@
+package ydb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/options"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Aggregate defines table of counts and sums of rows of source model by group columns
+type Aggregate struct {
+	// Table of aggregates, its primary key is GroupBy columns
+	Table string
+	// Source model whose creates, updates and deletes are aggregated
+	Source interface{}
+	// GroupBy columns of source, values must not be NULL
+	GroupBy []string
+	// Sums numeric columns of source summed into columns of the same name
+	Sums []string
+	// CountColumn keeping count of rows, defaults to count
+	CountColumn string
+
+	source string
+}
+
+// Aggregates is a gorm plugin maintaining pre-aggregated tables (YDB has no materialized views)
+// by deltas of creates, updates and deletes of source models, written in the transaction of
+// the write, so aggregates are consistent with source tables. Rows of updates and deletes are
+// read before and after the statement like by Audit plugin. Raw statements (Exec) and
+// BulkUpsert are not aggregated
+//
+//	aggregates := &ydb.Aggregates{Definitions: []ydb.Aggregate{{
+//		Table: "order_totals", Source: &Order{}, GroupBy: []string{"customer_id"}, Sums: []string{"amount"},
+//	}}}
+//	db.Use(aggregates)
+//	aggregates.Migrate(ctx, db)
+type Aggregates struct {
+	Definitions []Aggregate
+
+	bySource map[string][]*Aggregate
+}
+
+const aggregatesKey = "ydb:aggregates"
+
+func (a *Aggregates) Name() string {
+	return aggregatesKey
+}
+
+func (a *Aggregates) Initialize(db *gorm.DB) error {
+	a.bySource = map[string][]*Aggregate{}
+	for i := range a.Definitions {
+		def := &a.Definitions[i]
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(def.Source); err != nil {
+			return err
+		}
+		if def.Table == "" || len(def.GroupBy) == 0 {
+			return fmt.Errorf("ydb: aggregate of %s requires table and group columns", stmt.Table)
+		}
+		if def.CountColumn == "" {
+			def.CountColumn = "count"
+		}
+		def.source = stmt.Table
+		a.bySource[def.source] = append(a.bySource[def.source], def)
+	}
+
+	callback := db.Callback()
+	if err := callback.Create().After("gorm:create").Register(aggregatesKey, a.created); err != nil {
+		return err
+	}
+	if update := callback.Update().Get("gorm:update"); update != nil {
+		if err := callback.Update().Replace("gorm:update", a.changed(update)); err != nil {
+			return err
+		}
+	}
+	if del := callback.Delete().Get("gorm:delete"); del != nil {
+		if err := callback.Delete().Replace("gorm:delete", a.changed(del)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// aggregated reports whether writes of statement are aggregated
+func (a *Aggregates) aggregated(db *gorm.DB) bool {
+	return db.Error == nil && !db.DryRun && len(a.bySource[db.Statement.Table]) > 0
+}
+
+// created adds rows inserted by values of statement to aggregates
+func (a *Aggregates) created(db *gorm.DB) {
+	if !a.aggregated(db) {
+		return
+	}
+	values, ok := db.Statement.Clauses["VALUES"].Expression.(clause.Values)
+	if !ok {
+		return
+	}
+	rows := make([]map[string]interface{}, 0, len(values.Values))
+	for _, v := range values.Values {
+		row := make(map[string]interface{}, len(values.Columns))
+		for i, column := range values.Columns {
+			row[column.Name] = unwrapSensitive(v[i])
+		}
+		rows = append(rows, row)
+	}
+	a.apply(db, nil, rows)
+}
+
+// changed replaces aggregates of rows matching conditions of write read before it
+// by aggregates of the rows read after it
+func (a *Aggregates) changed(write func(*gorm.DB)) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if !a.aggregated(db) || db.Statement.SQL.Len() > 0 {
+			write(db)
+			return
+		}
+		dry := dryRun(db, write)
+		if dry == nil {
+			write(db)
+			return
+		}
+		if db.Statement.Schema == nil || len(db.Statement.Schema.PrimaryFieldDBNames) == 0 {
+			db.AddError(fmt.Errorf("%w: aggregated table %s", errMissingPrimaryKey, db.Statement.Table))
+			return
+		}
+
+		tx := db.Session(&gorm.Session{NewDB: true}).Table(db.Statement.Table)
+		query := tx
+		if where, ok := dry.Statement.Clauses["WHERE"]; ok {
+			query = query.Clauses(where.Expression)
+		}
+		var before []map[string]interface{}
+		if err := query.Find(&before).Error; err != nil {
+			db.AddError(err)
+			return
+		}
+
+		write(db)
+		if db.Error != nil || len(before) == 0 {
+			return
+		}
+		var after []map[string]interface{}
+		if err := tx.Clauses(columnsCondition(db.Statement.Schema.PrimaryFieldDBNames, before)).Find(&after).Error; err != nil {
+			db.AddError(err)
+			return
+		}
+		a.apply(db, before, after)
+	}
+}
+
+// aggregateDelta is change of aggregates of group
+type aggregateDelta struct {
+	group []interface{}
+	count int64
+	sums  []interface{}
+}
+
+// apply writes deltas of aggregates of removed and added rows
+func (a *Aggregates) apply(db *gorm.DB, removed, added []map[string]interface{}) {
+	for _, def := range a.bySource[db.Statement.Table] {
+		deltas := map[string]*aggregateDelta{}
+		var order []string
+		add := func(row map[string]interface{}, sign int64) {
+			group := make([]interface{}, 0, len(def.GroupBy))
+			for _, column := range def.GroupBy {
+				group = append(group, row[column])
+			}
+			key := fmt.Sprintf("%#v", group)
+			delta, ok := deltas[key]
+			if !ok {
+				delta = &aggregateDelta{group: group, sums: make([]interface{}, len(def.Sums))}
+				deltas[key] = delta
+				order = append(order, key)
+			}
+			delta.count += sign
+			for i, column := range def.Sums {
+				delta.sums[i] = addNumbers(delta.sums[i], row[column], sign)
+			}
+		}
+		for _, row := range removed {
+			add(row, -1)
+		}
+		for _, row := range added {
+			add(row, 1)
+		}
+
+		for _, key := range order {
+			if delta := deltas[key]; !delta.zero() {
+				if err := def.write(db, delta); err != nil {
+					db.AddError(err)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (delta *aggregateDelta) zero() bool {
+	if delta.count != 0 {
+		return false
+	}
+	for _, sum := range delta.sums {
+		if sum != nil && !reflect.ValueOf(sum).IsZero() {
+			return false
+		}
+	}
+	return true
+}
+
+// write adds delta to aggregates of group by single UPSERT reading current aggregates
+func (def *Aggregate) write(db *gorm.DB, delta *aggregateDelta) error {
+	var (
+		sql  strings.Builder
+		vars []interface{}
+	)
+	sql.WriteString("UPSERT INTO ? SELECT ")
+	vars = append(vars, clause.Table{Name: def.Table})
+	for i, column := range def.GroupBy {
+		sql.WriteString("? AS ?, ")
+		vars = append(vars, delta.group[i], clause.Column{Name: column})
+	}
+	sql.WriteString("COALESCE(MAX(?), 0) + ? AS ?")
+	vars = append(vars, clause.Column{Name: def.CountColumn}, delta.count, clause.Column{Name: def.CountColumn})
+	for i, column := range def.Sums {
+		sum := delta.sums[i]
+		if sum == nil {
+			sum = int64(0)
+		}
+		sql.WriteString(", COALESCE(MAX(?), ?) + ? AS ?")
+		vars = append(vars, clause.Column{Name: column}, reflect.Zero(reflect.TypeOf(sum)).Interface(), sum, clause.Column{Name: column})
+	}
+	sql.WriteString(" FROM ? WHERE ")
+	vars = append(vars, clause.Table{Name: def.Table})
+	for i, column := range def.GroupBy {
+		if i > 0 {
+			sql.WriteString(" AND ")
+		}
+		sql.WriteString("? = ?")
+		vars = append(vars, clause.Column{Name: column}, delta.group[i])
+	}
+	return db.Session(&gorm.Session{NewDB: true}).Exec(sql.String(), vars...).Error
+}
+
+// addNumbers returns sum + sign * v as int64 or as float64 for floating point values
+func addNumbers(sum, v interface{}, sign int64) interface{} {
+	var n interface{}
+	switch rv := reflect.Indirect(reflect.ValueOf(v)); rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = sign * rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n = sign * int64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		n = float64(sign) * rv.Float()
+	default:
+		return sum
+	}
+	switch s := sum.(type) {
+	case nil:
+		return n
+	case float64:
+		if i, ok := n.(int64); ok {
+			return s + float64(i)
+		}
+		return s + n.(float64)
+	case int64:
+		if f, ok := n.(float64); ok {
+			return float64(s) + f
+		}
+		return s + n.(int64)
+	}
+	return sum
+}
+
+// Migrate creates aggregate tables which don't exist, group columns have types of source
+// columns, count is Int64 and sums are Int64 or Double for floating point source columns
+func (a *Aggregates) Migrate(ctx context.Context, db *gorm.DB) error {
+	nativeDriver, err := Unwrap(db)
+	if err != nil {
+		return err
+	}
+	for i := range a.Definitions {
+		def := &a.Definitions[i]
+		var desc options.Description
+		err = nativeDriver.Table().Do(ctx, func(ctx context.Context, s table.Session) (err error) {
+			desc, err = s.DescribeTable(ctx, tablePath(nativeDriver, def.source))
+			return err
+		}, table.WithIdempotent())
+		if err != nil {
+			return err
+		}
+		columnTypes := make(map[string]types.Type, len(desc.Columns))
+		for _, column := range desc.Columns {
+			columnTypes[column.Name] = column.Type
+		}
+
+		opts := make([]options.CreateTableOption, 0, len(def.GroupBy)+len(def.Sums)+2)
+		for _, column := range def.GroupBy {
+			t, ok := columnTypes[column]
+			if !ok {
+				return fmt.Errorf("ydb: table %s has no column %s", def.source, column)
+			}
+			opts = append(opts, options.WithColumn(column, t))
+		}
+		opts = append(opts, options.WithColumn(def.CountColumn, types.Optional(types.TypeInt64)))
+		for _, column := range def.Sums {
+			t, ok := columnTypes[column]
+			if !ok {
+				return fmt.Errorf("ydb: table %s has no column %s", def.source, column)
+			}
+			sumType := types.TypeInt64
+			if yql := t.Yql(); strings.Contains(yql, "Double") || strings.Contains(yql, "Float") {
+				sumType = types.TypeDouble
+			}
+			opts = append(opts, options.WithColumn(column, types.Optional(sumType)))
+		}
+		opts = append(opts, options.WithPrimaryKeyColumn(def.GroupBy...))
+		if err = createTable(ctx, db, def.Table, opts...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
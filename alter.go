@@ -0,0 +1,117 @@
+package ydb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/options"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrUnsupportedAlter returned by AlterColumn for changes YDB can't apply to existing column
+var ErrUnsupportedAlter = errors.New("ydb: unsupported column alteration")
+
+// AlterError describes change of column unsupported by YDB, it matches ErrUnsupportedAlter
+type AlterError struct {
+	Table  string
+	Column string
+	// Change is description of unsupported change, e.g. type Optional<Int32> to Int64
+	Change string
+}
+
+func (e *AlterError) Error() string {
+	return fmt.Sprintf("%v: %s of column %s.%s; create table with new definition, copy rows by "+
+		"INSERT INTO ... SELECT and switch to it, or add new column, backfill it and drop the old one",
+		ErrUnsupportedAlter, e.Change, e.Table, e.Column)
+}
+
+func (e *AlterError) Is(target error) bool {
+	return target == ErrUnsupportedAlter
+}
+
+// AlterColumn applies changes of field's column YDB supports in place and returns AlterError
+// for others:
+//   - column family of field tagged with family is changed by ALTER COLUMN SET FAMILY
+//   - changes of type, including Optional to NOT NULL and back, are unsupported
+func (m Migrator) AlterColumn(value interface{}, field string) error {
+	err := m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		f := stmt.Schema.LookUpField(field)
+		if f == nil {
+			return fmt.Errorf("failed to look up field with name: %s", field)
+		}
+		column, err := m.describeColumn(stmt.Table, f.DBName)
+		if err != nil {
+			return err
+		}
+
+		current := column.Type.Yql()
+		optional := strings.HasPrefix(current, "Optional<")
+		if dataType := m.DataTypeOf(f); !strings.EqualFold(unwrapOptional(current), dataType) {
+			return &AlterError{Table: stmt.Table, Column: f.DBName, Change: fmt.Sprintf("type %s to %s", current, dataType)}
+		}
+		// primary keys of tables created before NOT NULL support are optional
+		if notNull := m.notNull(f); !f.PrimaryKey && notNull == optional {
+			change := "NOT NULL to nullable"
+			if notNull {
+				change = "nullable to NOT NULL"
+			}
+			return &AlterError{Table: stmt.Table, Column: f.DBName, Change: change}
+		}
+
+		family := f.TagSettings["FAMILY"]
+		if family != "" && family != column.Family && !(family == "default" && column.Family == "") {
+			return m.execScheme("ALTER TABLE ? ALTER COLUMN ? SET FAMILY ?",
+				m.CurrentTable(stmt), clause.Column{Name: f.DBName}, clause.Column{Name: family})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	m.resetPreparedStmts()
+	return nil
+}
+
+// describeTable returns description of table by native driver
+func (m Migrator) describeTable(tableName string) (desc options.Description, err error) {
+	nativeDriver, err := Unwrap(m.DB)
+	if err != nil {
+		return desc, err
+	}
+	err = nativeDriver.Table().Do(m.context(), func(ctx context.Context, s table.Session) (err error) {
+		desc, err = s.DescribeTable(ctx, tablePath(nativeDriver, tableName))
+		return err
+	}, table.WithIdempotent())
+	return desc, err
+}
+
+// describeColumn returns column of table described by native driver
+func (m Migrator) describeColumn(tableName, name string) (options.Column, error) {
+	desc, err := m.describeTable(tableName)
+	if err != nil {
+		return options.Column{}, err
+	}
+	for _, column := range desc.Columns {
+		if column.Name == name {
+			return column, nil
+		}
+	}
+	return options.Column{}, fmt.Errorf("ydb: table %s has no column %s", tableName, name)
+}
+
+// execScheme executes DDL statement as scheme query
+func (m Migrator) execScheme(sql string, values ...interface{}) error {
+	return m.DB.WithContext(ydb.WithQueryMode(m.context(), ydb.SchemeQueryMode)).Exec(sql, values...).Error
+}
+
+func (m Migrator) context() context.Context {
+	if ctx := m.DB.Statement.Context; ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
@@ -0,0 +1,365 @@
+package ydb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"gorm.io/gorm"
+)
+
+// ApplySchema creates objects of schema file missing in database, so the same file provisions new
+// environments and brings existing ones up to date idempotently, e.g. file of Migrator.DumpSchema.
+// Schema file is YQL script of CREATE TABLE statements: tables missing in database are created by
+// the statements, column families, columns and indexes missing in existing tables are added by
+// ALTER TABLE. Columns are added to existing tables without NOT NULL, YDB doesn't add NOT NULL
+// columns to tables with rows. TTL of file is set for existing tables without TTL, it deletes
+// expired rows, so it's refused with ErrDestructiveMigration when
+// Config.DisallowDestructiveMigrations is set unless allowed by AllowDestructiveMigrations.
+// Otherwise existing objects are never changed or dropped, even if their definitions differ from
+// the file, see Migrator.Diff. The whole file is parsed before changes, statements other than
+// CREATE TABLE are rejected
+//
+//	f, err := os.Open("schema.yql")
+//	...
+//	err = ydb.ApplySchema(ctx, db, f)
+func ApplySchema(ctx context.Context, db *gorm.DB, r io.Reader) error {
+	script, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	var tables []schemaTable
+	for _, statement := range splitStatements(string(script)) {
+		table, err := parseCreateTable(statement)
+		if err != nil {
+			return err
+		}
+		tables = append(tables, table)
+	}
+
+	db = db.WithContext(ctx)
+	m, ok := db.Migrator().(Migrator)
+	if !ok {
+		return fmt.Errorf("ydb: schema is applied by ydb dialector only, got %s", db.Dialector.Name())
+	}
+	exec := func(sql string) error {
+		return db.WithContext(ydb.WithQueryMode(ctx, ydb.SchemeQueryMode)).Exec(sql).Error
+	}
+	for _, table := range tables {
+		desc, err := m.describeTable(table.name)
+		if ydb.IsOperationErrorSchemeError(err) {
+			if err = exec(table.statement); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		existing := make(map[string]bool)
+		for _, family := range desc.ColumnFamilies {
+			existing["FAMILY "+family.Name] = true
+		}
+		for _, column := range desc.Columns {
+			existing["COLUMN "+column.Name] = true
+		}
+		for _, index := range desc.Indexes {
+			existing["INDEX "+index.Name] = true
+		}
+		alter := "ALTER TABLE " + table.quotedName + " ADD "
+		// families before columns stored in them, indexes after their columns
+		for _, kind := range []string{"FAMILY", "COLUMN", "INDEX"} {
+			for _, element := range table.elements {
+				if element.kind != kind || existing[kind+" "+element.name] {
+					continue
+				}
+				sql := alter + element.definition
+				if kind == "COLUMN" {
+					definition := notNullConstraint.ReplaceAllString(element.definition, "")
+					if definition != element.definition {
+						db.Logger.Warn(ctx, "ydb: column %s is added to existing table %s without NOT NULL",
+							element.name, table.name)
+					}
+					sql = alter + "COLUMN " + definition
+				}
+				if err = exec(sql); err != nil {
+					return err
+				}
+			}
+		}
+		if table.ttl != "" && desc.TimeToLiveSettings == nil {
+			if err = m.checkTTL(table.name, desc.TimeToLiveSettings, ttlInterval(table.ttl)); err != nil {
+				return err
+			}
+			if err = exec("ALTER TABLE " + table.quotedName + " SET (" + table.ttl + ")"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// notNullConstraint matches NOT NULL of column definition
+var notNullConstraint = regexp.MustCompile(`(?i)\s+NOT\s+NULL\b`)
+
+// schemaTable is CREATE TABLE statement of schema file
+type schemaTable struct {
+	statement  string
+	name       string
+	quotedName string
+	elements   []schemaElement
+	// ttl is TTL setting of WITH, e.g. TTL = Interval("PT1H") ON expires_at
+	ttl string
+}
+
+// schemaElement is column, index or column family of CREATE TABLE
+type schemaElement struct {
+	// kind is COLUMN, INDEX or FAMILY
+	kind       string
+	name       string
+	definition string
+}
+
+// parseCreateTable parses CREATE TABLE statement
+func parseCreateTable(statement string) (table schemaTable, err error) {
+	table.statement = statement
+	s := statement
+	for _, keyword := range []string{"CREATE", "TABLE"} {
+		var ok bool
+		if s, ok = consumeKeyword(s, keyword); !ok {
+			return table, fmt.Errorf("ydb: unsupported statement of schema, CREATE TABLE expected: %s", firstLine(statement))
+		}
+	}
+	if rest, ok := consumeKeyword(s, "IF"); ok {
+		if rest, ok = consumeKeyword(rest, "NOT"); ok {
+			if rest, ok = consumeKeyword(rest, "EXISTS"); ok {
+				s = rest
+			}
+		}
+	}
+	table.quotedName, table.name, s = nextIdentifier(s)
+	if table.name == "" || !strings.HasPrefix(s, "(") {
+		return table, fmt.Errorf("ydb: malformed CREATE TABLE of schema: %s", firstLine(statement))
+	}
+	end := closingParen(s)
+	if end < 0 {
+		return table, fmt.Errorf("ydb: unbalanced parentheses in CREATE TABLE %s", table.name)
+	}
+
+	for _, definition := range splitTopLevel(s[1:end]) {
+		element := schemaElement{kind: "COLUMN", definition: definition}
+		rest := definition
+		switch strings.ToUpper(firstWord(definition)) {
+		case "PRIMARY":
+			continue
+		case "INDEX", "FAMILY":
+			element.kind = strings.ToUpper(firstWord(definition))
+			rest = strings.TrimSpace(definition[len(element.kind):])
+		}
+		_, element.name, _ = nextIdentifier(rest)
+		table.elements = append(table.elements, element)
+	}
+
+	if with, ok := consumeKeyword(s[end+1:], "WITH"); ok && strings.HasPrefix(with, "(") {
+		if end = closingParen(with); end > 0 {
+			for _, setting := range splitTopLevel(with[1:end]) {
+				if strings.EqualFold(firstWord(setting), "TTL") {
+					table.ttl = setting
+				}
+			}
+		}
+	}
+	return table, nil
+}
+
+// ttlInterval returns expiration interval of TTL setting, e.g. 1h of TTL = Interval("PT1H") ON
+// expires_at, zero when it can't be parsed
+func ttlInterval(setting string) time.Duration {
+	start := strings.Index(setting, "\"")
+	if start < 0 {
+		return 0
+	}
+	literal := setting[start+1:]
+	end := strings.IndexByte(literal, '"')
+	if end < 0 || !strings.HasPrefix(literal, "P") {
+		return 0
+	}
+	var interval time.Duration
+	units := map[byte]time.Duration{'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour}
+	number := ""
+	for i := 1; i < end; i++ {
+		switch c := literal[i]; {
+		case c == 'T':
+			units = map[byte]time.Duration{'H': time.Hour, 'M': time.Minute, 'S': time.Second}
+		case c >= '0' && c <= '9' || c == '.':
+			number += string(c)
+		default:
+			n, err := strconv.ParseFloat(number, 64)
+			if err != nil || units[c] == 0 {
+				return 0
+			}
+			interval += time.Duration(n * float64(units[c]))
+			number = ""
+		}
+	}
+	return interval
+}
+
+// consumeKeyword returns s after leading keyword, case insensitive
+func consumeKeyword(s, keyword string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if !strings.EqualFold(firstWord(s), keyword) {
+		return s, false
+	}
+	return strings.TrimSpace(s[len(keyword):]), true
+}
+
+// firstWord returns leading word of s
+func firstWord(s string) string {
+	end := strings.IndexFunc(s, func(r rune) bool {
+		return !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	})
+	if end < 0 {
+		return s
+	}
+	return s[:end]
+}
+
+// nextIdentifier returns leading identifier of s as written and unquoted, and the rest of s
+func nextIdentifier(s string) (quoted, name, rest string) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "`") {
+		end := strings.IndexByte(s[1:], '`')
+		if end < 0 {
+			return "", "", s
+		}
+		return s[:end+2], s[1 : end+1], strings.TrimSpace(s[end+2:])
+	}
+	end := strings.IndexFunc(s, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '(' || r == ','
+	})
+	if end < 0 {
+		end = len(s)
+	}
+	return s[:end], s[:end], strings.TrimSpace(s[end:])
+}
+
+// closingParen returns index of parenthesis closing the one s starts with, -1 if there is none
+func closingParen(s string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitTopLevel splits s by commas outside of parentheses and quotes
+func splitTopLevel(s string) (parts []string) {
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" {
+		parts = append(parts, last)
+	}
+	return parts
+}
+
+// splitStatements splits script by semicolons outside of quotes, comments are removed
+func splitStatements(script string) (statements []string) {
+	var (
+		sb    strings.Builder
+		quote byte
+	)
+	flush := func() {
+		if statement := strings.TrimSpace(sb.String()); statement != "" {
+			statements = append(statements, statement)
+		}
+		sb.Reset()
+	}
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && i+1 < len(script) {
+				sb.WriteByte(c)
+				i++
+				c = script[i]
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '-' && strings.HasPrefix(script[i:], "--"):
+			if end := strings.IndexByte(script[i:], '\n'); end >= 0 {
+				i += end
+				c = '\n'
+			} else {
+				i = len(script)
+				continue
+			}
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				i = len(script)
+				continue
+			}
+			i += end + 3
+			c = ' '
+		case c == ';':
+			flush()
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	flush()
+	return statements
+}
+
+// firstLine returns first line of statement for errors
+func firstLine(statement string) string {
+	if i := strings.IndexByte(statement, '\n'); i >= 0 {
+		return statement[:i] + " ..."
+	}
+	return statement
+}
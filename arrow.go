@@ -0,0 +1,482 @@
+package ydb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"time"
+)
+
+// ArrowBatchRows count of rows in record batch written by Arrow export format
+var ArrowBatchRows = 1024
+
+// Arrow is export format writing Apache Arrow IPC stream (columnar format version 5, readable
+// e.g. by pyarrow.ipc.open_stream) of record batches of ArrowBatchRows rows. Types of columns
+// are inferred from values of the first batch: signed integers are written as Int64, unsigned
+// as UInt64, floats as Double, bytes as Binary, times as Timestamp of microseconds in UTC,
+// durations as Duration of microseconds, strings and other values (e.g. decimals) as Utf8,
+// formatted like by CSV, all columns are nullable. Values of next batches of other types fail
+// export unless column is Utf8
+var Arrow ExportFormat = func(w io.Writer, columns []string) (ExportEncoder, error) {
+	return &arrowEncoder{w: w, columns: columns}, nil
+}
+
+// arrowType is type of Arrow column, values are ids of Type union of Schema.fbs
+type arrowType byte
+
+const (
+	arrowInt       arrowType = 2
+	arrowFloat     arrowType = 3
+	arrowBinary    arrowType = 4
+	arrowUtf8      arrowType = 5
+	arrowBool      arrowType = 6
+	arrowTimestamp arrowType = 10
+	arrowDuration  arrowType = 18
+)
+
+// arrowColumn is type of Arrow column, signed is for Int columns
+type arrowColumn struct {
+	typ    arrowType
+	signed bool
+}
+
+const (
+	arrowMetadataV5    = 4
+	arrowHeaderSchema  = 1
+	arrowHeaderBatch   = 3
+	arrowPrecisionDbl  = 2
+	arrowUnitMicro     = 2
+	arrowContinuation  = 0xFFFFFFFF
+	arrowBufferAlign   = 8
+	arrowSchemaFields  = 1
+	arrowMessageFields = 3
+)
+
+type arrowEncoder struct {
+	w       io.Writer
+	columns []string
+	types   []arrowColumn
+	rows    [][]interface{}
+}
+
+func (e *arrowEncoder) Encode(row []interface{}) error {
+	e.rows = append(e.rows, append([]interface{}(nil), row...))
+	if len(e.rows) < ArrowBatchRows {
+		return nil
+	}
+	return e.flush()
+}
+
+func (e *arrowEncoder) Close() error {
+	if err := e.flush(); err != nil {
+		return err
+	}
+	if e.types == nil {
+		if err := e.writeSchema(); err != nil {
+			return err
+		}
+	}
+	var eos [8]byte
+	binary.LittleEndian.PutUint32(eos[:], arrowContinuation)
+	_, err := e.w.Write(eos[:])
+	return err
+}
+
+// flush writes buffered rows as record batch, schema is written before the first batch
+func (e *arrowEncoder) flush() error {
+	if len(e.rows) == 0 {
+		return nil
+	}
+	if e.types == nil {
+		if err := e.writeSchema(); err != nil {
+			return err
+		}
+	}
+
+	var (
+		body    []byte
+		nodes   [][2]int64
+		buffers [][2]int64
+	)
+	addBuffer := func(data []byte) {
+		buffers = append(buffers, [2]int64{int64(len(body)), int64(len(data))})
+		body = append(body, data...)
+		for len(body)%arrowBufferAlign != 0 {
+			body = append(body, 0)
+		}
+	}
+	for i, column := range e.types {
+		validity := make([]byte, (len(e.rows)+7)/8)
+		var nulls int64
+		var data, offsets []byte
+		if column.typ == arrowUtf8 || column.typ == arrowBinary {
+			offsets = make([]byte, 4, 4*(len(e.rows)+1))
+		}
+		if column.typ == arrowBool {
+			data = make([]byte, (len(e.rows)+7)/8)
+		}
+		for r, row := range e.rows {
+			v := row[i]
+			if v != nil {
+				validity[r/8] |= 1 << (r % 8)
+			} else {
+				nulls++
+			}
+			var err error
+			if data, err = appendArrowValue(data, column, r, v); err != nil {
+				return fmt.Errorf("ydb: column %s: %w", e.columns[i], err)
+			}
+			if offsets != nil {
+				var offset [4]byte
+				binary.LittleEndian.PutUint32(offset[:], uint32(len(data)))
+				offsets = append(offsets, offset[:]...)
+			}
+		}
+		nodes = append(nodes, [2]int64{int64(len(e.rows)), nulls})
+		addBuffer(validity)
+		if offsets != nil {
+			addBuffer(offsets)
+		}
+		addBuffer(data)
+	}
+
+	b := &flatBuilder{}
+	nodesVector := b.createStructs(nodes)
+	buffersVector := b.createStructs(buffers)
+	b.startTable()
+	b.addInt64(0, int64(len(e.rows)))
+	b.addOffset(1, nodesVector)
+	b.addOffset(2, buffersVector)
+	batch := b.endTable()
+	e.rows = e.rows[:0]
+	return e.writeMessage(b, arrowHeaderBatch, batch, body)
+}
+
+// writeSchema writes schema of columns with types inferred from buffered rows
+func (e *arrowEncoder) writeSchema() error {
+	e.types = make([]arrowColumn, len(e.columns))
+	for i := range e.columns {
+		e.types[i] = arrowColumn{typ: arrowUtf8}
+		for _, row := range e.rows {
+			if row[i] != nil {
+				e.types[i] = arrowColumnOf(row[i])
+				break
+			}
+		}
+	}
+
+	b := &flatBuilder{}
+	fields := make([]int, len(e.columns))
+	for i, name := range e.columns {
+		column := e.types[i]
+		nameString := b.createString(name)
+		children := b.createOffsets(nil)
+		var timezone int
+		if column.typ == arrowTimestamp {
+			timezone = b.createString("UTC")
+		}
+		b.startTable()
+		switch column.typ {
+		case arrowInt:
+			b.addInt32(0, 64)
+			b.addBool(1, column.signed)
+		case arrowFloat:
+			b.addInt16(0, arrowPrecisionDbl)
+		case arrowTimestamp:
+			b.addInt16(0, arrowUnitMicro)
+			b.addOffset(1, timezone)
+		case arrowDuration:
+			b.addInt16(0, arrowUnitMicro)
+		}
+		typ := b.endTable()
+
+		b.startTable()
+		b.addOffset(0, nameString)
+		b.addBool(1, true)
+		b.addByte(2, byte(column.typ))
+		b.addOffset(3, typ)
+		b.addOffset(5, children)
+		fields[i] = b.endTable()
+	}
+	fieldsVector := b.createOffsets(fields)
+	b.startTable()
+	b.addInt16(0, 0) // little endian
+	b.addOffset(arrowSchemaFields, fieldsVector)
+	schema := b.endTable()
+	return e.writeMessage(b, arrowHeaderSchema, schema, nil)
+}
+
+// writeMessage writes encapsulated message with header of type and body
+func (e *arrowEncoder) writeMessage(b *flatBuilder, headerType byte, header int, body []byte) error {
+	b.startTable()
+	b.addInt16(0, arrowMetadataV5)
+	b.addByte(1, headerType)
+	b.addOffset(2, header)
+	b.addInt64(arrowMessageFields, int64(len(body)))
+	metadata := b.finish(b.endTable())
+
+	// metadata is padded so body starts at multiple of 8
+	size := len(metadata)
+	for (8+size)%arrowBufferAlign != 0 {
+		size++
+	}
+	prefix := make([]byte, 8, 8+size+len(body))
+	binary.LittleEndian.PutUint32(prefix, arrowContinuation)
+	binary.LittleEndian.PutUint32(prefix[4:], uint32(size))
+	message := append(prefix, metadata...)
+	message = append(message, make([]byte, size-len(metadata))...)
+	message = append(message, body...)
+	_, err := e.w.Write(message)
+	return err
+}
+
+// arrowColumnOf returns type of Arrow column of value v
+func arrowColumnOf(v interface{}) arrowColumn {
+	switch v.(type) {
+	case bool:
+		return arrowColumn{typ: arrowBool}
+	case int, int8, int16, int32, int64:
+		return arrowColumn{typ: arrowInt, signed: true}
+	case uint, uint8, uint16, uint32, uint64:
+		return arrowColumn{typ: arrowInt}
+	case float32, float64:
+		return arrowColumn{typ: arrowFloat}
+	case []byte:
+		return arrowColumn{typ: arrowBinary}
+	case time.Time:
+		return arrowColumn{typ: arrowTimestamp}
+	case time.Duration:
+		return arrowColumn{typ: arrowDuration}
+	}
+	return arrowColumn{typ: arrowUtf8}
+}
+
+// appendArrowValue appends value v of row r to data buffer of column, NULLs are appended as zero
+// values of fixed size types
+func appendArrowValue(data []byte, column arrowColumn, r int, v interface{}) ([]byte, error) {
+	switch column.typ {
+	case arrowUtf8:
+		if v != nil {
+			data = append(data, csvField(v)...)
+		}
+		return data, nil
+	case arrowBinary:
+		b, ok := v.([]byte)
+		if !ok && v != nil {
+			return nil, fmt.Errorf("value %v of Binary column", v)
+		}
+		return append(data, b...), nil
+	case arrowBool:
+		b, ok := v.(bool)
+		if !ok && v != nil {
+			return nil, fmt.Errorf("value %v of Bool column", v)
+		}
+		if b {
+			data[r/8] |= 1 << (r % 8)
+		}
+		return data, nil
+	}
+
+	var bits uint64
+	switch column.typ {
+	case arrowInt:
+		rv := reflect.ValueOf(v)
+		switch kind := rv.Kind(); {
+		case v == nil:
+		case kind >= reflect.Int && kind <= reflect.Int64:
+			if !column.signed && rv.Int() < 0 {
+				return nil, fmt.Errorf("value %v of UInt64 column", v)
+			}
+			bits = uint64(rv.Int())
+		case kind >= reflect.Uint && kind <= reflect.Uint64:
+			if column.signed && rv.Uint() > math.MaxInt64 {
+				return nil, fmt.Errorf("value %v of Int64 column", v)
+			}
+			bits = rv.Uint()
+		default:
+			return nil, fmt.Errorf("value %v of Int64 column", v)
+		}
+	case arrowFloat:
+		switch f := v.(type) {
+		case nil:
+		case float32:
+			bits = math.Float64bits(float64(f))
+		case float64:
+			bits = math.Float64bits(f)
+		default:
+			return nil, fmt.Errorf("value %v of Double column", v)
+		}
+	case arrowTimestamp:
+		t, ok := v.(time.Time)
+		if !ok && v != nil {
+			return nil, fmt.Errorf("value %v of Timestamp column", v)
+		}
+		if ok {
+			bits = uint64(t.Unix()*1e6 + int64(t.Nanosecond()/1e3))
+		}
+	case arrowDuration:
+		d, ok := v.(time.Duration)
+		if !ok && v != nil {
+			return nil, fmt.Errorf("value %v of Duration column", v)
+		}
+		bits = uint64(d.Microseconds())
+	}
+	var value [8]byte
+	binary.LittleEndian.PutUint64(value[:], bits)
+	return append(data, value[:]...), nil
+}
+
+// flatBuilder builds FlatBuffers of Arrow metadata back to front like builders of flatbuffers
+// library: objects are prepended to buf, so they are referenced by offsets from its end
+type flatBuilder struct {
+	buf      []byte
+	maxAlign int
+	// offsets of fields of current table from end of buf by field id
+	fields []int
+	start  int
+}
+
+// offset returns offset of end of buf, objects are identified by offsets of their starts
+func (b *flatBuilder) offset() int {
+	return len(b.buf)
+}
+
+func (b *flatBuilder) prepend(data ...byte) {
+	b.buf = append(append(make([]byte, 0, len(data)+len(b.buf)), data...), b.buf...)
+}
+
+// align pads buf, so object of size prepended next starts at multiple of alignment
+func (b *flatBuilder) align(alignment, size int) {
+	if alignment > b.maxAlign {
+		b.maxAlign = alignment
+	}
+	for (len(b.buf)+size)%alignment != 0 {
+		b.prepend(0)
+	}
+}
+
+func (b *flatBuilder) prependUint32(v uint32) {
+	b.align(4, 4)
+	var data [4]byte
+	binary.LittleEndian.PutUint32(data[:], v)
+	b.prepend(data[:]...)
+}
+
+// prependOffset prepends offset to object at off relative to the offset itself
+func (b *flatBuilder) prependOffset(off int) {
+	b.align(4, 4)
+	b.prependUint32(uint32(b.offset() + 4 - off))
+}
+
+func (b *flatBuilder) createString(s string) int {
+	b.align(4, len(s)+1)
+	b.prepend(0)
+	b.prepend([]byte(s)...)
+	b.prependUint32(uint32(len(s)))
+	return b.offset()
+}
+
+// createOffsets creates vector of objects at offs
+func (b *flatBuilder) createOffsets(offs []int) int {
+	b.align(4, 4*len(offs))
+	for i := len(offs) - 1; i >= 0; i-- {
+		b.prependOffset(offs[i])
+	}
+	b.prependUint32(uint32(len(offs)))
+	return b.offset()
+}
+
+// createStructs creates vector of structs of two longs (FieldNode and Buffer of Arrow)
+func (b *flatBuilder) createStructs(structs [][2]int64) int {
+	b.align(8, 16*len(structs))
+	for i := len(structs) - 1; i >= 0; i-- {
+		var data [16]byte
+		binary.LittleEndian.PutUint64(data[:], uint64(structs[i][0]))
+		binary.LittleEndian.PutUint64(data[8:], uint64(structs[i][1]))
+		b.prepend(data[:]...)
+	}
+	b.prependUint32(uint32(len(structs)))
+	return b.offset()
+}
+
+func (b *flatBuilder) startTable() {
+	b.fields = b.fields[:0]
+	b.start = b.offset()
+}
+
+// field records offset of field id prepended last
+func (b *flatBuilder) field(id int) {
+	for len(b.fields) <= id {
+		b.fields = append(b.fields, 0)
+	}
+	b.fields[id] = b.offset()
+}
+
+func (b *flatBuilder) addScalar(id int, data []byte) {
+	b.align(len(data), len(data))
+	b.prepend(data...)
+	b.field(id)
+}
+
+func (b *flatBuilder) addByte(id int, v byte) {
+	b.addScalar(id, []byte{v})
+}
+
+func (b *flatBuilder) addBool(id int, v bool) {
+	if v {
+		b.addByte(id, 1)
+	} else {
+		b.addByte(id, 0)
+	}
+}
+
+func (b *flatBuilder) addInt16(id int, v int16) {
+	var data [2]byte
+	binary.LittleEndian.PutUint16(data[:], uint16(v))
+	b.addScalar(id, data[:])
+}
+
+func (b *flatBuilder) addInt32(id int, v int32) {
+	var data [4]byte
+	binary.LittleEndian.PutUint32(data[:], uint32(v))
+	b.addScalar(id, data[:])
+}
+
+func (b *flatBuilder) addInt64(id int, v int64) {
+	var data [8]byte
+	binary.LittleEndian.PutUint64(data[:], uint64(v))
+	b.addScalar(id, data[:])
+}
+
+func (b *flatBuilder) addOffset(id int, off int) {
+	b.prependOffset(off)
+	b.field(id)
+}
+
+// endTable prepends offset of vtable and vtable of current table and returns table
+func (b *flatBuilder) endTable() int {
+	b.prependUint32(0)
+	table := b.offset()
+
+	vtable := make([]byte, 4+2*len(b.fields))
+	binary.LittleEndian.PutUint16(vtable, uint16(len(vtable)))
+	binary.LittleEndian.PutUint16(vtable[2:], uint16(table-b.start))
+	for id, field := range b.fields {
+		if field != 0 {
+			binary.LittleEndian.PutUint16(vtable[4+2*id:], uint16(table-field))
+		}
+	}
+	b.prepend(vtable...)
+	// vtable precedes table, so offset of table to it is positive
+	binary.LittleEndian.PutUint32(b.buf[b.offset()-table:], uint32(b.offset()-table))
+	return table
+}
+
+// finish prepends offset of root table and returns the buffer
+func (b *flatBuilder) finish(root int) []byte {
+	b.align(b.maxAlign, 4)
+	b.prependOffset(root)
+	return b.buf
+}
@@ -0,0 +1,310 @@
+package ydb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// flatTable is table of FlatBuffer read by tests independently of flatBuilder
+type flatTable struct {
+	buf []byte
+	pos int
+}
+
+func flatRoot(buf []byte) flatTable {
+	return flatTable{buf, int(binary.LittleEndian.Uint32(buf))}
+}
+
+// field returns position of field id or 0 when it is absent
+func (t flatTable) field(id int) int {
+	vtable := t.pos - int(int32(binary.LittleEndian.Uint32(t.buf[t.pos:])))
+	if 4+2*id >= int(binary.LittleEndian.Uint16(t.buf[vtable:])) {
+		return 0
+	}
+	if off := int(binary.LittleEndian.Uint16(t.buf[vtable+4+2*id:])); off != 0 {
+		return t.pos + off
+	}
+	return 0
+}
+
+func (t flatTable) scalar(id, size int) uint64 {
+	pos := t.field(id)
+	if pos == 0 {
+		return 0
+	}
+	var v [8]byte
+	copy(v[:], t.buf[pos:pos+size])
+	return binary.LittleEndian.Uint64(v[:])
+}
+
+func (t flatTable) deref(id int) int {
+	pos := t.field(id)
+	return pos + int(binary.LittleEndian.Uint32(t.buf[pos:]))
+}
+
+func (t flatTable) table(id int) flatTable {
+	return flatTable{t.buf, t.deref(id)}
+}
+
+func (t flatTable) string(id int) string {
+	pos := t.deref(id)
+	return string(t.buf[pos+4 : pos+4+int(binary.LittleEndian.Uint32(t.buf[pos:]))])
+}
+
+// vector returns position of elements and length of vector field id
+func (t flatTable) vector(id int) (int, int) {
+	pos := t.deref(id)
+	return pos + 4, int(binary.LittleEndian.Uint32(t.buf[pos:]))
+}
+
+// arrowMessage is message of Arrow IPC stream
+type arrowMessage struct {
+	header     flatTable
+	headerType byte
+	body       []byte
+}
+
+func readArrowStream(t *testing.T, stream []byte) []arrowMessage {
+	t.Helper()
+	var messages []arrowMessage
+	for {
+		if len(stream) < 8 || binary.LittleEndian.Uint32(stream) != 0xFFFFFFFF {
+			t.Fatalf("no continuation marker at %x", stream)
+		}
+		size := int(binary.LittleEndian.Uint32(stream[4:]))
+		if size == 0 {
+			if len(stream) != 8 {
+				t.Fatalf("%d bytes after end of stream", len(stream)-8)
+			}
+			return messages
+		}
+		if (8+size)%8 != 0 {
+			t.Fatalf("metadata of %d bytes isn't padded", size)
+		}
+		message := flatRoot(stream[8 : 8+size])
+		if version := message.scalar(0, 2); version != 4 {
+			t.Fatalf("version %d, want V5", version)
+		}
+		bodyLength := int(message.scalar(3, 8))
+		messages = append(messages, arrowMessage{
+			header:     message.table(2),
+			headerType: byte(message.scalar(1, 1)),
+			body:       stream[8+size : 8+size+bodyLength],
+		})
+		stream = stream[8+size+bodyLength:]
+	}
+}
+
+// arrowField is field of Arrow schema, unit is of Timestamp and Duration
+type arrowField struct {
+	name     string
+	typ      arrowType
+	bitWidth int
+	signed   bool
+	unit     int
+	timezone string
+}
+
+func readArrowSchema(t *testing.T, message arrowMessage) []arrowField {
+	t.Helper()
+	if message.headerType != arrowHeaderSchema {
+		t.Fatalf("header type %d, want Schema", message.headerType)
+	}
+	schema := message.header
+	pos, n := schema.vector(1)
+	fields := make([]arrowField, n)
+	for i := range fields {
+		at := pos + 4*i
+		field := flatTable{schema.buf, at + int(binary.LittleEndian.Uint32(schema.buf[at:]))}
+		if field.scalar(1, 1) != 1 {
+			t.Errorf("field %d isn't nullable", i)
+		}
+		if _, children := field.vector(5); children != 0 {
+			t.Errorf("field %d has %d children", i, children)
+		}
+		fields[i] = arrowField{name: field.string(0), typ: arrowType(field.scalar(2, 1))}
+		typ := field.table(3)
+		switch fields[i].typ {
+		case arrowInt:
+			fields[i].bitWidth = int(typ.scalar(0, 4))
+			fields[i].signed = typ.scalar(1, 1) == 1
+		case arrowFloat:
+			fields[i].bitWidth = 16 << typ.scalar(0, 2)
+		case arrowTimestamp:
+			fields[i].unit = int(typ.scalar(0, 2))
+			fields[i].timezone = typ.string(1)
+		case arrowDuration:
+			fields[i].unit = int(typ.scalar(0, 2))
+		}
+	}
+	return fields
+}
+
+// readArrowBatch returns columns of values of record batch message with fields
+func readArrowBatch(t *testing.T, message arrowMessage, fields []arrowField) [][]interface{} {
+	t.Helper()
+	if message.headerType != arrowHeaderBatch {
+		t.Fatalf("header type %d, want RecordBatch", message.headerType)
+	}
+	batch := message.header
+	length := int(batch.scalar(0, 8))
+	nodes, _ := batch.vector(1)
+	buffers, _ := batch.vector(2)
+	buffer := func() []byte {
+		offset := binary.LittleEndian.Uint64(batch.buf[buffers:])
+		size := binary.LittleEndian.Uint64(batch.buf[buffers+8:])
+		buffers += 16
+		if offset%8 != 0 {
+			t.Errorf("buffer at %d isn't aligned", offset)
+		}
+		return message.body[offset : offset+size]
+	}
+	columns := make([][]interface{}, len(fields))
+	for i, field := range fields {
+		if n := int(binary.LittleEndian.Uint64(batch.buf[nodes+16*i:])); n != length {
+			t.Fatalf("column %s of %d values, want %d", field.name, n, length)
+		}
+		validity := buffer()
+		var offsets []byte
+		if field.typ == arrowUtf8 || field.typ == arrowBinary {
+			offsets = buffer()
+		}
+		data := buffer()
+		for r := 0; r < length; r++ {
+			if validity[r/8]&(1<<(r%8)) == 0 {
+				columns[i] = append(columns[i], nil)
+				continue
+			}
+			var v interface{}
+			switch field.typ {
+			case arrowUtf8, arrowBinary:
+				value := data[binary.LittleEndian.Uint32(offsets[4*r:]):binary.LittleEndian.Uint32(offsets[4*r+4:])]
+				if field.typ == arrowUtf8 {
+					v = string(value)
+				} else {
+					v = append([]byte(nil), value...)
+				}
+			case arrowBool:
+				v = data[r/8]&(1<<(r%8)) != 0
+			case arrowInt:
+				if field.signed {
+					v = int64(binary.LittleEndian.Uint64(data[8*r:]))
+				} else {
+					v = binary.LittleEndian.Uint64(data[8*r:])
+				}
+			case arrowFloat:
+				v = math.Float64frombits(binary.LittleEndian.Uint64(data[8*r:]))
+			case arrowTimestamp:
+				micros := int64(binary.LittleEndian.Uint64(data[8*r:]))
+				v = time.Unix(0, micros*1e3).UTC()
+			case arrowDuration:
+				v = time.Duration(binary.LittleEndian.Uint64(data[8*r:])) * time.Microsecond
+			}
+			columns[i] = append(columns[i], v)
+		}
+	}
+	return columns
+}
+
+func TestArrow(t *testing.T) {
+	defer func(rows int) { ArrowBatchRows = rows }(ArrowBatchRows)
+	ArrowBatchRows = 2
+
+	at := time.Date(2024, 3, 1, 12, 30, 0, 123456000, time.UTC)
+	columns := []string{"id", "count", "flag", "price", "name", "data", "at", "ttl", "empty"}
+	rows := [][]interface{}{
+		{int32(1), uint64(10), true, 1.5, "a", []byte{1}, at, time.Second, nil},
+		{int64(-2), nil, false, float32(0.5), nil, []byte{}, nil, nil, nil},
+		{int8(3), uint8(3), nil, nil, "c", nil, at.Add(time.Hour), time.Millisecond, nil},
+	}
+	var stream bytes.Buffer
+	encoder, err := Arrow(&stream, columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		if err := encoder.Encode(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	messages := readArrowStream(t, stream.Bytes())
+	if len(messages) != 3 {
+		t.Fatalf("%d messages, want schema and 2 record batches", len(messages))
+	}
+	fields := readArrowSchema(t, messages[0])
+	wantFields := []arrowField{
+		{name: "id", typ: arrowInt, bitWidth: 64, signed: true},
+		{name: "count", typ: arrowInt, bitWidth: 64},
+		{name: "flag", typ: arrowBool},
+		{name: "price", typ: arrowFloat, bitWidth: 64},
+		{name: "name", typ: arrowUtf8},
+		{name: "data", typ: arrowBinary},
+		{name: "at", typ: arrowTimestamp, unit: arrowUnitMicro, timezone: "UTC"},
+		{name: "ttl", typ: arrowDuration, unit: arrowUnitMicro},
+		{name: "empty", typ: arrowUtf8},
+	}
+	if !reflect.DeepEqual(fields, wantFields) {
+		t.Fatalf("schema %+v, want %+v", fields, wantFields)
+	}
+
+	got := readArrowBatch(t, messages[1], fields)
+	for i, column := range readArrowBatch(t, messages[2], fields) {
+		got[i] = append(got[i], column...)
+	}
+	want := [][]interface{}{
+		{int64(1), int64(-2), int64(3)},
+		{uint64(10), nil, uint64(3)},
+		{true, false, nil},
+		{1.5, 0.5, nil},
+		{"a", nil, "c"},
+		{[]byte{1}, []byte(nil), nil},
+		{at, nil, at.Add(time.Hour)},
+		{time.Second, nil, time.Millisecond},
+		{nil, nil, nil},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("columns %v, want %v", got, want)
+	}
+}
+
+func TestArrowEmpty(t *testing.T) {
+	var stream bytes.Buffer
+	encoder, err := Arrow(&stream, []string{"id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := encoder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	messages := readArrowStream(t, stream.Bytes())
+	if len(messages) != 1 {
+		t.Fatalf("%d messages, want schema", len(messages))
+	}
+	if fields := readArrowSchema(t, messages[0]); len(fields) != 1 || fields[0].name != "id" || fields[0].typ != arrowUtf8 {
+		t.Errorf("schema %+v, want id of Utf8", fields)
+	}
+}
+
+func TestArrowMismatch(t *testing.T) {
+	defer func(rows int) { ArrowBatchRows = rows }(ArrowBatchRows)
+	ArrowBatchRows = 1
+
+	encoder, err := Arrow(&bytes.Buffer{}, []string{"id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := encoder.Encode([]interface{}{int64(1)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := encoder.Encode([]interface{}{"two"}); err == nil {
+		t.Error("string value of Int64 column is encoded")
+	}
+}
@@ -0,0 +1,361 @@
+package ydb
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+var (
+	// ErrAuditClosed passed to Audit.OnError for changes made after Close, their records are dropped
+	ErrAuditClosed = errors.New("ydb: audit is closed")
+	// ErrAuditQueueFull passed to Audit.OnError for changes whose context was done while queue of
+	// records was full, their records are dropped
+	ErrAuditQueueFull = errors.New("ydb: audit queue is full")
+)
+
+type actorKey struct{}
+
+// WithActor returns context of changes made by actor, e.g. id of authenticated user
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns actor of context set by WithActor
+func ActorFromContext(ctx context.Context) (actor string, ok bool) {
+	if ctx == nil {
+		return "", false
+	}
+	actor, ok = ctx.Value(actorKey{}).(string)
+	return actor, ok
+}
+
+// AuditRecord is a row of audit table describing change of one row
+type AuditRecord struct {
+	ID        string `gorm:"primaryKey"`
+	TableName string
+	// Operation is create, update or delete
+	Operation string
+	Actor     string
+	// Before is image of row before change, nil for creates
+	Before AuditImage `gorm:"type:JsonDocument"`
+	// After is image of row after change, nil for deletes
+	After     AuditImage `gorm:"type:JsonDocument"`
+	CreatedAt time.Time
+}
+
+// AuditImage is row image of audit record by column names, stored as JsonDocument
+type AuditImage map[string]interface{}
+
+func (image AuditImage) Value() (driver.Value, error) {
+	if image == nil {
+		return types.NullValue(types.TypeJSONDocument), nil
+	}
+	data, err := json.Marshal(map[string]interface{}(image))
+	if err != nil {
+		return nil, err
+	}
+	return types.OptionalValue(types.JSONDocumentValueFromBytes(data)), nil
+}
+
+func (image *AuditImage) Scan(src interface{}) error {
+	*image = nil
+	if src == nil {
+		return nil
+	}
+	return decodeJSON(src, (*map[string]interface{})(image))
+}
+
+// Audit is a gorm plugin recording changes of rows made by creates, updates and deletes into
+// audit table with actor of context (see WithActor). Images of updated and deleted rows are
+// read before the statement by its conditions and after it by primary keys, so such statements
+// cost two more queries (images after are missing for tables without primary key), values of
+// fields tagged with sensitive are masked. Records are written asynchronously in batches after
+// successful statements, also of transactions rolled back later. Statements wait while queue of
+// records is full, so slow writes of records slow down changes, records of statements whose
+// context is done meanwhile are dropped. Raw statements (Exec) are not audited
+//
+//	audit := &ydb.Audit{}
+//	db.Use(audit)
+//	defer audit.Close()
+//	db.WithContext(ydb.WithActor(ctx, "alice")).Delete(&user)
+type Audit struct {
+	// Table of audit records created by migrator on initialization, defaults to _audit
+	Table string
+	// BatchSize max count of records written by one statement, defaults to 100
+	BatchSize int
+	// FlushInterval max delay of records written by not full batch, defaults to one second
+	FlushInterval time.Duration
+	// QueueSize max count of records waiting for writing, changes wait while queue is full
+	// until their context is done, defaults to 1024
+	QueueSize int
+	// OnError called with errors of writing records and of reading row images
+	OnError func(err error)
+
+	db     *gorm.DB
+	mu     sync.RWMutex
+	closed bool
+	queue  chan AuditRecord
+	done   chan struct{}
+}
+
+const auditKey = "ydb:audit"
+
+func (a *Audit) Name() string {
+	return auditKey
+}
+
+func (a *Audit) Initialize(db *gorm.DB) error {
+	if a.Table == "" {
+		a.Table = "_audit"
+	}
+	if a.BatchSize <= 0 {
+		a.BatchSize = 100
+	}
+	if a.FlushInterval <= 0 {
+		a.FlushInterval = time.Second
+	}
+	if a.QueueSize <= 0 {
+		a.QueueSize = 1024
+	}
+	a.db = db.Session(&gorm.Session{NewDB: true, SkipHooks: true, SkipDefaultTransaction: true})
+	if !db.DryRun {
+		if err := a.db.Table(a.Table).AutoMigrate(&AuditRecord{}); err != nil {
+			return err
+		}
+	}
+	a.queue = make(chan AuditRecord, a.QueueSize)
+	a.done = make(chan struct{})
+	go a.write()
+
+	callback := db.Callback()
+	if err := callback.Create().After("gorm:create").Register(auditKey, a.created); err != nil {
+		return err
+	}
+	if update := callback.Update().Get("gorm:update"); update != nil {
+		if err := callback.Update().Replace("gorm:update", a.changed("update", update)); err != nil {
+			return err
+		}
+	}
+	if del := callback.Delete().Get("gorm:delete"); del != nil {
+		if err := callback.Delete().Replace("gorm:delete", a.changed("delete", del)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close stops accepting records and waits until queued records are written
+func (a *Audit) Close() {
+	a.mu.Lock()
+	if !a.closed && a.queue != nil {
+		close(a.queue)
+	}
+	a.closed = true
+	a.mu.Unlock()
+	if a.done != nil {
+		<-a.done
+	}
+}
+
+func (a *Audit) audited(db *gorm.DB) bool {
+	return db.Error == nil && !db.DryRun && db.Statement.Table != "" && db.Statement.Table != a.Table
+}
+
+// created records images of rows inserted by values of statement
+func (a *Audit) created(db *gorm.DB) {
+	if !a.audited(db) {
+		return
+	}
+	values, ok := db.Statement.Clauses["VALUES"].Expression.(clause.Values)
+	if !ok {
+		return
+	}
+	for _, v := range values.Values {
+		after := make(AuditImage, len(values.Columns))
+		for i, column := range values.Columns {
+			after[column.Name] = auditValue(v[i])
+		}
+		a.enqueue(db, "create", nil, after)
+	}
+}
+
+// changed records images of rows matching conditions of write read before and after it
+func (a *Audit) changed(operation string, write func(*gorm.DB)) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if !a.audited(db) || db.Statement.SQL.Len() > 0 {
+			write(db)
+			return
+		}
+		dry := dryRun(db, write)
+		if dry == nil {
+			write(db)
+			return
+		}
+
+		tx := db.Session(&gorm.Session{NewDB: true}).Table(db.Statement.Table)
+		query := tx
+		if where, ok := dry.Statement.Clauses["WHERE"]; ok {
+			query = query.Clauses(where.Expression)
+		}
+		var before []map[string]interface{}
+		if err := query.Find(&before).Error; err != nil {
+			db.AddError(err)
+			return
+		}
+
+		write(db)
+		if db.Error != nil || len(before) == 0 {
+			return
+		}
+
+		var primaryKeys []string
+		if db.Statement.Schema != nil {
+			primaryKeys = db.Statement.Schema.PrimaryFieldDBNames
+		}
+		afterByKey := map[string]map[string]interface{}{}
+		if len(primaryKeys) > 0 {
+			var after []map[string]interface{}
+			if err := tx.Clauses(columnsCondition(primaryKeys, before)).Find(&after).Error; err != nil {
+				a.reportError(err)
+			}
+			for _, row := range after {
+				afterByKey[rowKey(primaryKeys, row)] = row
+			}
+		}
+
+		for _, row := range before {
+			var afterImage AuditImage
+			if changed, ok := afterByKey[rowKey(primaryKeys, row)]; ok {
+				afterImage = rowImage(db.Statement.Schema, changed)
+			}
+			a.enqueue(db, operation, rowImage(db.Statement.Schema, row), afterImage)
+		}
+	}
+}
+
+func (a *Audit) enqueue(db *gorm.DB, operation string, before, after AuditImage) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		a.reportError(err)
+		return
+	}
+	actor, _ := ActorFromContext(db.Statement.Context)
+	record := AuditRecord{
+		ID:        hex.EncodeToString(id),
+		TableName: db.Statement.Table,
+		Operation: operation,
+		Actor:     actor,
+		Before:    before,
+		After:     after,
+		CreatedAt: time.Now(),
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		a.reportError(ErrAuditClosed)
+		return
+	}
+	select {
+	case a.queue <- record:
+	case <-db.Statement.Context.Done():
+		a.reportError(fmt.Errorf("%w: %s of %s dropped: %v", ErrAuditQueueFull, operation, record.TableName,
+			db.Statement.Context.Err()))
+	}
+}
+
+// write writes queued records by batches of BatchSize or records queued for FlushInterval
+func (a *Audit) write() {
+	defer close(a.done)
+	ticker := time.NewTicker(a.FlushInterval)
+	defer ticker.Stop()
+	batch := make([]AuditRecord, 0, a.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if err := a.db.WithContext(ctx).Table(a.Table).Create(&batch).Error; err != nil {
+			a.reportError(fmt.Errorf("ydb: failed to write %d audit records: %w", len(batch), err))
+		}
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case record, ok := <-a.queue:
+			if !ok {
+				flush()
+				return
+			}
+			if batch = append(batch, record); len(batch) >= a.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func (a *Audit) reportError(err error) {
+	if a.OnError != nil {
+		a.OnError(err)
+	}
+}
+
+func rowKey(primaryKeys []string, row map[string]interface{}) string {
+	key := make([]string, 0, len(primaryKeys))
+	for _, primaryKey := range primaryKeys {
+		key = append(key, fmt.Sprintf("%#v", row[primaryKey]))
+	}
+	return strings.Join(key, ",")
+}
+
+// rowImage returns image of read row with values of sensitive fields masked
+func rowImage(s *schema.Schema, row map[string]interface{}) AuditImage {
+	image := make(AuditImage, len(row))
+	for column, v := range row {
+		if s != nil {
+			if field := s.LookUpField(column); field != nil && isSensitiveField(field) && v != nil {
+				v = maskedValue
+			}
+		}
+		image[column] = v
+	}
+	return image
+}
+
+// auditValue returns value of parameter encoded as JSON in row images
+func auditValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case sensitive:
+		return maskedValue
+	case jsonValue:
+		return v.value
+	case types.Value:
+		return v.Yql()
+	case driver.Valuer:
+		value, err := v.Value()
+		if err != nil {
+			return nil
+		}
+		if _, ok := value.(driver.Valuer); ok {
+			return value
+		}
+		return auditValue(value)
+	}
+	return v
+}
@@ -0,0 +1,94 @@
+package ydb
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// registerAutocommitCallbacks replaces default transaction of gorm around single write statement,
+// so the statement is executed with commit_tx in one round trip instead of interactive transaction.
+// Default transaction is kept for writes of several statements (associations, hooks, exact
+// RowsAffected, cascades, audit, history, aggregates, outbox events, idempotent creates,
+// creates checking unique indexes, conditional updates) to keep them atomic. Chunks of creates
+// split by MaxBatchRows and MaxBatchBytes aren't a reason for it, each chunk is committed by
+// its own statement, so creates of many rows don't exceed limits of single transaction
+func (dialector Dialector) registerAutocommitCallbacks(db *gorm.DB) error {
+	callback := db.Callback()
+	if begin := callback.Create().Get("gorm:begin_transaction"); begin != nil {
+		if err := callback.Create().Replace("gorm:begin_transaction", dialector.autocommit(begin, true)); err != nil {
+			return err
+		}
+	}
+	if begin := callback.Update().Get("gorm:begin_transaction"); begin != nil {
+		if err := callback.Update().Replace("gorm:begin_transaction", dialector.autocommit(begin, false)); err != nil {
+			return err
+		}
+	}
+	if begin := callback.Delete().Get("gorm:begin_transaction"); begin != nil {
+		if err := callback.Delete().Replace("gorm:begin_transaction", dialector.autocommit(begin, false)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (dialector Dialector) autocommit(begin func(*gorm.DB), create bool) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Error == nil && dialector.singleStatement(db, create) {
+			return
+		}
+		begin(db)
+	}
+}
+
+// singleStatement reports whether write of db is executed by single statement, or by statements
+// of chunks committed separately
+func (dialector Dialector) singleStatement(db *gorm.DB, create bool) bool {
+	if !create && dialector.RowsAffected == RowsAffectedExact {
+		return false
+	}
+	if _, ok := db.Config.Plugins[Cascade{}.Name()]; ok && !create {
+		return false
+	}
+	if _, ok := db.Config.Plugins[auditKey]; ok && !create {
+		return false
+	}
+	if _, ok := db.Config.Plugins[historyKey]; ok && !create && isVersioned(db.Statement.Schema) {
+		return false
+	}
+	if aggregates, ok := db.Config.Plugins[aggregatesKey].(*Aggregates); ok && aggregates.aggregated(db) {
+		return false
+	}
+	if _, ok := db.Config.Plugins[outboxKey]; ok && emitsOutboxEvents(db.Statement.Schema) {
+		return false
+	}
+	if _, ok := db.Statement.Context.Value(idempotentKey{}).(string); ok && create {
+		return false
+	}
+	if conditional, _ := db.Statement.Context.Value(updateIfKey{}).(bool); conditional && !create {
+		return false
+	}
+	if _, ok := db.Config.Plugins[uniqueIndexesKey]; ok && create && db.Statement.Schema != nil &&
+		len(uniqueIndexes(db.Statement.Schema)) > 0 {
+		return false
+	}
+
+	s := db.Statement.Schema
+	if s == nil {
+		return true
+	}
+	if !db.Statement.SkipHooks && (s.BeforeCreate || s.AfterCreate || s.BeforeUpdate || s.AfterUpdate ||
+		s.BeforeSave || s.AfterSave || s.BeforeDelete || s.AfterDelete) {
+		return false
+	}
+	return len(s.Relationships.Relations) == 0 || omitsAssociations(db.Statement)
+}
+
+func omitsAssociations(stmt *gorm.Statement) bool {
+	for _, omit := range stmt.Omits {
+		if omit == clause.Associations {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,245 @@
+package ydb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/retry"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// BatchProgress called after every committed batch with total count of processed rows
+type BatchProgress func(processed int64)
+
+// DeleteInBatches deletes rows of value's model matching db conditions by batches of batchSize
+// primary keys, so purge of large ranges doesn't exceed per transaction limits
+//
+//	ydb.DeleteInBatches(ctx, db.Where("created_at < ?", deadline), &Event{}, 1000, nil)
+func DeleteInBatches(ctx context.Context, db *gorm.DB, value interface{}, batchSize int, progress BatchProgress) (deleted int64, err error) {
+	if batchSize <= 0 {
+		return 0, fmt.Errorf("ydb: invalid batch size %d", batchSize)
+	}
+
+	stmt, err := parseStatement(db, value)
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		var keys []map[string]interface{}
+		err = retry.Retry(ctx, func(ctx context.Context) error {
+			keys = keys[:0]
+			if err := db.WithContext(ctx).Model(value).Select(stmt.Schema.PrimaryFieldDBNames).
+				Limit(batchSize).Find(&keys).Error; err != nil {
+				return err
+			}
+			if len(keys) == 0 {
+				return nil
+			}
+			return db.Session(&gorm.Session{NewDB: true, Context: ctx}).
+				Where(primaryKeysCondition(stmt.Schema, keys)).Delete(value).Error
+		}, retry.WithIdempotent(true))
+		if err != nil {
+			return deleted, err
+		}
+		if len(keys) == 0 {
+			return deleted, nil
+		}
+
+		deleted += int64(len(keys))
+		if progress != nil {
+			progress(deleted)
+		}
+		if len(keys) < batchSize {
+			return deleted, nil
+		}
+	}
+}
+
+// UpdateInBatches updates rows of value's model matching db conditions with values (as for gorm Updates)
+// walking primary key order by chunks of batchSize rows, every chunk is separate transaction retried
+// on aborts, so transaction size stays bounded on large key ranges. Progress and returned count
+// sum RowsAffected of UPDATEs of chunks, which are exact in RowsAffectedExact mode
+//
+//	ydb.UpdateInBatches(ctx, db.Where("status = ?", "new"), &Order{}, map[string]interface{}{"status": "archived"}, 1000, nil)
+func UpdateInBatches(ctx context.Context, db *gorm.DB, value interface{}, values interface{}, batchSize int, progress BatchProgress) (updated int64, err error) {
+	if batchSize <= 0 {
+		return 0, fmt.Errorf("ydb: invalid batch size %d", batchSize)
+	}
+
+	stmt, err := parseStatement(db, value)
+	if err != nil {
+		return 0, err
+	}
+
+	orderBy := clause.OrderBy{}
+	for _, field := range stmt.Schema.PrimaryFields {
+		orderBy.Columns = append(orderBy.Columns, clause.OrderByColumn{
+			Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName},
+		})
+	}
+
+	var last map[string]interface{}
+	for {
+		var (
+			keys     []map[string]interface{}
+			affected int64
+		)
+		err = retry.Retry(ctx, func(ctx context.Context) error {
+			keys, affected = keys[:0], 0
+			tx := db.WithContext(ctx).Model(value).Select(stmt.Schema.PrimaryFieldDBNames).Clauses(orderBy).Limit(batchSize)
+			if last != nil {
+				tx = tx.Where(keysetCondition(stmt.Schema, last))
+			}
+			if err := tx.Find(&keys).Error; err != nil {
+				return err
+			}
+			if len(keys) == 0 {
+				return nil
+			}
+			tx = db.Session(&gorm.Session{NewDB: true, Context: ctx}).Model(value).
+				Where(primaryKeysCondition(stmt.Schema, keys)).Updates(values)
+			affected = tx.RowsAffected
+			return tx.Error
+		}, retry.WithIdempotent(false))
+		if err != nil {
+			return updated, err
+		}
+		if len(keys) == 0 {
+			return updated, nil
+		}
+
+		updated += affected
+		if progress != nil {
+			progress(updated)
+		}
+		if len(keys) < batchSize {
+			return updated, nil
+		}
+		last = keys[len(keys)-1]
+	}
+}
+
+// chunkedCreate splits slice values of create statement into chunks limited by Config.MaxBatchRows
+// and Config.MaxBatchBytes, executing create for every chunk. Chunks are committed one by one
+// unless create is in transaction, default transaction isn't begun for them by autocommit
+func (dialector Dialector) chunkedCreate(create func(*gorm.DB)) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		rv := db.Statement.ReflectValue
+		// dry run builds single statement, see UpsertAll
+		if db.Error != nil || db.DryRun || db.Statement.SQL.Len() > 0 || (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array) {
+			create(db)
+			return
+		}
+
+		bounds := dialector.batchBounds(db.Statement, rv)
+		if len(bounds) <= 1 {
+			create(db)
+			return
+		}
+
+		var rowsAffected int64
+		for i, end := range bounds {
+			start := 0
+			if i > 0 {
+				start = bounds[i-1]
+			}
+			db.Statement.ReflectValue = rv.Slice(start, end)
+			db.Statement.SQL.Reset()
+			db.Statement.Vars = nil
+			db.RowsAffected = 0
+			create(db)
+			rowsAffected += db.RowsAffected
+			if db.Error != nil {
+				break
+			}
+		}
+		db.Statement.ReflectValue = rv
+		db.RowsAffected = rowsAffected
+	}
+}
+
+// batchBounds returns exclusive end indexes of chunks
+func (dialector Dialector) batchBounds(stmt *gorm.Statement, rv reflect.Value) (bounds []int) {
+	var rows, size int
+	for i := 0; i < rv.Len(); i++ {
+		var rowSize int
+		if dialector.MaxBatchBytes > 0 && stmt.Schema != nil {
+			elem := rv.Index(i)
+			for _, field := range stmt.Schema.Fields {
+				if field.DBName == "" || !field.Creatable {
+					continue
+				}
+				if v, isZero := field.ValueOf(stmt.Context, elem); !isZero {
+					rowSize += approximateSize(v)
+				}
+			}
+		}
+
+		if rows > 0 && ((dialector.MaxBatchRows > 0 && rows+1 > dialector.MaxBatchRows) ||
+			(dialector.MaxBatchBytes > 0 && size+rowSize > dialector.MaxBatchBytes)) {
+			bounds = append(bounds, i)
+			rows, size = 0, 0
+		}
+		rows++
+		size += rowSize
+	}
+	return append(bounds, rv.Len())
+}
+
+func parseStatement(db *gorm.DB, value interface{}) (*gorm.Statement, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(value); err != nil {
+		return nil, err
+	}
+	if len(stmt.Schema.PrimaryFields) == 0 {
+		return nil, fmt.Errorf("%w: %s", errMissingPrimaryKey, stmt.Schema.Name)
+	}
+	return stmt, nil
+}
+
+var errMissingPrimaryKey = errors.New("ydb: primary key required")
+
+// primaryKeysCondition builds condition matching any of rows by primary key
+func primaryKeysCondition(s *schema.Schema, rows []map[string]interface{}) clause.Expression {
+	return columnsCondition(s.PrimaryFieldDBNames, rows)
+}
+
+// columnsCondition builds condition matching any of rows by values of columns
+func columnsCondition(columns []string, rows []map[string]interface{}) clause.Expression {
+	if len(columns) == 1 {
+		values := make([]interface{}, 0, len(rows))
+		for _, row := range rows {
+			values = append(values, row[columns[0]])
+		}
+		return clause.IN{Column: clause.Column{Table: clause.CurrentTable, Name: columns[0]}, Values: values}
+	}
+
+	exprs := make([]clause.Expression, 0, len(rows))
+	for _, row := range rows {
+		eqs := make([]clause.Expression, 0, len(columns))
+		for _, column := range columns {
+			eqs = append(eqs, clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: column}, Value: row[column]})
+		}
+		exprs = append(exprs, clause.And(eqs...))
+	}
+	return clause.Or(exprs...)
+}
+
+// keysetCondition builds condition matching rows with primary key greater than row's one
+func keysetCondition(s *schema.Schema, row map[string]interface{}) clause.Expression {
+	exprs := make([]clause.Expression, 0, len(s.PrimaryFields))
+	for i, field := range s.PrimaryFields {
+		eqs := make([]clause.Expression, 0, i+1)
+		for _, prev := range s.PrimaryFields[:i] {
+			eqs = append(eqs, clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: prev.DBName}, Value: row[prev.DBName]})
+		}
+		eqs = append(eqs, clause.Gt{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: row[field.DBName]})
+		exprs = append(exprs, clause.And(eqs...))
+	}
+	return clause.Or(exprs...)
+}
@@ -0,0 +1,50 @@
+package ydb
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+)
+
+// Fields of big.Int and *big.Int types are kept in DyNumber columns, which keep integers of up
+// to 38 digits ordered by value. gorm can't infer data type of struct types, so such fields
+// require type tag
+//
+//	type Account struct {
+//		ID      uint64
+//		Balance *big.Int `gorm:"type:DyNumber"`
+//	}
+func init() {
+	RegisterTypeMapping(big.Int{}, "DyNumber", encodeBigInt, decodeBigInt)
+}
+
+func encodeBigInt(v interface{}) (interface{}, error) {
+	i := v.(big.Int)
+	return types.DyNumberValue(i.String()), nil
+}
+
+// decodeBigInt decodes DyNumber, text and integer columns into *big.Int
+func decodeBigInt(src interface{}, dst interface{}) error {
+	i := dst.(*big.Int)
+	switch v := src.(type) {
+	case int64:
+		i.SetInt64(v)
+	case uint64:
+		i.SetUint64(v)
+	case int32, int16, int8, uint32, uint16, uint8:
+		i.SetString(fmt.Sprint(v), 10)
+	case []byte:
+		return decodeBigInt(string(v), dst)
+	case string:
+		// DyNumber is returned in scientific notation, e.g. .12345e5
+		r, ok := new(big.Rat).SetString(v)
+		if !ok || !r.IsInt() {
+			return fmt.Errorf("ydb: %q is not an integer", v)
+		}
+		i.Set(r.Num())
+	default:
+		return fmt.Errorf("ydb: unsupported value %T of big.Int", src)
+	}
+	return nil
+}
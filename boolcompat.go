@@ -0,0 +1,50 @@
+package ydb
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+	"gorm.io/gorm/schema"
+)
+
+// isUint8BoolField reports whether bool field is mapped to Uint8 column by type tag
+func isUint8BoolField(field *schema.Field) bool {
+	return field.IndirectFieldType != nil && field.IndirectFieldType.Kind() == reflect.Bool &&
+		strings.EqualFold(string(field.DataType), "Uint8")
+}
+
+// patchUint8BoolField makes values of bool field Uint8
+func patchUint8BoolField(field *schema.Field) {
+	valueOf := field.ValueOf
+	field.ValueOf = func(ctx context.Context, rv reflect.Value) (interface{}, bool) {
+		v, isZero := valueOf(ctx, rv)
+		return boolToUint8(v), isZero
+	}
+}
+
+// boolToUint8 converts bool values to Uint8, other values returned as is
+func boolToUint8(v interface{}) interface{} {
+	switch b := v.(type) {
+	case bool:
+		if b {
+			return uint8(1)
+		}
+		return uint8(0)
+	case *bool:
+		if b == nil {
+			return types.NullValue(types.TypeUint8)
+		}
+		return boolToUint8(*b)
+	}
+	return v
+}
+
+// convertBool binds bool parameters as Uint8 when bool fields are mapped to Uint8
+func (config *Config) convertBool(v interface{}) interface{} {
+	if !config.BoolAsUint8 {
+		return v
+	}
+	return boolToUint8(v)
+}
@@ -0,0 +1,96 @@
+package ydb
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+)
+
+// ErrCircuitOpen returned without request to YDB while circuit breaker is open
+var ErrCircuitOpen = errors.New("ydb: circuit breaker is open")
+
+// CircuitBreaker fails requests fast after consecutive transport, UNAVAILABLE or OVERLOADED
+// failures, after OpenTimeout single probe request is let through and closes circuit on success
+//
+//	db, err := gorm.Open(ydb.New(ydb.Config{
+//		DSN:            dsn,
+//		CircuitBreaker: &ydb.CircuitBreaker{Failures: 5, OpenTimeout: 10 * time.Second},
+//	}))
+type CircuitBreaker struct {
+	// Failures count of consecutive failures opening circuit, defaults to 5
+	Failures int
+	// OpenTimeout duration of open state before probing, defaults to 5 seconds
+	OpenTimeout time.Duration
+	// OnStateChange called when circuit opens (open is true) or closes
+	OnStateChange func(open bool)
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	open     bool
+	probing  bool
+}
+
+// allow reports whether request may be sent, probe is true for the request probing open circuit
+func (b *CircuitBreaker) allow() (probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.open {
+		return false, nil
+	}
+	if b.probing || time.Since(b.openedAt) < b.openTimeout() {
+		return false, ErrCircuitOpen
+	}
+	b.probing = true
+	return true, nil
+}
+
+// done records result of request allowed by allow
+func (b *CircuitBreaker) done(probe bool, err error) {
+	failure := isUnavailable(err)
+
+	b.mu.Lock()
+	var changed bool
+	switch {
+	case probe:
+		b.probing = false
+		if failure {
+			b.openedAt = time.Now()
+		} else {
+			b.open, b.failures, changed = false, 0, true
+		}
+	case failure:
+		b.failures++
+		if !b.open && b.failures >= b.failuresThreshold() {
+			b.open, b.openedAt, changed = true, time.Now(), true
+		}
+	case !b.open:
+		b.failures = 0
+	}
+	open := b.open
+	b.mu.Unlock()
+
+	if changed && b.OnStateChange != nil {
+		b.OnStateChange(open)
+	}
+}
+
+func (b *CircuitBreaker) failuresThreshold() int {
+	if b.Failures > 0 {
+		return b.Failures
+	}
+	return 5
+}
+
+func (b *CircuitBreaker) openTimeout() time.Duration {
+	if b.OpenTimeout > 0 {
+		return b.OpenTimeout
+	}
+	return 5 * time.Second
+}
+
+func isUnavailable(err error) bool {
+	return err != nil && (ydb.IsTransportError(err) || ydb.IsOperationErrorUnavailable(err) || ydb.IsOperationErrorOverloaded(err))
+}
@@ -0,0 +1,251 @@
+package ydb
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BuildTrace is log of building statement: parts of YQL written by each clause and parameters
+// they added
+type BuildTrace struct {
+	// SQL of statement with ? placeholders, named $p1, $p2... in order of Params by connector
+	SQL     string
+	Params  []TraceParam
+	Clauses []ClauseTrace
+}
+
+// ClauseTrace is part of statement written by clause
+type ClauseTrace struct {
+	Name string
+	// Custom reports whether clause was written by builder of db.ClauseBuilders registered by
+	// dialector or plugins instead of the clause itself
+	Custom bool
+	SQL    string
+	Params []TraceParam
+}
+
+// TraceParam is parameter of statement
+type TraceParam struct {
+	Name string
+	// Value as added to statement, values marked as sensitive are formatted masked
+	Value interface{}
+	// Type is YQL type parameter is bound as, empty if it is unknown before execution
+	Type string
+}
+
+func (p TraceParam) String() string {
+	t := p.Type
+	if t == "" {
+		t = "unknown type"
+	}
+	return fmt.Sprintf("$%s %s (%T) = %v", p.Name, t, unwrapSensitive(p.Value), p.Value)
+}
+
+// TraceBuild returns statement modifier recording how statement is built, so bad YQL is traced
+// back to clauses which generated it. Traces are passed to fn or logged by logger of db at info
+// level if fn is nil, statement modifier of session traces all statements of session
+//
+//	db.Clauses(ydb.TraceBuild(nil)).Where("name = ?", name).Find(&users)
+//
+//	traced := db.Clauses(ydb.TraceBuild(func(ctx context.Context, trace ydb.BuildTrace) {
+//		log.Printf("%+v", trace)
+//	})).Session(&gorm.Session{})
+func TraceBuild(fn func(ctx context.Context, trace BuildTrace)) clause.Expression {
+	return traceBuild{fn: fn}
+}
+
+type traceBuild struct {
+	fn func(ctx context.Context, trace BuildTrace)
+}
+
+type traceBuildKey struct{}
+
+func (t traceBuild) ModifyStatement(stmt *gorm.Statement) {
+	if stmt.Context == nil {
+		stmt.Context = context.Background()
+	}
+	stmt.Context = context.WithValue(stmt.Context, traceBuildKey{}, t)
+}
+
+func (traceBuild) Build(clause.Builder) {}
+
+func registerBuildTraceCallbacks(db *gorm.DB) error {
+	callback := db.Callback()
+	if err := callback.Query().After("gorm:query").Register("ydb:trace_build", traceStatement); err != nil {
+		return err
+	}
+	if err := callback.Row().After("gorm:row").Register("ydb:trace_build", traceStatement); err != nil {
+		return err
+	}
+	if err := callback.Raw().After("gorm:raw").Register("ydb:trace_build", traceStatement); err != nil {
+		return err
+	}
+	if err := callback.Create().After("gorm:create").Register("ydb:trace_build", traceStatement); err != nil {
+		return err
+	}
+	if err := callback.Update().After("gorm:update").Register("ydb:trace_build", traceStatement); err != nil {
+		return err
+	}
+	return callback.Delete().After("gorm:delete").Register("ydb:trace_build", traceStatement)
+}
+
+// traceStatement rebuilds clauses of built statement one by one in separate statement, so
+// builders are not wrapped and statements not traced are built as usual
+func traceStatement(db *gorm.DB) {
+	t, ok := db.Statement.Context.Value(traceBuildKey{}).(traceBuild)
+	if !ok || db.Statement.SQL.Len() == 0 {
+		return
+	}
+	config := configOf(db)
+	trace := BuildTrace{SQL: db.Statement.SQL.String(), Params: traceParams(config, db.Statement.Vars, 0)}
+
+	stmt := db.Statement
+	scratch := &gorm.Statement{
+		DB:           db,
+		ConnPool:     stmt.ConnPool,
+		Context:      stmt.Context,
+		Clauses:      stmt.Clauses,
+		Table:        stmt.Table,
+		TableExpr:    stmt.TableExpr,
+		Model:        stmt.Model,
+		Dest:         stmt.Dest,
+		ReflectValue: stmt.ReflectValue,
+		Schema:       stmt.Schema,
+		Selects:      stmt.Selects,
+		Omits:        stmt.Omits,
+		Distinct:     stmt.Distinct,
+		Joins:        stmt.Joins,
+		Preloads:     stmt.Preloads,
+		Unscoped:     stmt.Unscoped,
+	}
+	for _, name := range stmt.BuildClauses {
+		c, ok := stmt.Clauses[name]
+		if !ok {
+			continue
+		}
+		if scratch.SQL.Len() > 0 {
+			scratch.WriteByte(' ')
+		}
+		start, vars := scratch.SQL.Len(), len(scratch.Vars)
+		builder, custom := db.ClauseBuilders[name]
+		if custom {
+			builder(c, scratch)
+		} else {
+			c.Build(scratch)
+		}
+		trace.Clauses = append(trace.Clauses, ClauseTrace{
+			Name:   name,
+			Custom: custom,
+			SQL:    scratch.SQL.String()[start:],
+			Params: traceParams(config, scratch.Vars[vars:], vars),
+		})
+	}
+
+	if t.fn != nil {
+		t.fn(stmt.Context, trace)
+		return
+	}
+	db.Logger.Info(stmt.Context, "%s", trace.format())
+}
+
+func (trace BuildTrace) format() string {
+	var sb strings.Builder
+	sb.WriteString("ydb: build trace of ")
+	sb.WriteString(trace.SQL)
+	if len(trace.Clauses) == 0 {
+		// raw statement
+		for _, p := range trace.Params {
+			sb.WriteString("\n\t")
+			sb.WriteString(p.String())
+		}
+	}
+	for _, c := range trace.Clauses {
+		sb.WriteString("\n\t")
+		sb.WriteString(c.Name)
+		if c.Custom {
+			sb.WriteString(" (custom builder)")
+		}
+		sb.WriteString(": ")
+		sb.WriteString(c.SQL)
+		for _, p := range c.Params {
+			sb.WriteString("\n\t\t")
+			sb.WriteString(p.String())
+		}
+	}
+	return sb.String()
+}
+
+// traceParams describes vars of statement starting from offset
+func traceParams(config *Config, vars []interface{}, offset int) []TraceParam {
+	params := make([]TraceParam, len(vars))
+	for i, v := range vars {
+		params[i] = TraceParam{Name: positionalName(offset + i + 1), Value: v, Type: paramType(config, v)}
+	}
+	return params
+}
+
+// paramType returns YQL type of parameter converted by connector like native driver does
+func paramType(config *Config, v interface{}) string {
+	v, err := encodeMapped(unwrapSensitive(v))
+	if err != nil {
+		return ""
+	}
+	if config != nil {
+		v = config.convertTime(v)
+		v = config.convertFloat(v)
+		v = config.convertBool(v)
+	}
+	if valuer, ok := v.(driver.Valuer); ok {
+		if v, err = valuer.Value(); err != nil {
+			return ""
+		}
+	}
+	switch x := v.(type) {
+	case types.Value:
+		return x.Type().Yql()
+	case table.ParameterOption:
+		return x.Value().Type().Yql()
+	case nil:
+		return "Null"
+	}
+	t := reflect.TypeOf(v)
+	if yql, ok := driverParamTypes[t]; ok {
+		return yql
+	}
+	if t.Kind() == reflect.Ptr {
+		if yql, ok := driverParamTypes[t.Elem()]; ok {
+			return "Optional<" + yql + ">"
+		}
+	}
+	return ""
+}
+
+// driverParamTypes are YQL types of Go types supported by native driver
+var driverParamTypes = map[reflect.Type]string{
+	reflect.TypeOf(false):            "Bool",
+	reflect.TypeOf(int8(0)):          "Int8",
+	reflect.TypeOf(uint8(0)):         "Uint8",
+	reflect.TypeOf(int16(0)):         "Int16",
+	reflect.TypeOf(uint16(0)):        "Uint16",
+	reflect.TypeOf(int32(0)):         "Int32",
+	reflect.TypeOf(uint32(0)):        "Uint32",
+	reflect.TypeOf(int64(0)):         "Int64",
+	reflect.TypeOf(uint64(0)):        "Uint64",
+	reflect.TypeOf(float32(0)):       "Float",
+	reflect.TypeOf(float64(0)):       "Double",
+	reflect.TypeOf([]byte(nil)):      "String",
+	reflect.TypeOf(""):               "Utf8",
+	reflect.TypeOf([]string(nil)):    "List<Utf8>",
+	reflect.TypeOf([16]byte{}):       "Uuid",
+	reflect.TypeOf(time.Time{}):      "Timestamp",
+	reflect.TypeOf(time.Duration(0)): "Interval",
+}
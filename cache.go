@@ -0,0 +1,297 @@
+package ydb
+
+import (
+	"container/list"
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/clause"
+)
+
+// CacheStore keeps cached results of queries, values are kept in process
+type CacheStore interface {
+	Get(key string) (value interface{}, ok bool)
+	Set(key string, value interface{}, ttl time.Duration)
+}
+
+// Cache is a gorm plugin caching results of queries marked with Cached, cached results are
+// invalidated by creates, updates and deletes of their tables executed through the db and
+// by raw statements (Exec)
+//
+//	db.Use(&ydb.Cache{Store: ydb.NewLRUCache(10000), TTL: time.Minute})
+//	db.Clauses(ydb.Cached(0)).Find(&countries)
+type Cache struct {
+	// Store of cached results, defaults to NewLRUCache(1024)
+	Store CacheStore
+	// TTL of cached results, defaults to one minute
+	TTL time.Duration
+
+	mu          sync.Mutex
+	generations map[string]uint64 // table -> count of writes
+	generation  uint64            // count of raw statements
+}
+
+// Cached returns statement modifier reading result of query from Cache plugin, ttl overrides
+// Cache.TTL when positive. Results are shared by queries of the same YQL and parameters,
+// cached values are shallow copies of query results
+func Cached(ttl time.Duration) clause.Expression {
+	return cached(ttl)
+}
+
+type cached time.Duration
+
+type cachedKey struct{}
+
+func (ttl cached) ModifyStatement(stmt *gorm.Statement) {
+	if stmt.Context == nil {
+		stmt.Context = context.Background()
+	}
+	stmt.Context = context.WithValue(stmt.Context, cachedKey{}, time.Duration(ttl))
+}
+
+func (ttl cached) Build(clause.Builder) {}
+
+type cacheEntry struct {
+	value        reflect.Value
+	rowsAffected int64
+}
+
+func (c *Cache) Name() string {
+	return "ydb:cache"
+}
+
+func (c *Cache) Initialize(db *gorm.DB) error {
+	if c.Store == nil {
+		c.Store = NewLRUCache(1024)
+	}
+	if c.TTL <= 0 {
+		c.TTL = time.Minute
+	}
+	c.generations = map[string]uint64{}
+
+	callback := db.Callback()
+	if query := callback.Query().Get("gorm:query"); query != nil {
+		if err := callback.Query().Replace("gorm:query", c.query(query)); err != nil {
+			return err
+		}
+	}
+	if err := callback.Create().After("gorm:create").Register("ydb:cache_invalidate", c.invalidate); err != nil {
+		return err
+	}
+	if err := callback.Update().After("gorm:update").Register("ydb:cache_invalidate", c.invalidate); err != nil {
+		return err
+	}
+	if err := callback.Delete().After("gorm:delete").Register("ydb:cache_invalidate", c.invalidate); err != nil {
+		return err
+	}
+	return callback.Raw().After("gorm:raw").Register("ydb:cache_invalidate", c.invalidateAll)
+}
+
+func (c *Cache) query(query func(*gorm.DB)) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		ttl, ok := db.Statement.Context.Value(cachedKey{}).(time.Duration)
+		dest := reflect.ValueOf(db.Statement.Dest)
+		if !ok || db.Error != nil || db.DryRun || dest.Kind() != reflect.Ptr || dest.IsNil() {
+			query(db)
+			return
+		}
+		if ttl <= 0 {
+			ttl = c.TTL
+		}
+
+		callbacks.BuildQuerySQL(db)
+		if db.Error != nil {
+			return
+		}
+		key := c.key(db.Statement)
+		if v, ok := c.Store.Get(key); ok {
+			if entry, ok := v.(cacheEntry); ok && entry.value.Type() == dest.Elem().Type() {
+				dest.Elem().Set(shallowCopy(entry.value))
+				db.RowsAffected = entry.rowsAffected
+				return
+			}
+		}
+
+		query(db)
+		if db.Error == nil {
+			c.Store.Set(key, cacheEntry{value: shallowCopy(dest.Elem()), rowsAffected: db.RowsAffected}, ttl)
+		}
+	}
+}
+
+// key of query is its normalized YQL, parameters and generations of read tables,
+// so writes to the tables make previous keys unreachable
+func (c *Cache) key(stmt *gorm.Statement) string {
+	var sb strings.Builder
+	c.mu.Lock()
+	fmt.Fprintf(&sb, "%d", c.generation)
+	for _, table := range readTables(stmt) {
+		fmt.Fprintf(&sb, ",%s:%d", table, c.generations[table])
+	}
+	c.mu.Unlock()
+	sb.WriteByte('|')
+	sb.WriteString(strings.Join(strings.Fields(stmt.SQL.String()), " "))
+	for _, v := range stmt.Vars {
+		sb.WriteByte('|')
+		writeKeyValue(&sb, v)
+	}
+	return sb.String()
+}
+
+// writeKeyValue writes parameter v to key by its value like uniqueKey: pointers are
+// dereferenced, times are normalized to UTC, valuers are written by their values and YDB values
+// by their YQL, so equal parameters give equal keys
+func writeKeyValue(sb *strings.Builder, v interface{}) {
+	rv := reflect.ValueOf(v)
+	switch t := v.(type) {
+	case types.Value:
+		sb.WriteString(t.Yql())
+		return
+	case table.ParameterOption:
+		fmt.Fprintf(sb, "%s=%s", t.Name(), t.Value().Yql())
+		return
+	case driver.Valuer:
+		if rv.Kind() == reflect.Ptr && rv.IsNil() {
+			break
+		}
+		if value, err := t.Value(); err == nil {
+			writeKeyValue(sb, value)
+			return
+		}
+	}
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.IsValid() && rv.Kind() != reflect.Ptr {
+		v = rv.Interface()
+	}
+	if t, ok := v.(time.Time); ok {
+		fmt.Fprintf(sb, "time.Time(%s)", t.UTC().Format(time.RFC3339Nano))
+		return
+	}
+	fmt.Fprintf(sb, "%#v", v)
+}
+
+// readTables returns tables of query and tables joined by associations
+func readTables(stmt *gorm.Statement) []string {
+	tables := []string{stmt.Table}
+	if stmt.Schema != nil {
+		for _, join := range stmt.Joins {
+			if rel, ok := stmt.Schema.Relationships.Relations[join.Name]; ok && rel.FieldSchema != nil {
+				tables = append(tables, rel.FieldSchema.Table)
+			}
+		}
+	}
+	if from, ok := stmt.Clauses["FROM"].Expression.(clause.From); ok {
+		for _, table := range from.Tables {
+			tables = append(tables, table.Name)
+		}
+		for _, join := range from.Joins {
+			tables = append(tables, join.Table.Name)
+		}
+	}
+	return tables
+}
+
+func (c *Cache) invalidate(db *gorm.DB) {
+	if db.Error != nil || db.DryRun {
+		return
+	}
+	c.mu.Lock()
+	c.generations[db.Statement.Table]++
+	c.mu.Unlock()
+}
+
+func (c *Cache) invalidateAll(db *gorm.DB) {
+	if db.Error != nil || db.DryRun {
+		return
+	}
+	c.mu.Lock()
+	c.generation++
+	c.mu.Unlock()
+}
+
+// shallowCopy copies slices and maps of v, so cached result and result of query don't share them
+func shallowCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(c, v)
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			c.SetMapIndex(iter.Key(), iter.Value())
+		}
+		return c
+	}
+	return v
+}
+
+// NewLRUCache returns in memory CacheStore keeping up to size least recently used values
+func NewLRUCache(size int) CacheStore {
+	return &lruCache{size: size, items: map[string]*list.Element{}, order: list.New()}
+}
+
+type lruCache struct {
+	size int
+
+	mu    sync.Mutex
+	items map[string]*list.Element
+	order *list.List
+}
+
+type lruItem struct {
+	key       string
+	value     interface{}
+	expiresAt time.Time
+}
+
+func (c *lruCache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	item := e.Value.(*lruItem)
+	if time.Now().After(item.expiresAt) {
+		c.order.Remove(e)
+		delete(c.items, key)
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return item.value, true
+}
+
+func (c *lruCache) Set(key string, value interface{}, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		item := e.Value.(*lruItem)
+		item.value, item.expiresAt = value, time.Now().Add(ttl)
+		c.order.MoveToFront(e)
+		return
+	}
+	c.items[key] = c.order.PushFront(&lruItem{key: key, value: value, expiresAt: time.Now().Add(ttl)})
+	for c.size > 0 && c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruItem).key)
+	}
+}
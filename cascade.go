@@ -0,0 +1,206 @@
+package ydb
+
+import (
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Cascade is a gorm plugin emulating ON DELETE and ON UPDATE actions (CASCADE and SET NULL)
+// of association constraints, since YDB has no foreign keys
+//
+//	type User struct {
+//		ID     uint64
+//		Orders []Order `gorm:"constraint:OnDelete:CASCADE"`
+//	}
+//
+//	db.Use(ydb.Cascade{})
+//
+// Associated rows are written in the transaction of the statement, so actions are atomic
+// unless gorm.Config.SkipDefaultTransaction is set
+type Cascade struct{}
+
+func (Cascade) Name() string {
+	return "ydb:cascade"
+}
+
+func (c Cascade) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+	if del := callback.Delete().Get("gorm:delete"); del != nil {
+		if err := callback.Delete().Replace("gorm:delete", c.cascadeDelete(del)); err != nil {
+			return err
+		}
+	}
+	if update := callback.Update().Get("gorm:update"); update != nil {
+		if err := callback.Update().Replace("gorm:update", c.cascadeUpdate(update)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const (
+	actionCascade = "CASCADE"
+	actionSetNull = "SET NULL"
+)
+
+// referencingConstraints returns constraints of associations referencing s with action returned by actionOf
+func referencingConstraints(s *schema.Schema, actionOf func(*schema.Constraint) string) (constraints []*schema.Constraint) {
+	for _, rel := range s.Relationships.Relations {
+		constraint := rel.ParseConstraint()
+		if constraint == nil || constraint.ReferenceSchema != s || constraint.Schema == nil {
+			continue
+		}
+		switch strings.ToUpper(actionOf(constraint)) {
+		case actionCascade, actionSetNull:
+			constraints = append(constraints, constraint)
+		}
+	}
+	return constraints
+}
+
+func (Cascade) cascadeDelete(del func(*gorm.DB)) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Error != nil || db.DryRun || db.Statement.Schema == nil || db.Statement.SQL.Len() > 0 {
+			del(db)
+			return
+		}
+		constraints := referencingConstraints(db.Statement.Schema, func(c *schema.Constraint) string { return c.OnDelete })
+		if len(constraints) == 0 {
+			del(db)
+			return
+		}
+		dry := dryRun(db, del)
+		if dry == nil {
+			del(db)
+			return
+		}
+
+		for _, constraint := range constraints {
+			keys, err := referencedKeys(db, dry, constraint)
+			if err != nil {
+				db.AddError(err)
+				return
+			}
+			if len(keys) == 0 {
+				continue
+			}
+			if err = applyAction(db, constraint, strings.ToUpper(constraint.OnDelete), keys, nil); err != nil {
+				db.AddError(err)
+				return
+			}
+		}
+		del(db)
+	}
+}
+
+func (Cascade) cascadeUpdate(update func(*gorm.DB)) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Error != nil || db.DryRun || db.Statement.Schema == nil || db.Statement.SQL.Len() > 0 {
+			update(db)
+			return
+		}
+		constraints := referencingConstraints(db.Statement.Schema, func(c *schema.Constraint) string { return c.OnUpdate })
+		if len(constraints) == 0 {
+			update(db)
+			return
+		}
+		dry := dryRun(db, update)
+		if dry == nil {
+			update(db)
+			return
+		}
+		set, _ := dry.Statement.Clauses["SET"].Expression.(clause.Set)
+		assigned := make(map[string]interface{}, len(set))
+		for _, assignment := range set {
+			assigned[assignment.Column.Name] = assignment.Value
+		}
+
+		for _, constraint := range constraints {
+			values := map[string]interface{}{}
+			for i, ref := range constraint.References {
+				if v, ok := assigned[ref.DBName]; ok {
+					values[constraint.ForeignKeys[i].DBName] = v
+				}
+			}
+			if len(values) == 0 {
+				continue
+			}
+			keys, err := referencedKeys(db, dry, constraint)
+			if err != nil {
+				db.AddError(err)
+				return
+			}
+			if len(keys) == 0 {
+				continue
+			}
+			if err = applyAction(db, constraint, strings.ToUpper(constraint.OnUpdate), keys, values); err != nil {
+				db.AddError(err)
+				return
+			}
+		}
+		update(db)
+	}
+}
+
+// referencedKeys selects referenced columns of rows matching WHERE of dry built statement,
+// returned rows are keyed by foreign key columns of constraint
+func referencedKeys(db *gorm.DB, dry *gorm.DB, constraint *schema.Constraint) ([]map[string]interface{}, error) {
+	columns := make([]string, 0, len(constraint.References))
+	for _, ref := range constraint.References {
+		columns = append(columns, ref.DBName)
+	}
+	tx := db.Session(&gorm.Session{NewDB: true}).Table(db.Statement.Table).Distinct(columns)
+	if where, ok := dry.Statement.Clauses["WHERE"]; ok {
+		tx = tx.Clauses(where.Expression)
+	}
+	var rows []map[string]interface{}
+	if err := tx.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	keys := make([]map[string]interface{}, 0, len(rows))
+	for _, row := range rows {
+		key := make(map[string]interface{}, len(constraint.ForeignKeys))
+		for i, fk := range constraint.ForeignKeys {
+			key[fk.DBName] = row[constraint.References[i].DBName]
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// applyAction deletes or updates associated rows referencing keys, values are new values of
+// foreign keys on update cascade
+func applyAction(db *gorm.DB, constraint *schema.Constraint, action string, keys []map[string]interface{}, values map[string]interface{}) error {
+	columns := make([]string, 0, len(constraint.ForeignKeys))
+	for _, fk := range constraint.ForeignKeys {
+		columns = append(columns, fk.DBName)
+	}
+	var (
+		cond = columnsCondition(columns, keys)
+		tx   = db.Session(&gorm.Session{NewDB: true}).Table(constraint.Schema.Table)
+		// join tables of many2many associations have no model type
+		joinTable = constraint.Schema.ModelType.Name() == ""
+	)
+
+	if action == actionSetNull {
+		values = make(map[string]interface{}, len(columns))
+		for _, column := range columns {
+			values[column] = nil
+		}
+	} else if values == nil {
+		if joinTable {
+			return tx.Exec("DELETE FROM ? WHERE ?", clause.Table{Name: constraint.Schema.Table}, cond).Error
+		}
+		// deleting by model runs callbacks, so nested cascades and soft delete apply
+		return tx.Where(cond).Delete(reflect.New(constraint.Schema.ModelType).Interface()).Error
+	}
+	if joinTable {
+		return tx.Where(cond).Updates(values).Error
+	}
+	return tx.Model(reflect.New(constraint.Schema.ModelType).Interface()).Where(cond).Updates(values).Error
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
+
+	"github.com/abrekhov/ydb"
+	ydbsdk "github.com/ydb-platform/ydb-go-sdk/v3"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const consoleHelp = `statements end with ';', ? placeholders are prompted for values:
+  null, true, false, numbers and 'quoted strings', other input is a string
+
+  EXPLAIN <query>;  show plan of query
+  \mode <mode>      query mode of next statements: data (default), scan, scripting
+  \sql              toggle printing of statements with bound parameters
+  \h                this help
+  \q                quit
+`
+
+var consoleModes = map[string]ydbsdk.QueryMode{
+	"data":      ydbsdk.DataQueryMode,
+	"scan":      ydbsdk.ScanQueryMode,
+	"scripting": ydbsdk.ScriptingQueryMode,
+}
+
+// schemeStatements are executed in scheme query mode regardless of mode of console
+var schemeStatements = map[string]bool{"CREATE": true, "ALTER": true, "DROP": true}
+
+type consoleSession struct {
+	db      *gorm.DB
+	in      *bufio.Scanner
+	out     io.Writer
+	timeout time.Duration
+	mode    string
+	showSQL bool
+}
+
+func console(args []string) error {
+	flags := flag.NewFlagSet("console", flag.ExitOnError)
+	dsn := flags.String("dsn", "", "connection string, defaults to "+ydb.EnvConnectionString)
+	timeout := flags.Duration("timeout", time.Minute, "timeout of statements")
+	mode := flags.String("mode", "data", "query mode: data, scan or scripting")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if _, ok := consoleModes[*mode]; !ok {
+		return fmt.Errorf("unknown query mode %q", *mode)
+	}
+	if *dsn != "" {
+		if err := os.Setenv(ydb.EnvConnectionString, *dsn); err != nil {
+			return err
+		}
+	}
+
+	dialector, err := ydb.NewFromEnv()
+	if err != nil {
+		return err
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		return err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+
+	in := bufio.NewScanner(os.Stdin)
+	in.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	s := &consoleSession{db: db, in: in, out: os.Stdout, timeout: *timeout, mode: *mode}
+	fmt.Fprintln(s.out, `connected, \h for help`)
+	return s.run()
+}
+
+func (s *consoleSession) run() error {
+	var statement strings.Builder
+	for {
+		if statement.Len() == 0 {
+			fmt.Fprint(s.out, "ydb> ")
+		} else {
+			fmt.Fprint(s.out, "  -> ")
+		}
+		if !s.in.Scan() {
+			fmt.Fprintln(s.out)
+			return s.in.Err()
+		}
+		line := strings.TrimSpace(s.in.Text())
+		if statement.Len() == 0 && strings.HasPrefix(line, `\`) {
+			if quit := s.command(line); quit {
+				return nil
+			}
+			continue
+		}
+		if line == "" {
+			continue
+		}
+		statement.WriteString(line)
+		statement.WriteByte('\n')
+		if strings.HasSuffix(line, ";") {
+			if err := s.execute(strings.TrimSpace(statement.String())); err != nil {
+				fmt.Fprintln(s.out, "error:", err)
+			}
+			statement.Reset()
+		}
+	}
+}
+
+// command runs meta command of console, reports whether console must quit
+func (s *consoleSession) command(line string) (quit bool) {
+	fields := strings.Fields(line)
+	switch fields[0] {
+	case `\q`:
+		return true
+	case `\h`, `\?`:
+		fmt.Fprint(s.out, consoleHelp)
+	case `\sql`:
+		s.showSQL = !s.showSQL
+		fmt.Fprintf(s.out, "printing of statements: %t\n", s.showSQL)
+	case `\mode`:
+		if len(fields) != 2 {
+			fmt.Fprintf(s.out, "query mode: %s\n", s.mode)
+		} else if _, ok := consoleModes[fields[1]]; !ok {
+			fmt.Fprintf(s.out, "unknown query mode %q\n", fields[1])
+		} else {
+			s.mode = fields[1]
+		}
+	default:
+		fmt.Fprintf(s.out, "unknown command %s, \\h for help\n", fields[0])
+	}
+	return false
+}
+
+func (s *consoleSession) execute(statement string) error {
+	query := strings.TrimSpace(strings.TrimSuffix(statement, ";"))
+	keyword := strings.ToUpper(firstWord(query))
+	explain := keyword == "EXPLAIN"
+	if explain {
+		query = strings.TrimSpace(query[len(keyword):])
+		keyword = strings.ToUpper(firstWord(query))
+	}
+
+	args := make([]interface{}, countPlaceholders(query))
+	for i := range args {
+		fmt.Fprintf(s.out, "parameter %d: ", i+1)
+		if !s.in.Scan() {
+			return io.ErrUnexpectedEOF
+		}
+		args[i] = parseParameter(strings.TrimSpace(s.in.Text()))
+	}
+	if s.showSQL {
+		fmt.Fprintln(s.out, s.db.Dialector.Explain(query, args...))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	start := time.Now()
+	switch {
+	case explain:
+		var ast, plan string
+		err := s.db.WithContext(ydbsdk.WithQueryMode(ctx, ydbsdk.ExplainQueryMode)).Raw(query, args...).Row().Scan(&ast, &plan)
+		if err != nil {
+			return err
+		}
+		var indented bytes.Buffer
+		if json.Indent(&indented, []byte(plan), "", "  ") == nil {
+			plan = indented.String()
+		}
+		fmt.Fprintln(s.out, plan)
+		return nil
+	case schemeStatements[keyword]:
+		if err := s.db.WithContext(ydbsdk.WithQueryMode(ctx, ydbsdk.SchemeQueryMode)).Exec(query, args...).Error; err != nil {
+			return err
+		}
+		fmt.Fprintf(s.out, "OK (%s)\n", time.Since(start).Round(time.Millisecond))
+		return nil
+	}
+
+	rows, err := s.db.WithContext(ydbsdk.WithQueryMode(ctx, consoleModes[s.mode])).Raw(query, args...).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	if len(columns) == 0 {
+		fmt.Fprintf(s.out, "OK (%s)\n", time.Since(start).Round(time.Millisecond))
+		return rows.Err()
+	}
+
+	w := tabwriter.NewWriter(s.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(columns, "\t"))
+	separators := make([]string, len(columns))
+	for i, column := range columns {
+		separators[i] = strings.Repeat("-", utf8.RuneCountInString(column))
+	}
+	fmt.Fprintln(w, strings.Join(separators, "\t"))
+
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	record := make([]string, len(columns))
+	count := 0
+	for rows.Next() {
+		if err = rows.Scan(pointers...); err != nil {
+			return err
+		}
+		for i, v := range values {
+			record[i] = formatValue(v)
+		}
+		fmt.Fprintln(w, strings.Join(record, "\t"))
+		count++
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(s.out, "(%d rows, %s)\n", count, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+func firstWord(query string) string {
+	if i := strings.IndexFunc(query, func(r rune) bool { return r == ' ' || r == '\n' || r == '\t' || r == '(' }); i >= 0 {
+		return query[:i]
+	}
+	return query
+}
+
+// countPlaceholders counts ? placeholders of query outside of literals, quoted names and comments
+func countPlaceholders(query string) (count int) {
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '-' && i+1 < len(query) && query[i+1] == '-':
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+		case c == '?':
+			count++
+		}
+	}
+	return count
+}
+
+// parseParameter parses value of parameter typed at prompt
+func parseParameter(s string) interface{} {
+	switch strings.ToLower(s) {
+	case "null":
+		return nil
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return s
+}
+
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		if utf8.Valid(v) {
+			return string(v)
+		}
+		return "0x" + hex.EncodeToString(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case string:
+		return strings.NewReplacer("\n", `\n`, "\t", `\t`).Replace(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,39 @@
+// Command ydbgorm is a toolbox for debugging applications using YDB dialector of GORM
+//
+//	ydbgorm console [-dsn grpcs://ydb.example.com:2135/database]
+//
+// Connection is configured by environment variables read by ydb.NewFromEnv, DSN given by flag
+// overrides YDB_CONNECTION_STRING
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+const usage = `usage: ydbgorm <command> [flags]
+
+commands:
+  console  run YQL statements interactively
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	var err error
+	switch os.Args[1] {
+	case "console":
+		err = console(os.Args[2:])
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "ydbgorm: unknown command %q\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ydbgorm:", err)
+		os.Exit(1)
+	}
+}
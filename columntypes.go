@@ -0,0 +1,123 @@
+package ydb
+
+import (
+	"context"
+	"database/sql/driver"
+	"math"
+	"strings"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/options"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result"
+	"github.com/ydb-platform/ydb-go-sdk/v3/trace"
+)
+
+// Native database/sql rows don't report types of columns, so results of queries are captured
+// by table trace of native driver into holder passed with context of query, and rows of
+// connector read YDB types of columns from current result set
+
+type resultHolderKey struct{}
+
+type resultHolder struct {
+	result result.BaseResult
+}
+
+func withResultHolder(ctx context.Context) (context.Context, *resultHolder) {
+	holder := &resultHolder{}
+	return context.WithValue(ctx, resultHolderKey{}, holder), holder
+}
+
+// ColumnTypesTrace returns table trace capturing results of queries for sql.Rows.ColumnTypes
+// and statistics of queries for SlowQuery, it is set up for driver opened by dialector,
+// Config.NativeDriver should be opened with option WithTraceTable(ColumnTypesTrace()) of
+// native driver
+func ColumnTypesTrace() trace.Table {
+	capture := func(ctx *context.Context) func(res interface{}) {
+		holder, _ := (*ctx).Value(resultHolderKey{}).(*resultHolder)
+		return func(res interface{}) {
+			r, ok := res.(result.BaseResult)
+			if !ok {
+				return
+			}
+			if holder != nil {
+				holder.result = r
+			}
+			captureStats(*ctx, r.Stats())
+		}
+	}
+	return trace.Table{
+		OnSessionQueryExecute: func(info trace.TableExecuteDataQueryStartInfo) func(trace.TableExecuteDataQueryDoneInfo) {
+			done := capture(info.Context)
+			return func(info trace.TableExecuteDataQueryDoneInfo) {
+				done(info.Result)
+			}
+		},
+		OnSessionTransactionExecute: func(info trace.TableTransactionExecuteStartInfo) func(trace.TableTransactionExecuteDoneInfo) {
+			done := capture(info.Context)
+			return func(info trace.TableTransactionExecuteDoneInfo) {
+				done(info.Result)
+			}
+		},
+	}
+}
+
+var (
+	_ driver.RowsColumnTypeDatabaseTypeName = &rows{}
+	_ driver.RowsColumnTypeNullable         = &rows{}
+	_ driver.RowsColumnTypeLength           = &rows{}
+	_ driver.RowsColumnTypePrecisionScale   = &rows{}
+)
+
+// columnType returns YQL type of column of current result set, e.g. Optional<Decimal(22,9)>
+func (r *rows) columnType(index int) string {
+	if r.result == nil || r.result.result == nil {
+		return ""
+	}
+	set := r.result.result.CurrentResultSet()
+	if set == nil {
+		return ""
+	}
+	var (
+		i   int
+		yql string
+	)
+	set.Columns(func(column options.Column) {
+		if i == index && column.Type != nil {
+			yql = column.Type.Yql()
+		}
+		i++
+	})
+	return yql
+}
+
+// ColumnTypeDatabaseTypeName returns upper case name of YDB type of column without parameters,
+// e.g. UTF8 for Optional<Utf8> and DECIMAL for Decimal(22,9)
+func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	yql := strings.TrimPrefix(r.columnType(index), "Optional<")
+	if i := strings.IndexAny(yql, "<(>"); i >= 0 {
+		yql = yql[:i]
+	}
+	return strings.ToUpper(yql)
+}
+
+func (r *rows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	yql := r.columnType(index)
+	if yql == "" {
+		return false, false
+	}
+	return strings.HasPrefix(yql, "Optional<"), true
+}
+
+// ColumnTypeLength reports unlimited length of variable length types (String, Utf8, Json...)
+func (r *rows) ColumnTypeLength(index int) (length int64, ok bool) {
+	switch r.ColumnTypeDatabaseTypeName(index) {
+	case "STRING", "UTF8", "JSON", "JSONDOCUMENT", "YSON", "DYNUMBER":
+		return math.MaxInt64, true
+	}
+	return 0, false
+}
+
+func (r *rows) ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool) {
+	yql := strings.TrimSuffix(strings.TrimPrefix(r.columnType(index), "Optional<"), ">")
+	p, s, ok := decimalParams(yql)
+	return int64(p), int64(s), ok
+}
@@ -0,0 +1,131 @@
+package ydb
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// ComputedModel is implemented by models with fields tagged with computed, YDB has no generated
+// columns, so Compute sets them from other fields of the model before creates and updates.
+// Values of computed columns in maps of updates are ignored, map updates of source columns
+// (listed by tag, computed:email) are applied to the model and computed, so they require model
+// value, e.g. db.Model(&user).Update("email", email)
+//
+//	type User struct {
+//		ID         uint64
+//		Email      string
+//		EmailLower string `gorm:"computed:email"`
+//	}
+//
+//	func (u *User) Compute() {
+//		u.EmailLower = strings.ToLower(u.Email)
+//	}
+type ComputedModel interface {
+	Compute()
+}
+
+// computedFields returns fields tagged with computed and fields of their source columns
+func computedFields(s *schema.Schema) (computed, sources []*schema.Field) {
+	for _, field := range s.Fields {
+		setting, ok := field.TagSettings["COMPUTED"]
+		if !ok {
+			continue
+		}
+		computed = append(computed, field)
+		for _, column := range strings.Split(setting, ",") {
+			if source := s.LookUpField(strings.TrimSpace(column)); source != nil {
+				sources = append(sources, source)
+			}
+		}
+	}
+	return computed, sources
+}
+
+func registerComputedCallbacks(db *gorm.DB) error {
+	callback := db.Callback()
+	if err := callback.Create().Before("gorm:create").Register("ydb:computed", computeCreated); err != nil {
+		return err
+	}
+	return callback.Update().Before("gorm:update").Register("ydb:computed", computeUpdated)
+}
+
+// compute calls Compute of addressable model value
+func compute(rv reflect.Value) {
+	if rv.Kind() == reflect.Struct && rv.CanAddr() {
+		if model, ok := rv.Addr().Interface().(ComputedModel); ok {
+			model.Compute()
+		}
+	}
+}
+
+func computeCreated(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+	switch rv := reflect.Indirect(db.Statement.ReflectValue); rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			compute(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		compute(rv)
+	}
+}
+
+func computeUpdated(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+	updates, ok := db.Statement.Dest.(map[string]interface{})
+	if !ok {
+		compute(reflect.Indirect(reflect.ValueOf(db.Statement.Dest)))
+		return
+	}
+
+	computed, sources := computedFields(db.Statement.Schema)
+	if len(computed) == 0 {
+		return
+	}
+	lookup := func(fields []*schema.Field, key string) *schema.Field {
+		for _, field := range fields {
+			if field.DBName == key || field.Name == key {
+				return field
+			}
+		}
+		return nil
+	}
+	var changed bool
+	for key := range updates {
+		if lookup(computed, key) != nil {
+			delete(updates, key)
+		} else if lookup(sources, key) != nil {
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+
+	ctx := db.Statement.Context
+	rv := reflect.Indirect(db.Statement.ReflectValue)
+	if rv.Kind() != reflect.Struct || !rv.CanAddr() {
+		db.AddError(fmt.Errorf("ydb: computed fields of %s require model value for update of their source columns", db.Statement.Schema.Name))
+		return
+	}
+	for key, value := range updates {
+		if field := db.Statement.Schema.LookUpField(key); field != nil {
+			if err := field.Set(ctx, rv, value); err != nil {
+				db.AddError(err)
+				return
+			}
+		}
+	}
+	compute(rv)
+	for _, field := range computed {
+		updates[field.DBName], _ = field.ValueOf(ctx, rv)
+	}
+}
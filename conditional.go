@@ -0,0 +1,74 @@
+package ydb
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UpdateIf returns statement modifier making update conditional (compare-and-set): condition is
+// added to conditions of update, e.g. to primary key of model, and rows matching them are counted
+// in transaction of update, so Matched reports whether condition matched and RowsAffected is exact
+//
+//	res := db.Model(&account).Clauses(ydb.UpdateIf("version = ?", account.Version)).
+//		Updates(map[string]interface{}{"balance": balance, "version": account.Version + 1})
+//	if res.Error == nil && !ydb.Matched(res) {
+//		// account was changed concurrently, reload and retry
+//	}
+//
+// Conditional updates keep default transaction of gorm even when single statements are executed
+// without it, but updates made with gorm.Config.SkipDefaultTransaction outside of transactions
+// are counted before write by separate query, so condition may change between them and Matched
+// isn't reliable
+func UpdateIf(query interface{}, args ...interface{}) clause.Expression {
+	return updateIf{query: query, args: args}
+}
+
+type updateIf struct {
+	query interface{}
+	args  []interface{}
+}
+
+type updateIfKey struct{}
+
+func (cond updateIf) ModifyStatement(stmt *gorm.Statement) {
+	if exprs := stmt.BuildCondition(cond.query, cond.args...); len(exprs) > 0 {
+		stmt.AddClause(clause.Where{Exprs: exprs})
+	}
+	if stmt.Context == nil {
+		stmt.Context = context.Background()
+	}
+	stmt.Context = context.WithValue(stmt.Context, updateIfKey{}, true)
+}
+
+func (updateIf) Build(clause.Builder) {}
+
+const matchedKey = "ydb:update_if_matched"
+
+// Matched reports whether condition of update of db made with UpdateIf matched any rows
+func Matched(db *gorm.DB) bool {
+	matched, _ := db.InstanceGet(matchedKey)
+	ok, _ := matched.(bool)
+	return ok
+}
+
+// countConditional counts rows affected by updates made with UpdateIf, other updates are
+// counted only in RowsAffectedExact mode
+func (mode RowsAffectedMode) countConditional(update func(*gorm.DB)) func(*gorm.DB) {
+	counted := countAffected(update)
+	return func(db *gorm.DB) {
+		if conditional, _ := db.Statement.Context.Value(updateIfKey{}).(bool); !conditional {
+			if mode == RowsAffectedExact {
+				counted(db)
+			} else {
+				update(db)
+			}
+			return
+		}
+		counted(db)
+		if db.Error == nil {
+			db.InstanceSet(matchedKey, db.RowsAffected > 0)
+		}
+	}
+}
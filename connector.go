@@ -0,0 +1,364 @@
+package ydb
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb"
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+)
+
+// connector wraps native database/sql connector to hook connection level operations
+type connector struct {
+	driver.Connector
+	config *Config
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	cc, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: cc, config: c.config}, nil
+}
+
+type conn struct {
+	driver.Conn
+	config *Config
+	// tx executes statements of connection pinned by Tx instead of native connection
+	tx *interactiveTx
+	// inTx is set while database/sql transaction of connection is open
+	inTx bool
+}
+
+var (
+	_ driver.ConnPrepareContext = &conn{}
+	_ driver.ExecerContext      = &conn{}
+	_ driver.QueryerContext     = &conn{}
+	_ driver.ConnBeginTx        = &conn{}
+	_ driver.NamedValueChecker  = &conn{}
+	_ driver.SessionResetter    = &conn{}
+	_ driver.Validator          = &conn{}
+	_ driver.Pinger             = &conn{}
+)
+
+// PrepareContext returns statement executed through the connection, so parameters binding
+// and checks are applied to prepared statements too
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	return &stmt{conn: c, query: query}, nil
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	query, err := bindParams(query, args)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkLimits(query, args); err != nil {
+		return nil, err
+	}
+	query = c.config.commentQuery(ctx, query)
+	cc, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx = c.config.labelsContext(c.config.operationContext(ctx))
+	ctx, cancel := c.config.deadlineContext(ctx, query)
+	defer cancel()
+	var result driver.Result
+	err = c.execute(func() (err error) {
+		if c.tx != nil {
+			result, err = c.tx.ExecContext(ctx, query, args)
+		} else {
+			result, err = cc.ExecContext(ctx, query, args)
+		}
+		return err
+	})
+	return result, queryError(ctx, query, err)
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	query, err := bindParams(query, args)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkLimits(query, args); err != nil {
+		return nil, err
+	}
+	query = c.config.commentQuery(ctx, query)
+	cc, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx = c.config.labelsContext(c.config.operationContext(ctx))
+	ctx, cancel := c.config.deadlineContext(ctx, query)
+	ctx, holder := withResultHolder(ctx)
+	var r driver.Rows
+	err = c.execute(func() (err error) {
+		if c.tx != nil {
+			r, err = c.tx.QueryContext(ctx, query, args)
+		} else {
+			r, err = cc.QueryContext(ctx, query, args)
+		}
+		return err
+	})
+	if err != nil {
+		cancel()
+		return nil, queryError(ctx, query, err)
+	}
+	// scan queries stream results with context of query
+	wrapped := &rows{Rows: r, result: holder, metrics: c.metrics(), cancel: cancel}
+	if c.config != nil {
+		wrapped.location = c.config.location
+	}
+	return wrapped, nil
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (tx driver.Tx, err error) {
+	if c.tx != nil {
+		return nil, ErrNestedTx
+	}
+	if err = c.drainer().enter(false); err != nil {
+		return nil, err
+	}
+	defer c.drainer().leave()
+	ctx = c.config.labelsContext(ctx)
+	err = c.guard(func() (err error) {
+		if cc, ok := c.Conn.(driver.ConnBeginTx); ok {
+			tx, err = cc.BeginTx(ctx, opts)
+		} else {
+			tx, err = c.Conn.Begin() //nolint:staticcheck
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.inTx = true
+	return &connTx{Tx: tx, conn: c}, nil
+}
+
+// connTx tracks end of database/sql transaction of conn
+type connTx struct {
+	driver.Tx
+	conn *conn
+}
+
+func (tx *connTx) Commit() error {
+	tx.conn.inTx = false
+	return tx.Tx.Commit()
+}
+
+func (tx *connTx) Rollback() error {
+	tx.conn.inTx = false
+	return tx.Tx.Rollback()
+}
+
+func (c *conn) CheckNamedValue(v *driver.NamedValue) (err error) {
+	if v.Value, err = encodeMapped(unwrapSensitive(v.Value)); err != nil {
+		return err
+	}
+	if c.config != nil {
+		v.Value = c.config.convertTime(v.Value)
+		v.Value = c.config.convertFloat(v.Value)
+		v.Value = c.config.convertBool(v.Value)
+	}
+	if v.Name == "" {
+		switch v.Value.(type) {
+		case table.ParameterOption, *table.QueryParameters:
+		default:
+			v.Name = positionalName(v.Ordinal)
+		}
+	}
+	if cc, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return cc.CheckNamedValue(v)
+	}
+	return driver.ErrSkip
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if cc, ok := c.Conn.(driver.SessionResetter); ok {
+		return cc.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *conn) IsValid() bool {
+	if cc, ok := c.Conn.(driver.Validator); ok {
+		return cc.IsValid()
+	}
+	return true
+}
+
+func (c *conn) drainer() *drainer {
+	if c.config == nil {
+		return nil
+	}
+	return c.config.drainer
+}
+
+func (c *conn) metrics() *metrics {
+	if c.config == nil {
+		return nil
+	}
+	return c.config.metrics
+}
+
+// schemeChangedRetries is count of retries of statement failed on scheme change
+const schemeChangedRetries = 2
+
+// execute executes statement op through guard, statements outside of transactions failed
+// because of scheme change (e.g. by migration) are retried and recompiled by server
+func (c *conn) execute(op func() error) error {
+	if err := c.drainer().enter(c.tx != nil || c.inTx); err != nil {
+		return err
+	}
+	defer c.drainer().leave()
+
+	err := c.guard(op)
+	for i := 0; i < schemeChangedRetries && err != nil && c.tx == nil && !c.inTx && isSchemeChanged(err); i++ {
+		c.metrics().retried("statement", err)
+		err = c.guard(op)
+	}
+	return err
+}
+
+// schemeChangedIssues are messages of issues reported by server for queries compiled
+// against previous version of scheme
+var schemeChangedIssues = []string{"scheme changed", "schema version mismatch", "query invalidated"}
+
+func isSchemeChanged(err error) bool {
+	if !ydb.IsOperationErrorSchemeError(err) && !ydb.IsOperationError(err, Ydb.StatusIds_ABORTED) {
+		return false
+	}
+	var changed bool
+	ydb.IterateByIssues(err, func(message string, code Ydb.StatusIds_StatusCode, severity uint32) {
+		message = strings.ToLower(message)
+		for _, issue := range schemeChangedIssues {
+			changed = changed || strings.Contains(message, issue)
+		}
+	})
+	return changed
+}
+
+// guard executes request op through circuit breaker of config
+func (c *conn) guard(op func() error) error {
+	if c.config == nil || c.config.CircuitBreaker == nil {
+		return op()
+	}
+	probe, err := c.config.CircuitBreaker.allow()
+	if err != nil {
+		return err
+	}
+	err = op()
+	c.config.CircuitBreaker.done(probe, err)
+	return err
+}
+
+func (c *conn) checkLimits(query string, args []driver.NamedValue) error {
+	if c.config == nil || c.config.Limits == nil {
+		return nil
+	}
+	return c.config.Limits.checkQuery(query, args)
+}
+
+// Ping executes lightweight data query instead of session keep-alive,
+// so it fails when the session cannot serve queries
+func (c *conn) Ping(ctx context.Context) error {
+	rows, err := c.QueryContext(ctx, pingQuery, nil)
+	if err != nil {
+		return err
+	}
+	return rows.Close()
+}
+
+const pingQuery = "SELECT 1;"
+
+// stmt is a prepared statement of conn, YDB compiles and caches queries on server side,
+// so statement just keeps query text
+type stmt struct {
+	conn  *conn
+	query string
+}
+
+var (
+	_ driver.StmtExecContext   = &stmt{}
+	_ driver.StmtQueryContext  = &stmt{}
+	_ driver.NamedValueChecker = &stmt{}
+)
+
+func (s *stmt) Close() error {
+	return nil
+}
+
+func (s *stmt) NumInput() int {
+	return -1
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, driver.ErrSkip
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, driver.ErrSkip
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.conn.ExecContext(ctx, s.query, args)
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.query, args)
+}
+
+func (s *stmt) CheckNamedValue(v *driver.NamedValue) error {
+	return s.conn.CheckNamedValue(v)
+}
+
+// rows converts time values of result to configured location and reports types of columns
+type rows struct {
+	driver.Rows
+	location *time.Location
+	result   *resultHolder
+	metrics  *metrics
+	cancel   context.CancelFunc
+}
+
+var _ driver.RowsNextResultSet = &rows{}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if err := r.Rows.Next(dest); err != nil || r.location == nil {
+		if err != nil && err != io.EOF {
+			r.metrics.truncated(err)
+		}
+		return err
+	}
+	for i, v := range dest {
+		if t, ok := v.(time.Time); ok {
+			dest[i] = t.In(r.location)
+		}
+	}
+	return nil
+}
+
+func (r *rows) Close() error {
+	defer r.cancel()
+	return r.Rows.Close()
+}
+
+func (r *rows) HasNextResultSet() bool {
+	if rr, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return rr.HasNextResultSet()
+	}
+	return false
+}
+
+func (r *rows) NextResultSet() error {
+	if rr, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return rr.NextResultSet()
+	}
+	return io.EOF
+}
@@ -0,0 +1,160 @@
+package ydb
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WriteMark records commit of write transaction, so later reads (possibly of other requests,
+// e.g. mark kept in session) are guaranteed to observe the write
+type WriteMark struct {
+	// CommittedAt client time of transaction commit
+	CommittedAt time.Time
+}
+
+// WriteTx executes fc in serializable read-write transaction and returns mark of its commit
+//
+//	mark, err := ydb.WriteTx(ctx, db, func(tx *gorm.DB) error {
+//		return tx.Create(&order).Error
+//	})
+//	...
+//	ydb.ReadAfter(ctx, db, mark, 10*time.Second).Find(&orders)
+func WriteTx(ctx context.Context, db *gorm.DB, fc func(tx *gorm.DB) error) (WriteMark, error) {
+	if err := db.WithContext(ctx).Transaction(fc); err != nil {
+		return WriteMark{}, err
+	}
+	return WriteMark{CommittedAt: time.Now()}, nil
+}
+
+// ReadAfter returns db observing write of mark: reads are executed with snapshot read-only
+// transactions while mark is not older than staleness, so queries of the db read consistent
+// snapshot, and with cheaper online read-only transactions then, which read committed data of
+// every query separately. Stale read-only transactions are never used, they may miss the write
+func ReadAfter(ctx context.Context, db *gorm.DB, mark WriteMark, staleness time.Duration) *gorm.DB {
+	txOption := table.WithSnapshotReadOnly()
+	if time.Since(mark.CommittedAt) > staleness {
+		txOption = table.WithOnlineReadOnly()
+	}
+	ctx = context.WithValue(ctx, explicitTxControlKey{}, true)
+	return db.WithContext(ydb.WithTxControl(ctx, table.TxControl(table.BeginTx(txOption), table.CommitTx())))
+}
+
+// explicitTxControlKey marks context whose transaction control was chosen by caller
+type explicitTxControlKey struct{}
+
+// ReadConsistency is transaction mode of reads outside of transactions
+type ReadConsistency int
+
+const (
+	// ReadSerializable reads in serializable read-write transactions, the default
+	ReadSerializable ReadConsistency = iota
+	// ReadOnline reads committed data in online read-only transactions
+	ReadOnline
+	// ReadSnapshot reads consistent snapshot in snapshot read-only transactions
+	ReadSnapshot
+	// ReadStale reads possibly stale data, e.g. from followers, in stale read-only transactions
+	ReadStale
+)
+
+// ReadConsistencyModel is implemented by models with default consistency of their queries, e.g.
+// cheap stale reads of reference data. Queries in transactions and queries with ReadAfter or
+// AllowStale are not affected
+//
+//	func (Country) ReadConsistency() ydb.ReadConsistency {
+//		return ydb.ReadStale
+//	}
+type ReadConsistencyModel interface {
+	ReadConsistency() ReadConsistency
+}
+
+// WriteMode is statement creating rows
+type WriteMode int
+
+const (
+	// WriteInsert creates rows by INSERT failing on existing primary keys, the default
+	WriteInsert WriteMode = iota
+	// WriteUpsert creates rows by UPSERT updating columns of existing rows
+	WriteUpsert
+	// WriteReplace creates rows by REPLACE overwriting existing rows
+	WriteReplace
+)
+
+// WriteModeModel is implemented by models created by UPSERT or REPLACE instead of INSERT
+//
+//	func (Country) WriteMode() ydb.WriteMode {
+//		return ydb.WriteUpsert
+//	}
+type WriteModeModel interface {
+	WriteMode() WriteMode
+}
+
+// applyReadConsistency sets transaction control of query by read consistency of model
+func applyReadConsistency(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+	if _, inTx := db.Statement.ConnPool.(gorm.TxCommitter); inTx {
+		return
+	}
+	ctx := db.Statement.Context
+	if ctx.Value(explicitTxControlKey{}) != nil || ctx.Value(allowStaleKey{}) != nil {
+		return
+	}
+	model, ok := reflect.New(db.Statement.Schema.ModelType).Interface().(ReadConsistencyModel)
+	if !ok {
+		return
+	}
+	var txOption table.TxOption
+	switch model.ReadConsistency() {
+	case ReadOnline:
+		txOption = table.WithOnlineReadOnly()
+	case ReadSnapshot:
+		txOption = table.WithSnapshotReadOnly()
+	case ReadStale:
+		txOption = table.WithStaleReadOnly()
+	default:
+		return
+	}
+	db.Statement.Context = ydb.WithTxControl(ctx, table.TxControl(table.BeginTx(txOption), table.CommitTx()))
+}
+
+// writeModeKey is setting of statement overriding WriteMode of model
+const writeModeKey = "ydb:write_mode"
+
+// buildInsert builds UPSERT or REPLACE instead of INSERT for models with WriteMode
+func buildInsert(c clause.Clause, builder clause.Builder) {
+	if stmt, ok := builder.(*gorm.Statement); ok && stmt.Schema != nil {
+		mode := WriteInsert
+		if v, ok := stmt.Settings.Load(writeModeKey); ok {
+			mode, _ = v.(WriteMode)
+		} else if model, ok := reflect.New(stmt.Schema.ModelType).Interface().(WriteModeModel); ok {
+			mode = model.WriteMode()
+		}
+		switch mode {
+		case WriteUpsert:
+			builder.WriteString("UPSERT ")
+			c.Expression.Build(builder)
+			return
+		case WriteReplace:
+			builder.WriteString("REPLACE ")
+			c.Expression.Build(builder)
+			return
+		}
+	}
+	c.Build(builder)
+}
+
+func registerConsistencyCallbacks(db *gorm.DB) error {
+	db.ClauseBuilders["INSERT"] = buildInsert
+	callback := db.Callback()
+	if err := callback.Query().Before("gorm:query").Register("ydb:read_consistency", applyReadConsistency); err != nil {
+		return err
+	}
+	return callback.Row().Before("gorm:row").Register("ydb:read_consistency", applyReadConsistency)
+}
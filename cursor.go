@@ -0,0 +1,145 @@
+package ydb
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrInvalidCursor returned for cursors which are not tokens of EncodeCursor for the model
+var ErrInvalidCursor = errors.New("ydb: invalid cursor")
+
+// EncodeCursor returns opaque token of position of lastRow (pointer to model) in primary key order
+// for pagination of APIs, token is base64 encoded JSON of values of primary key columns, so it
+// must not be trusted more than other input of clients. See Paginate
+func EncodeCursor(lastRow interface{}) (string, error) {
+	s, err := cursorSchema(lastRow)
+	if err != nil {
+		return "", err
+	}
+	rv := reflect.Indirect(reflect.ValueOf(lastRow))
+	if rv.Kind() != reflect.Struct {
+		return "", fmt.Errorf("ydb: cursor of %T, struct expected", lastRow)
+	}
+	values := make([]interface{}, len(s.PrimaryFields))
+	for i, field := range s.PrimaryFields {
+		values[i] = field.ReflectValueOf(context.Background(), rv).Interface()
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeCursor sets primary key fields of row (pointer to model) to position of token of
+// EncodeCursor, returns ErrInvalidCursor for malformed tokens
+func DecodeCursor(token string, row interface{}) error {
+	s, err := cursorSchema(row)
+	if err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(row)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("ydb: cursor decoded into %T, pointer to struct expected", row)
+	}
+	values, err := decodeCursor(s, token)
+	if err != nil {
+		return err
+	}
+	for i, field := range s.PrimaryFields {
+		field.ReflectValueOf(context.Background(), rv.Elem()).Set(reflect.ValueOf(values[i]))
+	}
+	return nil
+}
+
+var cursorSchemas sync.Map
+
+// cursorSchema returns schema of model, names of tables and columns don't matter for cursors
+func cursorSchema(value interface{}) (*schema.Schema, error) {
+	s, err := schema.Parse(value, &cursorSchemas, schema.NamingStrategy{})
+	if err != nil {
+		return nil, err
+	}
+	if len(s.PrimaryFields) == 0 {
+		return nil, fmt.Errorf("%w: %s", errMissingPrimaryKey, s.Name)
+	}
+	return s, nil
+}
+
+// decodeCursor returns values of primary key columns of s in token, typed as fields
+func decodeCursor(s *schema.Schema, token string) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	var raw []json.RawMessage
+	if err = json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	if len(raw) != len(s.PrimaryFields) {
+		return nil, fmt.Errorf("%w: %d values for %d primary key columns of %s", ErrInvalidCursor, len(raw), len(s.PrimaryFields), s.Table)
+	}
+	values := make([]interface{}, len(raw))
+	for i, field := range s.PrimaryFields {
+		v := reflect.New(field.FieldType)
+		if err = json.Unmarshal(raw[i], v.Interface()); err != nil {
+			return nil, fmt.Errorf("%w: column %s: %v", ErrInvalidCursor, field.DBName, err)
+		}
+		values[i] = v.Elem().Interface()
+	}
+	return values, nil
+}
+
+// Paginate returns scope selecting page of limit rows in primary key order after position of
+// cursor of EncodeCursor, empty cursor selects the first page. Pages are read by keyset
+// condition on primary key, so pages are served by range reads of the table regardless of
+// their depth, unlike pages of OFFSET
+//
+//	var users []User
+//	err := db.Scopes(ydb.Paginate(req.PageToken, 50)).Find(&users).Error
+//	if len(users) == 50 {
+//		resp.NextPageToken, err = ydb.EncodeCursor(&users[len(users)-1])
+//	}
+func Paginate(cursor string, limit int) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		value := db.Statement.Model
+		if value == nil {
+			value = db.Statement.Dest
+		}
+		stmt, err := parseStatement(db, value)
+		if err != nil {
+			_ = db.AddError(err)
+			return db
+		}
+
+		orderBy := clause.OrderBy{}
+		for _, field := range stmt.Schema.PrimaryFields {
+			orderBy.Columns = append(orderBy.Columns, clause.OrderByColumn{
+				Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName},
+			})
+		}
+		db = db.Clauses(orderBy).Limit(limit)
+		if cursor == "" {
+			return db
+		}
+		values, err := decodeCursor(stmt.Schema, cursor)
+		if err != nil {
+			_ = db.AddError(err)
+			return db
+		}
+		last := make(map[string]interface{}, len(values))
+		for i, field := range stmt.Schema.PrimaryFields {
+			last[field.DBName] = values[i]
+		}
+		return db.Where(keysetCondition(stmt.Schema, last))
+	}
+}
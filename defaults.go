@@ -0,0 +1,106 @@
+package ydb
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// reloadDefaults re-selects columns with database defaults of created rows by primary key
+// when RETURNING is disabled, rows with generated (Serial) primary keys cannot be found
+// and are left as is
+func reloadDefaults(db *gorm.DB) {
+	if db.Error != nil || db.DryRun || db.RowsAffected == 0 || db.Statement.Schema == nil {
+		return
+	}
+	if _, ok := db.Statement.Clauses["RETURNING"]; ok {
+		return
+	}
+
+	s := db.Statement.Schema
+	fields := make([]*schema.Field, 0, len(s.FieldsWithDefaultDBValue))
+	for _, field := range s.FieldsWithDefaultDBValue {
+		if !field.PrimaryKey {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 0 || len(s.PrimaryFields) == 0 {
+		return
+	}
+
+	var (
+		ctx   = db.Statement.Context
+		elems = map[string]reflect.Value{}
+		keys  []map[string]interface{}
+	)
+	collect := func(elem reflect.Value) {
+		elem = reflect.Indirect(elem)
+		if elem.Kind() != reflect.Struct {
+			return
+		}
+		key := make(map[string]interface{}, len(s.PrimaryFields))
+		for _, field := range s.PrimaryFields {
+			v, isZero := field.ValueOf(ctx, elem)
+			if isZero {
+				return
+			}
+			key[field.DBName] = v
+		}
+		elems[primaryKeyString(s, key)] = elem
+		keys = append(keys, key)
+	}
+	switch rv := db.Statement.ReflectValue; rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			collect(rv.Index(i))
+		}
+	default:
+		collect(rv)
+	}
+	if len(keys) == 0 {
+		return
+	}
+
+	columns := append([]string{}, s.PrimaryFieldDBNames...)
+	for _, field := range fields {
+		columns = append(columns, field.DBName)
+	}
+
+	var results []map[string]interface{}
+	if err := db.Session(&gorm.Session{NewDB: true}).Table(db.Statement.Table).Select(columns).
+		Where(primaryKeysCondition(s, keys)).Find(&results).Error; err != nil {
+		db.AddError(err)
+		return
+	}
+	for _, result := range results {
+		elem, ok := elems[primaryKeyString(s, result)]
+		if !ok {
+			continue
+		}
+		for _, field := range fields {
+			if err := field.Set(ctx, elem, result[field.DBName]); err != nil {
+				db.AddError(err)
+				return
+			}
+		}
+	}
+}
+
+// primaryKeyString returns key of row's primary key values independent of their Go types
+func primaryKeyString(s *schema.Schema, row map[string]interface{}) string {
+	var sb strings.Builder
+	for _, field := range s.PrimaryFields {
+		v := reflect.ValueOf(row[field.DBName])
+		for v.Kind() == reflect.Ptr && !v.IsNil() {
+			v = v.Elem()
+		}
+		if v.IsValid() {
+			fmt.Fprint(&sb, v.Interface())
+		}
+		sb.WriteByte(0)
+	}
+	return sb.String()
+}
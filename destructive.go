@@ -0,0 +1,47 @@
+package ydb
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/options"
+	"gorm.io/gorm"
+)
+
+// ErrDestructiveMigration returned by DropTable and DropColumn of migrator and by TTL shortening
+// when Config.DisallowDestructiveMigrations is set and they are not allowed by
+// AllowDestructiveMigrations
+var ErrDestructiveMigration = errors.New("ydb: destructive migration is disallowed")
+
+const allowDestructiveKey = "ydb:allow_destructive_migrations"
+
+// AllowDestructiveMigrations returns db whose migrator drops tables and columns and shortens TTL
+// despite Config.DisallowDestructiveMigrations
+//
+//	ydb.AllowDestructiveMigrations(db).Migrator().DropColumn(&User{}, "legacy_name")
+func AllowDestructiveMigrations(db *gorm.DB) *gorm.DB {
+	return db.Set(allowDestructiveKey, true)
+}
+
+// checkDestructive returns ErrDestructiveMigration for operation when destructive migrations
+// are disallowed
+func (m Migrator) checkDestructive(operation string) error {
+	config := configOf(m.DB)
+	if config == nil || !config.DisallowDestructiveMigrations {
+		return nil
+	}
+	if allowed, ok := m.DB.Get(allowDestructiveKey); ok && allowed == true {
+		return nil
+	}
+	return fmt.Errorf("%w: %s, see ydb.AllowDestructiveMigrations", ErrDestructiveMigration, operation)
+}
+
+// checkTTL returns ErrDestructiveMigration when TTL of table with current settings is set or
+// shortened to expireAfter, so rows kept by current TTL would be deleted
+func (m Migrator) checkTTL(table string, current *options.TimeToLiveSettings, expireAfter time.Duration) error {
+	if current != nil && expireAfter >= time.Duration(current.ExpireAfterSeconds)*time.Second {
+		return nil
+	}
+	return m.checkDestructive(fmt.Sprintf("shorten TTL of %s to %s", table, expireAfter))
+}
@@ -0,0 +1,146 @@
+package ydb
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// SchemaDiff is report of differences between models and live schema
+type SchemaDiff struct {
+	Tables []TableDiff
+}
+
+// Empty reports whether schema matches models
+func (d *SchemaDiff) Empty() bool {
+	return len(d.Tables) == 0
+}
+
+// TableDiff differences of table of model
+type TableDiff struct {
+	Table string
+	// Missing is set when table doesn't exist, other differences are not reported then
+	Missing bool
+	// MissingColumns of model absent in table, ExtraColumns of table absent in model
+	MissingColumns []string
+	ExtraColumns   []string
+	// TypeMismatches columns whose types differ
+	TypeMismatches []ColumnTypeDiff
+	// MissingIndexes of model absent in table, ExtraIndexes of table absent in model
+	MissingIndexes []string
+	ExtraIndexes   []string
+}
+
+func (d *TableDiff) empty() bool {
+	return !d.Missing && len(d.MissingColumns) == 0 && len(d.ExtraColumns) == 0 && len(d.TypeMismatches) == 0 &&
+		len(d.MissingIndexes) == 0 && len(d.ExtraIndexes) == 0
+}
+
+// ColumnTypeDiff types of column in YQL, e.g. Optional<Int32> in database and Int64 in model
+type ColumnTypeDiff struct {
+	Column   string
+	Model    string
+	Database string
+}
+
+// Diff compares tables of models with live schema without changing anything, e.g. to fail
+// CI/CD when migrations were not applied. View models are skipped, TTL settings are not
+// described by models and not compared
+//
+//	diff, err := db.Migrator().(ydb.Migrator).Diff(&User{}, &Order{})
+//	if err == nil && !diff.Empty() {
+//		log.Fatalf("schema drift: %+v", diff.Tables)
+//	}
+func (m Migrator) Diff(models ...interface{}) (*SchemaDiff, error) {
+	diff := &SchemaDiff{}
+	for _, model := range withoutViews(models) {
+		err := m.RunWithValue(model, func(stmt *gorm.Statement) error {
+			tableDiff, err := m.diffTable(stmt)
+			if err == nil && !tableDiff.empty() {
+				diff.Tables = append(diff.Tables, tableDiff)
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return diff, nil
+}
+
+func (m Migrator) diffTable(stmt *gorm.Statement) (TableDiff, error) {
+	diff := TableDiff{Table: stmt.Table}
+	desc, err := m.describeTable(stmt.Table)
+	if ydb.IsOperationErrorSchemeError(err) {
+		diff.Missing = true
+		return diff, nil
+	}
+	if err != nil {
+		return diff, err
+	}
+
+	columns := make(map[string]string, len(desc.Columns))
+	for _, column := range desc.Columns {
+		columns[column.Name] = column.Type.Yql()
+	}
+	for _, dbName := range stmt.Schema.DBNames {
+		field := stmt.Schema.FieldsByDBName[dbName]
+		if field.IgnoreMigration {
+			continue
+		}
+		current, ok := columns[dbName]
+		if !ok {
+			diff.MissingColumns = append(diff.MissingColumns, dbName)
+			continue
+		}
+		delete(columns, dbName)
+		if expected := m.columnYql(field); !sameColumnType(field, expected, current) {
+			diff.TypeMismatches = append(diff.TypeMismatches, ColumnTypeDiff{Column: dbName, Model: expected, Database: current})
+		}
+	}
+	for name := range columns {
+		diff.ExtraColumns = append(diff.ExtraColumns, name)
+	}
+	sort.Strings(diff.ExtraColumns)
+
+	indexes := stmt.Schema.ParseIndexes()
+	for _, index := range desc.Indexes {
+		if _, ok := indexes[index.Name]; !ok {
+			diff.ExtraIndexes = append(diff.ExtraIndexes, index.Name)
+		}
+		delete(indexes, index.Name)
+	}
+	for name := range indexes {
+		diff.MissingIndexes = append(diff.MissingIndexes, name)
+	}
+	sort.Strings(diff.MissingIndexes)
+	return diff, nil
+}
+
+// columnYql returns YQL type of field's column, e.g. Optional<Int64>
+func (m Migrator) columnYql(field *schema.Field) string {
+	if m.notNull(field) {
+		return m.DataTypeOf(field)
+	}
+	return "Optional<" + m.DataTypeOf(field) + ">"
+}
+
+// sameColumnType compares YQL types of field's column, nullability of primary keys is ignored
+// since primary keys of tables created before NOT NULL support are optional
+func sameColumnType(field *schema.Field, expected, current string) bool {
+	if field.PrimaryKey {
+		expected, current = unwrapOptional(expected), unwrapOptional(current)
+	}
+	return strings.EqualFold(expected, current)
+}
+
+// unwrapOptional returns item type of Optional YQL type or type itself
+func unwrapOptional(yql string) string {
+	if strings.HasPrefix(yql, "Optional<") && strings.HasSuffix(yql, ">") {
+		return yql[len("Optional<") : len(yql)-1]
+	}
+	return yql
+}
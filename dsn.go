@@ -0,0 +1,65 @@
+package ydb
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// DSN is structured data source name of database, String of DSN escapes database path and
+// parameters
+//
+//	dsn := ydb.DSN{
+//		Endpoint: "ydb.example.com:2135",
+//		Database: "/ru-central1/b1g/etn",
+//		Secure:   true,
+//		Params:   url.Values{"time_zone": {"Europe/Moscow"}},
+//	}
+//	db, err := gorm.Open(ydb.Open(dsn.String()))
+type DSN struct {
+	// Endpoint is host and port of database
+	Endpoint string
+	// Database is path of database, e.g. /local
+	Database string
+	// Secure selects grpcs scheme instead of grpc
+	Secure bool
+	// Params are DSN parameters, e.g. time_zone or single_endpoint
+	Params url.Values
+}
+
+func (dsn DSN) String() string {
+	u := url.URL{
+		Scheme:   "grpc",
+		Host:     dsn.Endpoint,
+		Path:     "/" + strings.TrimPrefix(dsn.Database, "/"),
+		RawQuery: dsn.Params.Encode(),
+	}
+	if dsn.Secure {
+		u.Scheme = "grpcs"
+	}
+	return u.String()
+}
+
+// ParseDSN parses data source name, e.g. grpcs://ydb.example.com:2135/ru-central1/b1g/etn
+func ParseDSN(s string) (DSN, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return DSN{}, fmt.Errorf("ydb: invalid DSN: %w", err)
+	}
+	if u.Scheme != "grpc" && u.Scheme != "grpcs" {
+		return DSN{}, fmt.Errorf("ydb: invalid DSN scheme %q, grpc or grpcs expected", u.Scheme)
+	}
+	if u.Host == "" {
+		return DSN{}, fmt.Errorf("ydb: DSN has no endpoint")
+	}
+	dsn := DSN{
+		Endpoint: u.Host,
+		Database: u.Path,
+		Secure:   u.Scheme == "grpcs",
+		Params:   u.Query(),
+	}
+	if len(dsn.Params) == 0 {
+		dsn.Params = nil
+	}
+	return dsn, nil
+}
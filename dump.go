@@ -0,0 +1,198 @@
+package ydb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/options"
+	"gorm.io/gorm"
+)
+
+// DumpSchema writes CREATE TABLE statements of live tables of models, or of all tables of database
+// when no models are given, with columns, column families, primary keys, secondary indexes and TTL
+// settings, e.g. to audit schema drift or bootstrap new environments by ApplySchema. Output is
+// reproducible: tables are sorted by path relative to database, indexes by name, and statistics or
+// partitions are not written. Descriptions of tables don't tell unique indexes, indexes are written
+// UNIQUE by uniqueIndex tags of models, so dump of all tables writes them as non-unique. Tables
+// of models which don't exist are skipped
+//
+//	var buf bytes.Buffer
+//	err := db.Migrator().(ydb.Migrator).DumpSchema(&buf, &User{}, &Order{})
+func (m Migrator) DumpSchema(w io.Writer, models ...interface{}) error {
+	type dumpTable struct {
+		name   string
+		unique map[string]bool
+	}
+	var tables []dumpTable
+	if len(models) == 0 {
+		names, err := m.listTables()
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			tables = append(tables, dumpTable{name: name})
+		}
+	}
+	for _, model := range withoutViews(models) {
+		if err := m.RunWithValue(model, func(stmt *gorm.Statement) error {
+			table := dumpTable{name: stmt.Table, unique: map[string]bool{}}
+			for _, idx := range uniqueIndexes(stmt.Schema) {
+				table.unique[idx.Name] = true
+			}
+			tables = append(tables, table)
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	sort.SliceStable(tables, func(i, j int) bool {
+		return tables[i].name < tables[j].name
+	})
+
+	for i, table := range tables {
+		if i > 0 && table.name == tables[i-1].name {
+			continue
+		}
+		desc, err := m.describeTable(table.name)
+		if ydb.IsOperationErrorSchemeError(err) && len(models) > 0 {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if _, err = io.WriteString(w, m.createTableStatement(table.name, desc, table.unique)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listTables returns paths of tables of database relative to it, system directories are skipped
+func (m Migrator) listTables() (tables []string, err error) {
+	nativeDriver, err := Unwrap(m.DB)
+	if err != nil {
+		return nil, err
+	}
+	root := nativeDriver.Name()
+	var list func(ctx context.Context, dir string) error
+	list = func(ctx context.Context, dir string) error {
+		d, err := nativeDriver.Scheme().ListDirectory(ctx, path.Join(root, dir))
+		if err != nil {
+			return err
+		}
+		for _, child := range d.Children {
+			if strings.HasPrefix(child.Name, ".") {
+				continue
+			}
+			switch name := path.Join(dir, child.Name); {
+			case child.IsTable():
+				tables = append(tables, name)
+			case child.IsDirectory():
+				if err = list(ctx, name); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err = list(m.context(), ""); err != nil {
+		return nil, err
+	}
+	sort.Strings(tables)
+	return tables, nil
+}
+
+// createTableStatement returns CREATE TABLE statement of table described by desc, indexes of
+// unique are written UNIQUE
+func (m Migrator) createTableStatement(name string, desc options.Description, unique map[string]bool) string {
+	quote := m.DB.Statement.Quote
+	quoteList := func(names []string) string {
+		quoted := make([]string, len(names))
+		for i, name := range names {
+			quoted[i] = quote(name)
+		}
+		return strings.Join(quoted, ", ")
+	}
+
+	var lines []string
+	for _, column := range desc.Columns {
+		line := quote(column.Name) + " " + unwrapOptional(column.Type.Yql())
+		if column.Family != "" {
+			line += " FAMILY " + quote(column.Family)
+		}
+		if !strings.HasPrefix(column.Type.Yql(), "Optional<") {
+			line += " NOT NULL"
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "PRIMARY KEY ("+quoteList(desc.PrimaryKey)+")")
+
+	indexes := append([]options.IndexDescription(nil), desc.Indexes...)
+	sort.Slice(indexes, func(i, j int) bool {
+		return indexes[i].Name < indexes[j].Name
+	})
+	for _, idx := range indexes {
+		line := "INDEX " + quote(idx.Name) + " GLOBAL "
+		if unique[idx.Name] {
+			line += "UNIQUE "
+		}
+		line += "SYNC ON (" + quoteList(idx.IndexColumns) + ")"
+		if len(idx.DataColumns) > 0 {
+			line += " COVER (" + quoteList(idx.DataColumns) + ")"
+		}
+		lines = append(lines, line)
+	}
+
+	for _, family := range desc.ColumnFamilies {
+		var settings []string
+		if family.Data.Media != "" {
+			settings = append(settings, fmt.Sprintf("DATA = %q", family.Data.Media))
+		}
+		switch family.Compression {
+		case options.ColumnFamilyCompressionNone:
+			settings = append(settings, `COMPRESSION = "off"`)
+		case options.ColumnFamilyCompressionLZ4:
+			settings = append(settings, `COMPRESSION = "lz4"`)
+		}
+		if len(settings) > 0 {
+			lines = append(lines, "FAMILY "+quote(family.Name)+" ("+strings.Join(settings, ", ")+")")
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("CREATE TABLE ")
+	sb.WriteString(quote(name))
+	sb.WriteString(" (\n")
+	for i, line := range lines {
+		sb.WriteString("\t")
+		sb.WriteString(line)
+		if i < len(lines)-1 {
+			sb.WriteByte(',')
+		}
+		sb.WriteByte('\n')
+	}
+	sb.WriteByte(')')
+	if ttl := desc.TimeToLiveSettings; ttl != nil {
+		fmt.Fprintf(&sb, "\nWITH (TTL = Interval(\"PT%dS\") ON %s", ttl.ExpireAfterSeconds, quote(ttl.ColumnName))
+		if ttl.Mode == options.TimeToLiveModeValueSinceUnixEpoch && ttl.ColumnUnit != nil {
+			switch *ttl.ColumnUnit {
+			case options.TimeToLiveUnitSeconds:
+				sb.WriteString(" AS SECONDS")
+			case options.TimeToLiveUnitMilliseconds:
+				sb.WriteString(" AS MILLISECONDS")
+			case options.TimeToLiveUnitMicroseconds:
+				sb.WriteString(" AS MICROSECONDS")
+			case options.TimeToLiveUnitNanoseconds:
+				sb.WriteString(" AS NANOSECONDS")
+			}
+		}
+		sb.WriteByte(')')
+	}
+	sb.WriteString(";\n\n")
+	return sb.String()
+}
@@ -0,0 +1,183 @@
+package ydb
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm/schema"
+)
+
+// ErrDecrypt returned for values of encrypted columns which cannot be decrypted
+var ErrDecrypt = errors.New("ydb: cannot decrypt value")
+
+// KeyProvider provides AES keys (16, 24 or 32 bytes) of encrypted columns, e.g. fetched from KMS
+type KeyProvider interface {
+	// CurrentKey returns key encrypting written values and its id
+	CurrentKey(ctx context.Context) (id string, key []byte, err error)
+	// Key returns key of id decrypting values encrypted by it
+	Key(ctx context.Context, id string) ([]byte, error)
+}
+
+// StaticKeys is KeyProvider of fixed keys by id, Current is id of key encrypting written values,
+// older keys are kept for reading values written before rotation
+type StaticKeys struct {
+	Keys    map[string][]byte
+	Current string
+}
+
+func (k StaticKeys) CurrentKey(ctx context.Context) (string, []byte, error) {
+	key, err := k.Key(ctx, k.Current)
+	return k.Current, key, err
+}
+
+func (k StaticKeys) Key(ctx context.Context, id string) ([]byte, error) {
+	key, ok := k.Keys[id]
+	if !ok {
+		return nil, fmt.Errorf("ydb: unknown encryption key %q", id)
+	}
+	return key, nil
+}
+
+// RegisterEncryption registers serializer "encrypted" encrypting values of tagged fields with
+// AES-GCM before writing them to String columns, values other than strings and bytes are
+// encrypted as JSON. Ciphertext is bound to table and column, so it cannot be copied to other
+// columns. Serializer must be registered before models are parsed
+//
+//	ydb.RegisterEncryption(ydb.StaticKeys{Keys: map[string][]byte{"2024": key}, Current: "2024"})
+//
+//	type User struct {
+//		ID    uint64
+//		Email string `gorm:"serializer:encrypted"`
+//	}
+func RegisterEncryption(keys KeyProvider) {
+	schema.RegisterSerializer("encrypted", &EncryptedSerializer{Keys: keys})
+}
+
+// EncryptedSerializer encrypts values of fields with keys of KeyProvider
+type EncryptedSerializer struct {
+	Keys KeyProvider
+}
+
+// encryptedVersion is the first byte of ciphertext followed by length of key id, key id,
+// nonce and sealed value
+const encryptedVersion = 1
+
+func (s *EncryptedSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	var plaintext []byte
+	switch v := fieldValue.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		plaintext = []byte(v)
+	case []byte:
+		plaintext = v
+	default:
+		if rv := reflect.ValueOf(fieldValue); rv.Kind() == reflect.Ptr && rv.IsNil() {
+			return nil, nil
+		}
+		var err error
+		if plaintext, err = json.Marshal(fieldValue); err != nil {
+			return nil, err
+		}
+	}
+
+	id, key, err := s.Keys.CurrentKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(id) > 255 {
+		return nil, fmt.Errorf("ydb: encryption key id %q is too long", id)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	ciphertext := make([]byte, 0, 2+len(id)+len(nonce)+len(plaintext)+gcm.Overhead())
+	ciphertext = append(ciphertext, encryptedVersion, byte(len(id)))
+	ciphertext = append(ciphertext, id...)
+	ciphertext = append(ciphertext, nonce...)
+	return gcm.Seal(ciphertext, nonce, plaintext, associatedData(field)), nil
+}
+
+func (s *EncryptedSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var ciphertext []byte
+	switch v := dbValue.(type) {
+	case nil:
+		field.ReflectValueOf(ctx, dst).Set(reflect.Zero(field.FieldType))
+		return nil
+	case []byte:
+		ciphertext = v
+	case string:
+		ciphertext = []byte(v)
+	default:
+		return fmt.Errorf("%w: unsupported value %T of %s", ErrDecrypt, dbValue, field.Name)
+	}
+
+	if len(ciphertext) < 2 || ciphertext[0] != encryptedVersion || len(ciphertext) < 2+int(ciphertext[1]) {
+		return fmt.Errorf("%w: malformed value of %s", ErrDecrypt, field.Name)
+	}
+	id, ciphertext := string(ciphertext[2:2+ciphertext[1]]), ciphertext[2+ciphertext[1]:]
+	key, err := s.Keys.Key(ctx, id)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return fmt.Errorf("%w: malformed value of %s", ErrDecrypt, field.Name)
+	}
+	plaintext, err := gcm.Open(nil, ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():], associatedData(field))
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrDecrypt, field.Name, err)
+	}
+
+	fieldValue := reflect.New(field.IndirectFieldType)
+	switch field.IndirectFieldType.Kind() {
+	case reflect.String:
+		fieldValue.Elem().SetString(string(plaintext))
+	case reflect.Slice:
+		if field.IndirectFieldType.Elem().Kind() == reflect.Uint8 {
+			fieldValue.Elem().SetBytes(plaintext)
+			break
+		}
+		fallthrough
+	default:
+		if err = json.Unmarshal(plaintext, fieldValue.Interface()); err != nil {
+			return err
+		}
+	}
+	if field.FieldType.Kind() != reflect.Ptr {
+		fieldValue = fieldValue.Elem()
+	}
+	field.ReflectValueOf(ctx, dst).Set(fieldValue)
+	return nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// associatedData binds ciphertext to column of field
+func associatedData(field *schema.Field) []byte {
+	if field.Schema == nil {
+		return []byte(field.DBName)
+	}
+	return []byte(field.Schema.Table + "." + field.DBName)
+}
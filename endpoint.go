@@ -0,0 +1,32 @@
+package ydb
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"github.com/ydb-platform/ydb-go-sdk/v3/balancers"
+)
+
+// endpointOptions returns options of endpoint override and of single endpoint mode of config or
+// of single_endpoint DSN parameter
+func (config *Config) endpointOptions() ([]ydb.Option, error) {
+	single := config.SingleEndpoint
+	if uri, err := url.Parse(config.DSN); err == nil && !single {
+		if param := uri.Query().Get("single_endpoint"); param != "" {
+			if single, err = strconv.ParseBool(param); err != nil {
+				return nil, fmt.Errorf("ydb: invalid single_endpoint %q", param)
+			}
+		}
+	}
+
+	var opts []ydb.Option
+	if config.Endpoint != "" {
+		opts = append(opts, ydb.WithEndpoint(config.Endpoint))
+	}
+	if single {
+		opts = append(opts, ydb.WithBalancer(balancers.SingleConn()))
+	}
+	return opts, nil
+}
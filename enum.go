@@ -0,0 +1,140 @@
+package ydb
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// EnumStorage column type of enum
+type EnumStorage int
+
+const (
+	// EnumUtf8 stores enum value name in Utf8 column
+	EnumUtf8 EnumStorage = iota
+	// EnumUint8 stores numeric enum value in Uint8 column
+	EnumUint8
+)
+
+// EnumUnknownPolicy defines how database values not matching any enum value are read
+type EnumUnknownPolicy int
+
+const (
+	// EnumUnknownError fails scanning
+	EnumUnknownError EnumUnknownPolicy = iota
+	// EnumUnknownZero reads zero value of enum type
+	EnumUnknownZero
+	// EnumUnknownFallback reads Enum.Fallback
+	EnumUnknownFallback
+)
+
+// Enum describes values of Go enum type
+type Enum struct {
+	Storage EnumStorage
+	// Values enum values with names stored in Utf8 columns
+	Values   map[interface{}]string
+	Unknown  EnumUnknownPolicy
+	Fallback interface{}
+}
+
+// ErrInvalidEnumValue returned on write of value not described by Enum.Values
+var ErrInvalidEnumValue = errors.New("ydb: invalid enum value")
+
+// RegisterEnum registers type mapping for enum type of value. Mapping is registered for type
+// of value instead of Enum[T] wrapping fields, since type parameters need Go 1.18 and go.mod
+// targets Go 1.14, so fields keep their own enum types and values of Enum are checked against
+// the type at registration
+//
+//	type Status uint8
+//
+//	const (
+//		StatusNew Status = iota
+//		StatusDone
+//	)
+//
+//	ydb.RegisterEnum(StatusNew, ydb.Enum{
+//		Storage: ydb.EnumUtf8,
+//		Values:  map[interface{}]string{StatusNew: "new", StatusDone: "done"},
+//		Unknown: ydb.EnumUnknownZero,
+//	})
+func RegisterEnum(value interface{}, enum Enum) error {
+	enumType := reflect.TypeOf(value)
+	names := make(map[string]interface{}, len(enum.Values))
+	codes := make(map[uint64]interface{}, len(enum.Values))
+	for v, name := range enum.Values {
+		if reflect.TypeOf(v) != enumType {
+			return fmt.Errorf("ydb: enum value %v is not of type %s", v, enumType)
+		}
+		names[name] = v
+		if enum.Storage == EnumUint8 {
+			code, ok := enumCode(v)
+			if !ok {
+				return fmt.Errorf("ydb: enum value %v doesn't fit Uint8", v)
+			}
+			codes[code] = v
+		}
+	}
+	if enum.Unknown == EnumUnknownFallback && reflect.TypeOf(enum.Fallback) != enumType {
+		return fmt.Errorf("ydb: enum fallback %v is not of type %s", enum.Fallback, enumType)
+	}
+
+	yqlType := "Utf8"
+	if enum.Storage == EnumUint8 {
+		yqlType = "Uint8"
+	}
+
+	RegisterTypeMapping(value, yqlType, func(v interface{}) (interface{}, error) {
+		name, ok := enum.Values[v]
+		if !ok {
+			return nil, fmt.Errorf("%w %v of %s", ErrInvalidEnumValue, v, enumType)
+		}
+		if enum.Storage == EnumUint8 {
+			code, _ := enumCode(v)
+			return uint8(code), nil
+		}
+		return name, nil
+	}, func(src interface{}, dst interface{}) error {
+		var (
+			v  interface{}
+			ok bool
+		)
+		switch x := src.(type) {
+		case string:
+			v, ok = names[x]
+		case []byte:
+			v, ok = names[string(x)]
+		default:
+			if code, isCode := enumCode(src); isCode {
+				v, ok = codes[code]
+			}
+		}
+		if !ok {
+			switch enum.Unknown {
+			case EnumUnknownZero:
+				v = reflect.Zero(enumType).Interface()
+			case EnumUnknownFallback:
+				v = enum.Fallback
+			default:
+				return fmt.Errorf("ydb: unknown %s enum value %v", enumType, src)
+			}
+		}
+		reflect.ValueOf(dst).Elem().Set(reflect.ValueOf(v))
+		return nil
+	})
+	return nil
+}
+
+func enumCode(v interface{}) (uint64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i := rv.Int(); i >= 0 && i <= 255 {
+			return uint64(i), true
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if u := rv.Uint(); u <= 255 {
+			return u, true
+		}
+	}
+	return 0, false
+}
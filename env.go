@@ -0,0 +1,112 @@
+package ydb
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"gorm.io/gorm"
+)
+
+// Environment variables read by NewFromEnv
+const (
+	// EnvConnectionString DSN of database, e.g. grpcs://ydb.example.com:2135/ru-central1/b1g/etn
+	EnvConnectionString = "YDB_CONNECTION_STRING"
+	// EnvEndpoint and EnvDatabase build DSN when EnvConnectionString is not set, EnvEndpoint
+	// overrides endpoint of EnvConnectionString otherwise
+	EnvEndpoint = "YDB_ENDPOINT"
+	EnvDatabase = "YDB_DATABASE"
+	// EnvAuthMode is token (default, access token of YDB_TOKEN), anonymous or static (user and
+	// password of YDB_USER and YDB_PASSWORD)
+	EnvAuthMode = "YDB_AUTH_MODE"
+	EnvToken    = "YDB_TOKEN"
+	EnvUser     = "YDB_USER"
+	EnvPassword = "YDB_PASSWORD"
+	// EnvMaxSessions is Config.MaxSessions
+	EnvMaxSessions = "YDB_MAX_SESSIONS"
+	// EnvQueryMode is default query mode of connector: data, scan, explain, scheme or scripting
+	EnvQueryMode = "YDB_QUERY_MODE"
+	// EnvOperationTimeout is Config.OperationTimeout, e.g. 5s
+	EnvOperationTimeout = "YDB_OPERATION_TIMEOUT"
+	// EnvSingleEndpoint is Config.SingleEndpoint, e.g. true
+	EnvSingleEndpoint = "YDB_SINGLE_ENDPOINT"
+)
+
+var queryModes = map[string]ydb.QueryMode{
+	"data":      ydb.DataQueryMode,
+	"scan":      ydb.ScanQueryMode,
+	"explain":   ydb.ExplainQueryMode,
+	"scheme":    ydb.SchemeQueryMode,
+	"scripting": ydb.ScriptingQueryMode,
+}
+
+// NewFromEnv returns dialector configured by environment variables (see EnvConnectionString
+// and others), so services are configured in the same way in all deployments
+//
+//	dialector, err := ydb.NewFromEnv()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	db, err := gorm.Open(dialector)
+func NewFromEnv() (gorm.Dialector, error) {
+	config, err := configFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return New(config), nil
+}
+
+func configFromEnv() (config Config, err error) {
+	config.DSN = os.Getenv(EnvConnectionString)
+	endpoint, database := os.Getenv(EnvEndpoint), os.Getenv(EnvDatabase)
+	switch {
+	case config.DSN != "":
+		config.Endpoint = endpoint
+	case endpoint != "" && database != "":
+		dsn := DSN{Endpoint: strings.TrimSuffix(endpoint, "/"), Database: database, Secure: true}
+		if i := strings.Index(dsn.Endpoint, "://"); i >= 0 {
+			dsn.Secure, dsn.Endpoint = dsn.Endpoint[:i] == "grpcs", dsn.Endpoint[i+len("://"):]
+		}
+		config.DSN = dsn.String()
+	default:
+		return config, fmt.Errorf("ydb: %s or %s and %s must be set", EnvConnectionString, EnvEndpoint, EnvDatabase)
+	}
+
+	switch mode := os.Getenv(EnvAuthMode); mode {
+	case "", "token":
+		config.Credentials = ydb.WithAccessTokenCredentials(os.Getenv(EnvToken))
+	case "anonymous":
+		config.Credentials = ydb.WithAnonymousCredentials()
+	case "static":
+		config.Credentials = ydb.WithStaticCredentials(os.Getenv(EnvUser), os.Getenv(EnvPassword))
+	default:
+		return config, fmt.Errorf("ydb: unknown %s %q", EnvAuthMode, mode)
+	}
+
+	if v := os.Getenv(EnvMaxSessions); v != "" {
+		if config.MaxSessions, err = strconv.Atoi(v); err != nil {
+			return config, fmt.Errorf("ydb: invalid %s: %w", EnvMaxSessions, err)
+		}
+	}
+	if v := os.Getenv(EnvQueryMode); v != "" {
+		mode, ok := queryModes[v]
+		if !ok {
+			return config, fmt.Errorf("ydb: unknown %s %q", EnvQueryMode, v)
+		}
+		config.ConnectorOptions = append(config.ConnectorOptions, ydb.WithDefaultQueryMode(mode))
+	}
+	if v := os.Getenv(EnvOperationTimeout); v != "" {
+		if config.OperationTimeout, err = time.ParseDuration(v); err != nil {
+			return config, fmt.Errorf("ydb: invalid %s: %w", EnvOperationTimeout, err)
+		}
+	}
+	if v := os.Getenv(EnvSingleEndpoint); v != "" {
+		if config.SingleEndpoint, err = strconv.ParseBool(v); err != nil {
+			return config, fmt.Errorf("ydb: invalid %s: %w", EnvSingleEndpoint, err)
+		}
+	}
+	return config, nil
+}
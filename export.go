@@ -0,0 +1,116 @@
+package ydb
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"gorm.io/gorm"
+)
+
+// ExportFormat creates encoder of rows with columns written to w
+type ExportFormat func(w io.Writer, columns []string) (ExportEncoder, error)
+
+// ExportEncoder encodes exported rows
+type ExportEncoder interface {
+	// Encode encodes values of row in order of columns
+	Encode(row []interface{}) error
+	// Close flushes encoded rows
+	Close() error
+}
+
+// CSV is export format writing header with names of columns and rows as RFC 4180 records,
+// NULLs are written as empty fields and times in RFC 3339 format.
+// Apache Arrow is written by Arrow, other formats are plugged in by implementing ExportFormat
+var CSV ExportFormat = func(w io.Writer, columns []string) (ExportEncoder, error) {
+	encoder := &csvEncoder{w: csv.NewWriter(w)}
+	if err := encoder.w.Write(columns); err != nil {
+		return nil, err
+	}
+	return encoder, nil
+}
+
+// Export streams result of query executed as scan query to w in format without materializing
+// it in memory and returns count of exported rows
+//
+//	f, _ := os.Create("orders.csv")
+//	defer f.Close()
+//	n, err := ydb.Export(ctx, db, "SELECT * FROM orders WHERE created_at > ?", f, ydb.CSV, since)
+//
+// ydb.Arrow is passed as format to write Apache Arrow IPC stream instead
+func Export(ctx context.Context, db *gorm.DB, query string, w io.Writer, format ExportFormat, args ...interface{}) (exported int64, err error) {
+	rows, err := db.WithContext(ydb.WithQueryMode(ctx, ydb.ScanQueryMode)).Raw(query, args...).Rows()
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	encoder, err := format(w, columns)
+	if err != nil {
+		return 0, err
+	}
+
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	for rows.Next() {
+		if err = rows.Scan(pointers...); err != nil {
+			return exported, err
+		}
+		if err = encoder.Encode(values); err != nil {
+			return exported, err
+		}
+		exported++
+	}
+	if err = rows.Err(); err != nil {
+		return exported, err
+	}
+	return exported, encoder.Close()
+}
+
+type csvEncoder struct {
+	w      *csv.Writer
+	record []string
+}
+
+func (e *csvEncoder) Encode(row []interface{}) error {
+	e.record = e.record[:0]
+	for _, v := range row {
+		e.record = append(e.record, csvField(v))
+	}
+	return e.w.Write(e.record)
+}
+
+func (e *csvEncoder) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func csvField(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,107 @@
+package ydb
+
+import (
+	"hash/fnv"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	collapsedLists  = regexp.MustCompile(`\?(\s*,\s*\?)+`)
+	collapsedTuples = regexp.MustCompile(`\(\?\)(\s*,\s*\(\?\))+`)
+)
+
+// NormalizeQuery returns query with literals, ? placeholders and $parameters replaced by ?,
+// lists of them collapsed to single ?, comments and DECLARE statements removed and whitespace
+// collapsed, so statements differing only by values and count of IN values are equal
+//
+//	ydb.NormalizeQuery("SELECT * FROM `users` WHERE id IN (1, 2, 3) AND name = 'x'")
+//	// SELECT * FROM `users` WHERE id IN (?) AND name = ?
+func NormalizeQuery(query string) string {
+	var sb strings.Builder
+	sb.Grow(len(query))
+	space := func() {
+		if s := sb.String(); len(s) > 0 && s[len(s)-1] != ' ' {
+			sb.WriteByte(' ')
+		}
+	}
+	identifier := func(c byte) bool {
+		return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+	}
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '-' && i+1 < len(query) && query[i+1] == '-':
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+			space()
+		case c == '/' && i+1 < len(query) && query[i+1] == '*':
+			if end := strings.Index(query[i+2:], "*/"); end >= 0 {
+				i += end + 3
+			} else {
+				i = len(query)
+			}
+			space()
+		case c == '\'' || c == '"' || c == '`':
+			start := i
+			for i++; i < len(query) && query[i] != c; i++ {
+				if query[i] == '\\' && c != '`' {
+					i++
+				}
+			}
+			if c == '`' {
+				if i >= len(query) {
+					i = len(query) - 1
+				}
+				sb.WriteString(query[start : i+1])
+			} else {
+				// literal with optional type suffix, e.g. "..."u or '...'j
+				for i+1 < len(query) && identifier(query[i+1]) {
+					i++
+				}
+				sb.WriteByte('?')
+			}
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			space()
+		case c == '?' || c == '$':
+			for i+1 < len(query) && identifier(query[i+1]) {
+				i++
+			}
+			sb.WriteByte('?')
+		case c >= '0' && c <= '9':
+			for i+1 < len(query) && (identifier(query[i+1]) || query[i+1] == '.') {
+				i++
+			}
+			sb.WriteByte('?')
+		case identifier(c):
+			start := i
+			for i+1 < len(query) && identifier(query[i+1]) {
+				i++
+			}
+			word := query[start : i+1]
+			if strings.EqualFold(word, "DECLARE") {
+				if end := strings.IndexByte(query[i:], ';'); end >= 0 {
+					i += end
+					space()
+					continue
+				}
+			}
+			sb.WriteString(word)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	normalized := collapsedLists.ReplaceAllString(sb.String(), "?")
+	normalized = collapsedTuples.ReplaceAllString(normalized, "(?)")
+	return strings.TrimSpace(normalized)
+}
+
+// Fingerprint returns hash of normalized query identifying statements of the same shape in
+// logs and metrics, e.g. for dashboards of top queries
+func Fingerprint(query string) string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(NormalizeQuery(query)))
+	return strconv.FormatUint(h.Sum64(), 16)
+}
@@ -0,0 +1,111 @@
+package ydb
+
+import (
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+	"gorm.io/gorm/schema"
+)
+
+type floatRow struct {
+	ID       int64 `gorm:"primaryKey;autoIncrement:false"`
+	Float32  float32
+	Float64  float64
+	Single   float64 `gorm:"precision:24"`
+	Double   float32 `gorm:"precision:53"`
+	Optional *float32
+}
+
+func TestDataTypeOfFloat(t *testing.T) {
+	s, err := schema.Parse(&floatRow{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		doubleFloats bool
+		field        string
+		want         string
+	}{
+		{false, "Float32", "Float"},
+		{false, "Float64", "Double"},
+		{false, "Single", "Float"},
+		{false, "Double", "Double"},
+		{false, "Optional", "Float"},
+		{true, "Float32", "Double"},
+		{true, "Float64", "Double"},
+		{true, "Single", "Float"},
+		{true, "Optional", "Double"},
+	} {
+		dialector := Dialector{Config: &Config{DoubleFloats: tt.doubleFloats}}
+		if got := dialector.DataTypeOf(s.LookUpField(tt.field)); got != tt.want {
+			t.Errorf("DataTypeOf(%s) with DoubleFloats %t = %s, want %s", tt.field, tt.doubleFloats, got, tt.want)
+		}
+	}
+}
+
+func TestConvertFloat(t *testing.T) {
+	f := float32(0.1)
+	for _, tt := range []struct {
+		doubleFloats bool
+		v            interface{}
+		want         interface{}
+	}{
+		{false, f, f},
+		{false, &f, &f},
+		{true, f, float64(f)},
+		{true, &f, float64(f)},
+		{true, 0.1, 0.1},
+	} {
+		config := &Config{DoubleFloats: tt.doubleFloats}
+		if got := config.convertFloat(tt.v); got != tt.want {
+			t.Errorf("convertFloat(%v) with DoubleFloats %t = %v, want %v", tt.v, tt.doubleFloats, got, tt.want)
+		}
+	}
+	var null *float32
+	got, ok := (&Config{DoubleFloats: true}).convertFloat(null).(types.Value)
+	if !ok || !types.Equal(got.Type(), types.Optional(types.TypeDouble)) {
+		t.Errorf("convertFloat(nil) = %v, want NULL of Double", got)
+	}
+}
+
+func TestFloatRoundTrip(t *testing.T) {
+	for _, doubleFloats := range []bool{false, true} {
+		db := openTestDB(t, func(config *Config) { config.DoubleFloats = doubleFloats })
+		migrateTestTables(t, db, &floatRow{})
+
+		float32s := []float32{0, 0.1, -1.5, math.Pi, math.MaxFloat32, math.SmallestNonzeroFloat32, 16777217}
+		float64s := []float64{0, 0.1, -1.5, math.Pi, math.MaxFloat64, math.SmallestNonzeroFloat64, 1<<53 + 1}
+		rows := make([]floatRow, len(float32s))
+		for i := range rows {
+			optional := float32s[i]
+			rows[i] = floatRow{
+				ID:       int64(i),
+				Float32:  float32s[i],
+				Float64:  float64s[i],
+				Single:   float64(float32s[i]),
+				Double:   float32s[i],
+				Optional: &optional,
+			}
+		}
+		if err := db.Create(&rows).Error; err != nil {
+			t.Fatal(err)
+		}
+
+		var got []floatRow
+		if err := db.Order("id").Find(&got).Error; err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(rows) {
+			t.Fatalf("DoubleFloats %t: read %d rows, want %d", doubleFloats, len(got), len(rows))
+		}
+		for i, row := range rows {
+			g := got[i]
+			if g.Float32 != row.Float32 || g.Float64 != row.Float64 || g.Single != row.Single ||
+				g.Double != row.Double || g.Optional == nil || *g.Optional != *row.Optional {
+				t.Errorf("DoubleFloats %t: read %+v, want %+v", doubleFloats, g, row)
+			}
+		}
+	}
+}
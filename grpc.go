@@ -0,0 +1,60 @@
+package ydb
+
+import (
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"github.com/ydb-platform/ydb-go-sdk/v3/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/keepalive"
+)
+
+// GRPCOptions configures gRPC transport of native driver opened by the dialector, defaults of
+// gRPC limit received messages to 4MB, which large BulkUpsert batches and wide rows exceed
+type GRPCOptions struct {
+	// Compression of requests, "gzip" or name of other registered compressor, none by default
+	Compression string
+	// MaxRecvMsgSize and MaxSendMsgSize max size of received and sent messages in bytes
+	MaxRecvMsgSize int
+	MaxSendMsgSize int
+	// KeepaliveTime interval of keepalive pings of idle connections, KeepaliveTimeout waits
+	// for ping ack before connection is closed
+	KeepaliveTime    time.Duration
+	KeepaliveTimeout time.Duration
+}
+
+// GzipCompression is name of gzip compressor of GRPCOptions
+const GzipCompression = gzip.Name
+
+func (o *GRPCOptions) driverOptions() []ydb.Option {
+	if o == nil {
+		return nil
+	}
+	var callOptions []grpc.CallOption
+	if o.Compression != "" {
+		callOptions = append(callOptions, grpc.UseCompressor(o.Compression))
+	}
+	if o.MaxRecvMsgSize > 0 {
+		callOptions = append(callOptions, grpc.MaxCallRecvMsgSize(o.MaxRecvMsgSize))
+	}
+	if o.MaxSendMsgSize > 0 {
+		callOptions = append(callOptions, grpc.MaxCallSendMsgSize(o.MaxSendMsgSize))
+	}
+
+	var dialOptions []grpc.DialOption
+	if len(callOptions) > 0 {
+		dialOptions = append(dialOptions, grpc.WithDefaultCallOptions(callOptions...))
+	}
+	if o.KeepaliveTime > 0 {
+		dialOptions = append(dialOptions, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                o.KeepaliveTime,
+			Timeout:             o.KeepaliveTimeout,
+			PermitWithoutStream: true,
+		}))
+	}
+	if len(dialOptions) == 0 {
+		return nil
+	}
+	return []ydb.Option{ydb.With(config.WithGrpcOptions(dialOptions...))}
+}
@@ -0,0 +1,182 @@
+package ydb
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// HashShard returns shard in [0, shards) of values of key columns, it is value of field tagged
+// with hashShard for the values, so queries by the key can use full primary key. Shards must be
+// positive, HashShard panics otherwise. Pointers are dereferenced and driver.Valuer values are
+// hashed by their driver values, so shards don't depend on addresses or wrappers of values.
+// Shards are hashed by the client with FNV-1a rather than by Digest functions of YQL, which
+// can't reproduce it, so statements computing shards on server (e.g. INSERT ... SELECT) must
+// read them from rows written by the dialector
+//
+//	db.Where("shard = ? AND id = ?", ydb.HashShard(16, id), id).Take(&event)
+func HashShard(shards uint64, values ...interface{}) uint64 {
+	h := fnv.New64a()
+	for _, v := range values {
+		switch v := hashValue(v).(type) {
+		case time.Time:
+			h.Write([]byte(v.UTC().Format(time.RFC3339Nano)))
+		case []byte:
+			h.Write(v)
+		default:
+			fmt.Fprint(h, v)
+		}
+		h.Write([]byte{0})
+	}
+	return h.Sum64() % shards
+}
+
+// hashValue returns value hashed for v: pointed value or driver value of driver.Valuer
+func hashValue(v interface{}) interface{} {
+	v = unwrapSensitive(v)
+	for i := 0; i < 2; i++ {
+		rv := reflect.ValueOf(v)
+		for rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				return nil
+			}
+			rv = rv.Elem()
+			v = rv.Interface()
+		}
+		valuer, ok := v.(driver.Valuer)
+		if !ok {
+			if rv.CanAddr() {
+				valuer, ok = rv.Addr().Interface().(driver.Valuer)
+			}
+			if !ok {
+				return v
+			}
+		}
+		value, err := valuer.Value()
+		if err != nil {
+			return v
+		}
+		v = value
+	}
+	return v
+}
+
+// hashShardField is field tagged with hashShard:N[,column...] keeping shard of source columns,
+// other primary key columns by default
+type hashShardField struct {
+	field   *schema.Field
+	shards  uint64
+	sources []*schema.Field
+}
+
+var hashShardFields sync.Map // *schema.Schema -> []hashShardField
+
+// lookupHashShardFields returns hash shard fields of schema, leading primary key fields spread
+// writes with monotonically increasing keys over partitions
+//
+//	type Event struct {
+//		Shard uint64 `gorm:"primaryKey;hashShard:16"`
+//		ID    uint64 `gorm:"primaryKey;autoIncrement:false"`
+//	}
+func lookupHashShardFields(s *schema.Schema) ([]hashShardField, error) {
+	if v, ok := hashShardFields.Load(s); ok {
+		return v.([]hashShardField), nil
+	}
+	var fields []hashShardField
+	for _, field := range s.Fields {
+		setting, ok := field.TagSettings["HASHSHARD"]
+		if !ok {
+			continue
+		}
+		if len(s.PrimaryFields) == 0 || s.PrimaryFields[0] != field {
+			return nil, fmt.Errorf("ydb: hash shard field %s must be the first primary key field of %s", field.Name, s.Name)
+		}
+		args := strings.Split(setting, ",")
+		shards, err := strconv.ParseUint(strings.TrimSpace(args[0]), 10, 64)
+		if err != nil || shards == 0 {
+			return nil, fmt.Errorf("ydb: invalid count of shards %q of %s", args[0], field.Name)
+		}
+		shard := hashShardField{field: field, shards: shards}
+		if len(args) > 1 {
+			for _, column := range args[1:] {
+				source := s.LookUpField(strings.TrimSpace(column))
+				if source == nil {
+					return nil, fmt.Errorf("ydb: unknown column %q of hash shard %s", column, field.Name)
+				}
+				shard.sources = append(shard.sources, source)
+			}
+		} else {
+			shard.sources = s.PrimaryFields[1:]
+		}
+		if len(shard.sources) == 0 {
+			return nil, fmt.Errorf("ydb: hash shard %s has no key columns", field.Name)
+		}
+		fields = append(fields, shard)
+	}
+	hashShardFields.Store(s, fields)
+	return fields, nil
+}
+
+func registerHashShardCallbacks(db *gorm.DB) error {
+	callback := db.Callback()
+	if err := callback.Create().Before("gorm:create").Register("ydb:hash_shard", assignHashShards); err != nil {
+		return err
+	}
+	if err := callback.Query().Before("gorm:query").Register("ydb:hash_shard", assignHashShards); err != nil {
+		return err
+	}
+	if err := callback.Update().Before("gorm:update").Register("ydb:hash_shard", assignHashShards); err != nil {
+		return err
+	}
+	return callback.Delete().Before("gorm:delete").Register("ydb:hash_shard", assignHashShards)
+}
+
+// assignHashShards sets hash shard fields of models of statement with non zero key columns,
+// so creates write shard and primary key conditions of queries, updates and deletes include it
+func assignHashShards(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+	fields, err := lookupHashShardFields(db.Statement.Schema)
+	if db.AddError(err) != nil || len(fields) == 0 {
+		return
+	}
+
+	ctx := db.Statement.Context
+	assign := func(rv reflect.Value) {
+		for _, shard := range fields {
+			values := make([]interface{}, 0, len(shard.sources))
+			for _, source := range shard.sources {
+				v, isZero := source.ValueOf(ctx, rv)
+				if isZero {
+					values = nil
+					break
+				}
+				values = append(values, v)
+			}
+			if values != nil {
+				db.AddError(shard.field.Set(ctx, rv, HashShard(shard.shards, values...)))
+			}
+		}
+	}
+	switch rv := reflect.Indirect(db.Statement.ReflectValue); rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len() && db.Error == nil; i++ {
+			if elem := reflect.Indirect(rv.Index(i)); elem.Kind() == reflect.Struct {
+				assign(elem)
+			}
+		}
+	case reflect.Struct:
+		if rv.Type() == db.Statement.Schema.ModelType {
+			assign(rv)
+		}
+	}
+}
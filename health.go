@@ -0,0 +1,66 @@
+package ydb
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Health describes connection state for readiness probes
+type Health struct {
+	Endpoint  string
+	Database  string
+	Secure    bool
+	Endpoints []HealthEndpoint
+	Latency   time.Duration
+}
+
+// HealthEndpoint is endpoint known by discovery
+type HealthEndpoint struct {
+	NodeID     uint32
+	Address    string
+	Location   string
+	LocalDC    bool
+	LoadFactor float32
+}
+
+// HealthCheck discovers cluster endpoints and executes lightweight data query
+func HealthCheck(ctx context.Context, db *gorm.DB) (*Health, error) {
+	nativeDriver, err := Unwrap(db)
+	if err != nil {
+		return nil, err
+	}
+
+	health := &Health{
+		Endpoint: nativeDriver.Endpoint(),
+		Database: nativeDriver.Name(),
+		Secure:   nativeDriver.Secure(),
+	}
+
+	endpoints, err := nativeDriver.Discovery().Discover(ctx)
+	if err != nil {
+		return health, err
+	}
+	for _, e := range endpoints {
+		health.Endpoints = append(health.Endpoints, HealthEndpoint{
+			NodeID:     e.NodeID(),
+			Address:    e.Address(),
+			Location:   e.Location(),
+			LocalDC:    e.LocalDC(),
+			LoadFactor: e.LoadFactor(),
+		})
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return health, err
+	}
+	start := time.Now()
+	if err = sqlDB.PingContext(ctx); err != nil {
+		return health, err
+	}
+	health.Latency = time.Since(start)
+
+	return health, nil
+}
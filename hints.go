@@ -0,0 +1,124 @@
+package ydb
+
+import (
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// hintsPkgPath is path of gorm.io/hints package, its expressions are recognized by type
+const hintsPkgPath = "gorm.io/hints"
+
+func isHint(expr clause.Expression) bool {
+	t := reflect.TypeOf(expr)
+	return t != nil && t.PkgPath() == hintsPkgPath
+}
+
+// registerHintsCallbacks translates expressions of gorm.io/hints by their shape, so code shared
+// with other dialects works without the dialector depending on the package. hints.UseIndex and
+// hints.ForceIndex read the table through its secondary index with VIEW, only the first index
+// is used since YQL reads through one index. hints.IgnoreIndex is dropped, YDB uses secondary
+// indexes only by VIEW. Optimizer hints of hints.New (/*+ ... */) are dropped as their syntax
+// is specific to MySQL, comments of hints.Comment, hints.CommentBefore and hints.CommentAfter
+// are written as is
+//
+//	db.Clauses(hints.UseIndex("idx_users_email")).Where("email = ?", email).Find(&users)
+//	// SELECT * FROM `users` VIEW `idx_users_email` WHERE email = ?
+func registerHintsCallbacks(db *gorm.DB) error {
+	callback := db.Callback()
+	if err := callback.Query().Before("gorm:query").Register("ydb:hints", dropOptimizerHints); err != nil {
+		return err
+	}
+	if err := callback.Row().Before("gorm:row").Register("ydb:hints", dropOptimizerHints); err != nil {
+		return err
+	}
+	if err := callback.Create().Before("gorm:create").Register("ydb:hints", dropOptimizerHints); err != nil {
+		return err
+	}
+	if err := callback.Update().Before("gorm:update").Register("ydb:hints", dropOptimizerHints); err != nil {
+		return err
+	}
+	return callback.Delete().Before("gorm:delete").Register("ydb:hints", dropOptimizerHints)
+}
+
+// dropOptimizerHints removes optimizer hints of gorm.io/hints from clauses of statement
+func dropOptimizerHints(db *gorm.DB) {
+	for name, c := range db.Statement.Clauses {
+		c.BeforeExpression = filterHints(c.BeforeExpression, isOptimizerHint)
+		c.AfterNameExpression = filterHints(c.AfterNameExpression, isOptimizerHint)
+		c.AfterExpression = filterHints(c.AfterExpression, isOptimizerHint)
+		db.Statement.Clauses[name] = c
+	}
+}
+
+// filterHints returns expr without hints matching drop, nil if nothing is left
+func filterHints(expr clause.Expression, drop func(reflect.Value) bool) clause.Expression {
+	if !isHint(expr) {
+		return expr
+	}
+	rv := reflect.ValueOf(expr)
+	if rv.Kind() != reflect.Slice {
+		if drop(rv) {
+			return nil
+		}
+		return expr
+	}
+
+	// hints.Exprs
+	kept := reflect.MakeSlice(rv.Type(), 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		if e, ok := rv.Index(i).Interface().(clause.Expression); ok {
+			if e = filterHints(e, drop); e != nil {
+				kept = reflect.Append(kept, reflect.ValueOf(e))
+			}
+		}
+	}
+	if kept.Len() == 0 {
+		return nil
+	}
+	return kept.Interface().(clause.Expression)
+}
+
+// isOptimizerHint reports whether hint is hints.Hints with /*+ ... */ content
+func isOptimizerHint(hint reflect.Value) bool {
+	if hint.Kind() != reflect.Struct {
+		return false
+	}
+	prefix := hint.FieldByName("Prefix")
+	return prefix.Kind() == reflect.String && strings.HasPrefix(prefix.String(), "/*+")
+}
+
+// isIndexHint reports whether hint is hints.IndexHint
+func isIndexHint(hint reflect.Value) bool {
+	return hint.Kind() == reflect.Struct &&
+		hint.FieldByName("Type").Kind() == reflect.String && hint.FieldByName("Keys").Kind() == reflect.Slice
+}
+
+// indexHint returns index of first hints.UseIndex or hints.ForceIndex of expr
+func indexHint(expr clause.Expression) string {
+	if !isHint(expr) {
+		return ""
+	}
+	rv := reflect.ValueOf(expr)
+	if rv.Kind() == reflect.Slice {
+		for i := 0; i < rv.Len(); i++ {
+			if e, ok := rv.Index(i).Interface().(clause.Expression); ok {
+				if index := indexHint(e); index != "" {
+					return index
+				}
+			}
+		}
+		return ""
+	}
+	if !isIndexHint(rv) {
+		return ""
+	}
+	hintType := strings.ToUpper(strings.TrimSpace(rv.FieldByName("Type").String()))
+	keys, ok := rv.FieldByName("Keys").Interface().([]string)
+	if !ok || len(keys) == 0 || !strings.HasPrefix(hintType, "USE INDEX") && !strings.HasPrefix(hintType, "FORCE INDEX") {
+		return ""
+	}
+	return keys[0]
+}
@@ -0,0 +1,214 @@
+package ydb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/options"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Versioned is embedded into models keeping history of their rows with History plugin,
+// ValidFrom is time since current version of row is valid
+type Versioned struct {
+	ValidFrom time.Time
+}
+
+func (Versioned) versioned() {}
+
+type versioned interface {
+	versioned()
+}
+
+var versionedType = reflect.TypeOf((*versioned)(nil)).Elem()
+
+// HistoryTable returns name of table keeping previous versions of rows of table
+func HistoryTable(table string) string {
+	return table + "_history"
+}
+
+// History is a gorm plugin keeping previous versions of rows of models embedding Versioned:
+// creates set valid_from, updates and deletes copy matched rows into history table
+// (see HistoryTable) with valid_to of the change before executing it, updates set new valid_from.
+// History tables keyed by primary key and valid_from are created by MigrateHistory,
+// point-in-time reads are made with FindAsOf. Raw statements (Exec) are not versioned
+//
+//	type Price struct {
+//		ydb.Versioned
+//		SKU    string `gorm:"primaryKey"`
+//		Amount uint64
+//	}
+//
+//	db.Use(&ydb.History{})
+//	db.AutoMigrate(&Price{})
+//	ydb.MigrateHistory(ctx, db, &Price{})
+//	ydb.FindAsOf(db.Where("sku = ?", sku), &prices, yesterday)
+type History struct{}
+
+const historyKey = "ydb:history"
+
+func (History) Name() string {
+	return historyKey
+}
+
+func (h History) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+	if err := callback.Create().Before("gorm:create").Register(historyKey, h.created); err != nil {
+		return err
+	}
+	if update := callback.Update().Get("gorm:update"); update != nil {
+		if err := callback.Update().Replace("gorm:update", h.changed(update, true)); err != nil {
+			return err
+		}
+	}
+	if del := callback.Delete().Get("gorm:delete"); del != nil {
+		if err := callback.Delete().Replace("gorm:delete", h.changed(del, false)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isVersioned(s *schema.Schema) bool {
+	return s != nil && reflect.PtrTo(s.ModelType).Implements(versionedType) && s.LookUpField("valid_from") != nil
+}
+
+// created sets valid_from of created rows
+func (History) created(db *gorm.DB) {
+	if db.Error != nil || !isVersioned(db.Statement.Schema) {
+		return
+	}
+	db.Statement.SetColumn("valid_from", time.Now(), true)
+}
+
+// changed copies rows matching conditions of write into history table before executing it
+func (History) changed(write func(*gorm.DB), update bool) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Error != nil || db.DryRun || db.Statement.SQL.Len() > 0 || !isVersioned(db.Statement.Schema) {
+			write(db)
+			return
+		}
+		dry := dryRun(db, write)
+		if dry == nil {
+			write(db)
+			return
+		}
+
+		now := time.Now()
+		columns := make([]clause.Expression, 0, len(db.Statement.Schema.DBNames))
+		for _, name := range db.Statement.Schema.DBNames {
+			columns = append(columns, clause.Expr{SQL: "?", Vars: []interface{}{clause.Column{Name: name}}})
+		}
+		sql := "UPSERT INTO ? SELECT ?, ? AS ? FROM ?"
+		vars := []interface{}{
+			clause.Table{Name: HistoryTable(db.Statement.Table)}, clause.CommaExpression{Exprs: columns},
+			now, clause.Column{Name: "valid_to"}, clause.Table{Name: db.Statement.Table},
+		}
+		if where, ok := dry.Statement.Clauses["WHERE"]; ok {
+			sql += " WHERE ?"
+			vars = append(vars, where.Expression)
+		}
+		if err := db.Session(&gorm.Session{NewDB: true}).Exec(sql, vars...).Error; err != nil {
+			db.AddError(err)
+			return
+		}
+
+		if update {
+			db.Statement.SetColumn("valid_from", now, true)
+		}
+		write(db)
+	}
+}
+
+// MigrateHistory creates history tables of models embedding Versioned, tables of models must exist.
+// History table has columns of model table and valid_to, its primary key is primary key of model
+// table and valid_from
+func MigrateHistory(ctx context.Context, db *gorm.DB, models ...interface{}) error {
+	nativeDriver, err := Unwrap(db)
+	if err != nil {
+		return err
+	}
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return err
+		}
+		if !isVersioned(stmt.Schema) {
+			return fmt.Errorf("ydb: model %s doesn't embed Versioned", stmt.Schema.Name)
+		}
+		err = nativeDriver.Table().Do(ctx, func(ctx context.Context, s table.Session) error {
+			historyPath := tablePath(nativeDriver, HistoryTable(stmt.Table))
+			if _, err := s.DescribeTable(ctx, historyPath); err == nil {
+				return nil
+			}
+			desc, err := s.DescribeTable(ctx, tablePath(nativeDriver, stmt.Table))
+			if err != nil {
+				return err
+			}
+			primaryKey := append([]string{}, desc.PrimaryKey...)
+			for _, column := range desc.PrimaryKey {
+				if column == "valid_from" {
+					return fmt.Errorf("ydb: valid_from is part of primary key of %s", stmt.Table)
+				}
+			}
+			primaryKey = append(primaryKey, "valid_from")
+			opts := make([]options.CreateTableOption, 0, len(desc.Columns)+2)
+			for _, column := range desc.Columns {
+				opts = append(opts, options.WithColumn(column.Name, column.Type))
+			}
+			opts = append(opts,
+				options.WithColumn("valid_to", types.Optional(types.TypeTimestamp)),
+				options.WithPrimaryKeyColumn(primaryKey...),
+			)
+			err = s.CreateTable(ctx, historyPath, opts...)
+			if ydb.IsOperationErrorAlreadyExistsError(err) {
+				return nil
+			}
+			return err
+		}, table.WithIdempotent())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindAsOf finds versions of rows valid at time at into dest, pointer to slice of models
+// embedding Versioned: current rows valid since at or earlier and rows of history table
+// valid at. Conditions of db and conds are applied to both tables
+func FindAsOf(db *gorm.DB, dest interface{}, at time.Time, conds ...interface{}) error {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("ydb: FindAsOf requires pointer to slice, got %T", dest)
+	}
+	tx := db.Session(&gorm.Session{})
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(dest); err != nil {
+		return err
+	}
+	if !isVersioned(stmt.Schema) {
+		return fmt.Errorf("ydb: model %s doesn't embed Versioned", stmt.Schema.Name)
+	}
+	current := stmt.Table
+	if tx.Statement.Table != "" {
+		current = tx.Statement.Table
+	}
+
+	if err := tx.Table(current).Where("valid_from <= ?", at).Find(dest, conds...).Error; err != nil {
+		return err
+	}
+	versions := reflect.New(rv.Elem().Type())
+	if err := tx.Table(HistoryTable(current)).Where("valid_from <= ? AND valid_to > ?", at, at).
+		Find(versions.Interface(), conds...).Error; err != nil {
+		return err
+	}
+	rv.Elem().Set(reflect.AppendSlice(rv.Elem(), versions.Elem()))
+	return nil
+}
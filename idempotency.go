@@ -0,0 +1,159 @@
+package ydb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/options"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrIdempotencyNotRegistered returned by creates with Idempotent key when Idempotency plugin
+// is not used by db
+var ErrIdempotencyNotRegistered = errors.New("ydb: idempotency plugin is not registered")
+
+// IdempotencyRecord is a row of idempotency table, result of create made with key
+type IdempotencyRecord struct {
+	Key       string `gorm:"primaryKey"`
+	TableName string `gorm:"primaryKey"`
+	// Result is JSON of created values
+	Result    []byte
+	CreatedAt time.Time
+	// ExpiresAt time since key may be reused, expired rows are deleted by TTL of table
+	ExpiresAt time.Time
+}
+
+// Idempotency is a gorm plugin deduplicating creates made with Idempotent keys for at-least-once
+// API consumers: key and created values are stored into idempotency table in transaction of
+// create, creates with stored key of the same table insert nothing and decode the stored values
+// into destination instead, see Replayed, after create hooks, associations and outbox events of
+// replayed creates are skipped. Concurrent creates with the same key conflict and one
+// of them fails with retryable error, creates executed with gorm.Config.SkipDefaultTransaction
+// outside of transactions may store values without key
+//
+//	db.Use(&ydb.Idempotency{TTL: 24 * time.Hour})
+//	db.Clauses(ydb.Idempotent(r.Header.Get("Idempotency-Key"))).Create(&payment)
+type Idempotency struct {
+	// Table of keys created on initialization, defaults to _idempotency
+	Table string
+	// TTL of keys, defaults to 24 hours
+	TTL time.Duration
+}
+
+// Idempotent returns statement modifier making create idempotent by key, see Idempotency
+func Idempotent(key string) clause.Expression {
+	return idempotent(key)
+}
+
+type idempotent string
+
+type idempotentKey struct{}
+
+func (key idempotent) ModifyStatement(stmt *gorm.Statement) {
+	if _, ok := stmt.DB.Config.Plugins[idempotencyKey]; !ok {
+		_ = stmt.AddError(ErrIdempotencyNotRegistered)
+		return
+	}
+	if stmt.Context == nil {
+		stmt.Context = context.Background()
+	}
+	stmt.Context = context.WithValue(stmt.Context, idempotentKey{}, string(key))
+}
+
+func (idempotent) Build(clause.Builder) {}
+
+const (
+	idempotencyKey = "ydb:idempotency"
+	replayedKey    = "ydb:idempotency_replayed"
+)
+
+func (i *Idempotency) Name() string {
+	return idempotencyKey
+}
+
+func (i *Idempotency) Initialize(db *gorm.DB) error {
+	if i.Table == "" {
+		i.Table = "_idempotency"
+	}
+	if i.TTL <= 0 {
+		i.TTL = 24 * time.Hour
+	}
+	if !db.DryRun {
+		err := createTable(context.Background(), db, i.Table,
+			options.WithColumn("key", types.Optional(types.TypeUTF8)),
+			options.WithColumn("table_name", types.Optional(types.TypeUTF8)),
+			options.WithColumn("result", types.Optional(types.TypeString)),
+			options.WithColumn("created_at", types.Optional(types.TypeTimestamp)),
+			options.WithColumn("expires_at", types.Optional(types.TypeTimestamp)),
+			options.WithPrimaryKeyColumn("key", "table_name"),
+			options.WithTimeToLiveSettings(options.NewTTLSettings().ColumnDateType("expires_at")),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	callback := db.Callback()
+	if create := callback.Create().Get("gorm:create"); create != nil {
+		return callback.Create().Replace("gorm:create", i.create(create))
+	}
+	return nil
+}
+
+// create skips create with stored key and decodes its stored values into destination,
+// otherwise stores key with created values
+func (i *Idempotency) create(write func(*gorm.DB)) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		key, ok := db.Statement.Context.Value(idempotentKey{}).(string)
+		if !ok || db.Error != nil || db.DryRun || db.Statement.Table == i.Table {
+			write(db)
+			return
+		}
+		tx := db.Session(&gorm.Session{NewDB: true, SkipHooks: true, SkipDefaultTransaction: true}).Table(i.Table)
+
+		var records []IdempotencyRecord
+		if err := tx.Where(&IdempotencyRecord{Key: key, TableName: db.Statement.Table}).Find(&records).Error; err != nil {
+			db.AddError(err)
+			return
+		}
+		if len(records) > 0 && records[0].ExpiresAt.After(time.Now()) {
+			if err := json.Unmarshal(records[0].Result, db.Statement.Dest); err != nil {
+				db.AddError(err)
+				return
+			}
+			db.Statement.RowsAffected = 0
+			db.InstanceSet(replayedKey, true)
+			// nothing was created, so after create hooks and associations must not run
+			db.Statement.SkipHooks = true
+			db.Statement.Omits = append(db.Statement.Omits, clause.Associations)
+			return
+		}
+
+		write(db)
+		if db.Error != nil {
+			return
+		}
+		result, err := json.Marshal(db.Statement.Dest)
+		if err != nil {
+			db.AddError(err)
+			return
+		}
+		now := time.Now()
+		// expired key may be not deleted by TTL yet
+		if err = tx.Exec("UPSERT INTO ? (key, table_name, result, created_at, expires_at) VALUES (?, ?, ?, ?, ?)",
+			clause.Table{Name: i.Table}, key, db.Statement.Table, result, now, now.Add(i.TTL)).Error; err != nil {
+			db.AddError(err)
+		}
+	}
+}
+
+// Replayed reports whether create of db was skipped as duplicate of create with its Idempotent key
+func Replayed(db *gorm.DB) bool {
+	replayed, _ := db.InstanceGet(replayedKey)
+	ok, _ := replayed.(bool)
+	return ok
+}
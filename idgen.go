@@ -0,0 +1,162 @@
+package ydb
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// IDGenerator generates value of field tagged with generator name
+type IDGenerator func(db *gorm.DB) (interface{}, error)
+
+var (
+	idGeneratorsMu sync.RWMutex
+	idGenerators   = map[string]IDGenerator{
+		"ulid": func(*gorm.DB) (interface{}, error) {
+			return NewULID()
+		},
+		"snowflake": func(db *gorm.DB) (interface{}, error) {
+			config := configOf(db)
+			if config == nil || config.snowflake == nil {
+				return nil, errors.New("ydb: snowflake ids are generated by ydb dialector only")
+			}
+			return config.snowflake.Next()
+		},
+	}
+)
+
+// RegisterIDGenerator registers generator of values of fields tagged with name, built-in
+// generators are ulid (string fields) and snowflake (integer fields, see Config.WorkerID)
+//
+//	type Event struct {
+//		ID string `gorm:"primaryKey;generate:ulid"`
+//	}
+func RegisterIDGenerator(name string, generator IDGenerator) {
+	idGeneratorsMu.Lock()
+	defer idGeneratorsMu.Unlock()
+	idGenerators[name] = generator
+}
+
+func lookupIDGenerator(name string) (IDGenerator, bool) {
+	idGeneratorsMu.RLock()
+	defer idGeneratorsMu.RUnlock()
+	generator, ok := idGenerators[name]
+	return generator, ok
+}
+
+// generateIDs sets zero fields of created rows tagged with generate by their generators
+func generateIDs(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+	var (
+		fields     []*schema.Field
+		generators []IDGenerator
+	)
+	for _, field := range db.Statement.Schema.Fields {
+		name, ok := field.TagSettings["GENERATE"]
+		if !ok {
+			continue
+		}
+		generator, ok := lookupIDGenerator(name)
+		if !ok {
+			db.AddError(fmt.Errorf("ydb: unknown id generator %q of %s", name, field.Name))
+			return
+		}
+		fields = append(fields, field)
+		generators = append(generators, generator)
+	}
+	if len(fields) == 0 {
+		return
+	}
+
+	ctx := db.Statement.Context
+	generate := func(rv reflect.Value) {
+		for i, field := range fields {
+			if _, isZero := field.ValueOf(ctx, rv); !isZero {
+				continue
+			}
+			id, err := generators[i](db)
+			if db.AddError(err) != nil {
+				return
+			}
+			db.AddError(field.Set(ctx, rv, id))
+		}
+	}
+	switch rv := reflect.Indirect(db.Statement.ReflectValue); rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len() && db.Error == nil; i++ {
+			if elem := reflect.Indirect(rv.Index(i)); elem.Kind() == reflect.Struct {
+				generate(elem)
+			}
+		}
+	case reflect.Struct:
+		generate(rv)
+	}
+}
+
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewULID returns ULID of current time, 26 characters sortable by time of generation
+func NewULID() (string, error) {
+	var id [16]byte
+	ms := uint64(time.Now().UnixNano() / int64(time.Millisecond))
+	binary.BigEndian.PutUint16(id[:2], uint16(ms>>32))
+	binary.BigEndian.PutUint32(id[2:6], uint32(ms))
+	if _, err := rand.Read(id[6:]); err != nil {
+		return "", err
+	}
+
+	hi, lo := binary.BigEndian.Uint64(id[:8]), binary.BigEndian.Uint64(id[8:])
+	var ulid [26]byte
+	for i := len(ulid) - 1; i >= 0; i-- {
+		ulid[i] = crockfordAlphabet[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(ulid[:]), nil
+}
+
+// snowflakeEpoch is start of time of snowflake ids
+var snowflakeEpoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+const (
+	snowflakeWorkerBits   = 10
+	snowflakeSequenceBits = 12
+	// MaxWorkerID is max worker id of snowflake ids
+	MaxWorkerID = 1<<snowflakeWorkerBits - 1
+)
+
+// Snowflake generates 63 bit ids of milliseconds since 2020, worker id and sequence within
+// millisecond, so ids of workers don't collide and are sortable by time of generation
+type Snowflake struct {
+	WorkerID uint16
+
+	mu       sync.Mutex
+	last     int64
+	sequence uint64
+}
+
+// Next returns next id of the worker, ids are increasing also when clock goes backwards
+func (s *Snowflake) Next() (uint64, error) {
+	if s.WorkerID > MaxWorkerID {
+		return 0, fmt.Errorf("ydb: snowflake worker id %d exceeds %d", s.WorkerID, MaxWorkerID)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := int64(time.Since(snowflakeEpoch) / time.Millisecond)
+	if now > s.last {
+		s.last, s.sequence = now, 0
+	} else if s.sequence++; s.sequence >= 1<<snowflakeSequenceBits {
+		s.last, s.sequence = s.last+1, 0
+	}
+	return uint64(s.last)<<(snowflakeWorkerBits+snowflakeSequenceBits) |
+		uint64(s.WorkerID)<<snowflakeSequenceBits | s.sequence, nil
+}
@@ -0,0 +1,419 @@
+package ydb
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/options"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+	"gorm.io/gorm"
+)
+
+// ImportFormat creates decoder of rows read from r
+type ImportFormat func(r io.Reader) (ImportDecoder, error)
+
+// ImportDecoder decodes imported rows
+type ImportDecoder interface {
+	// Columns returns names of columns of decoded rows
+	Columns() []string
+	// Decode returns values of next row in order of columns or io.EOF, values are types.Value
+	// or strings and other go values coerced to types of table columns
+	Decode() ([]interface{}, error)
+}
+
+// ImportCSV is import format reading RFC 4180 records with header of column names,
+// empty fields of nullable columns are imported as NULLs.
+// Parquet is read by ImportParquet, other formats are plugged in by implementing ImportFormat
+var ImportCSV ImportFormat = func(r io.Reader) (ImportDecoder, error) {
+	decoder := &csvDecoder{r: csv.NewReader(r)}
+	decoder.r.ReuseRecord = true
+	header, err := decoder.r.Read()
+	if err != nil {
+		return nil, err
+	}
+	decoder.columns = append([]string(nil), header...)
+	return decoder, nil
+}
+
+// ImportOptions configures Import
+type ImportOptions struct {
+	// BatchRows count of rows in single BulkUpsert, defaults to 1000
+	BatchRows int
+	// Workers count of concurrent BulkUpsert requests, defaults to 4
+	Workers int
+}
+
+// Import loads rows decoded from r in format into table with BulkUpsert, values are coerced
+// to types of columns from table description. Rows are upserted in parallel batches without
+// transaction, so failed import may be loaded partially and can be repeated
+//
+//	f, _ := os.Open("orders.csv")
+//	defer f.Close()
+//	n, err := ydb.Import(ctx, db, "orders", f, ydb.ImportCSV, ydb.ImportOptions{Workers: 8})
+//
+// ydb.ImportParquet is passed as format to load Apache Parquet files instead
+func Import(ctx context.Context, db *gorm.DB, tableName string, r io.Reader, format ImportFormat, opts ImportOptions) (imported int64, err error) {
+	nativeDriver, err := Unwrap(db)
+	if err != nil {
+		return 0, err
+	}
+	if opts.BatchRows <= 0 {
+		opts.BatchRows = 1000
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = 4
+	}
+	path := tablePath(nativeDriver, tableName)
+
+	var desc options.Description
+	err = nativeDriver.Table().Do(ctx, func(ctx context.Context, s table.Session) (err error) {
+		desc, err = s.DescribeTable(ctx, path)
+		return err
+	}, table.WithIdempotent())
+	if err != nil {
+		return 0, err
+	}
+
+	decoder, err := format(r)
+	if err != nil {
+		return 0, err
+	}
+	columnTypes := make(map[string]types.Type, len(desc.Columns))
+	for _, column := range desc.Columns {
+		columnTypes[column.Name] = column.Type
+	}
+	columns := decoder.Columns()
+	for _, column := range columns {
+		if _, ok := columnTypes[column]; !ok {
+			return 0, fmt.Errorf("ydb: table %s has no column %s", tableName, column)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		batches  = make(chan []types.Value)
+	)
+	fail := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+		mu.Unlock()
+	}
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for rows := range batches {
+				err := nativeDriver.Table().Do(ctx, func(ctx context.Context, s table.Session) error {
+					return s.BulkUpsert(ctx, path, types.ListValue(rows...))
+				}, table.WithIdempotent())
+				if err != nil {
+					fail(err)
+					continue
+				}
+				mu.Lock()
+				imported += int64(len(rows))
+				mu.Unlock()
+			}
+		}()
+	}
+
+	batch := make([]types.Value, 0, opts.BatchRows)
+	for line := 1; ctx.Err() == nil; line++ {
+		values, err := decoder.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err == nil {
+			batch, err = appendRow(batch, columns, columnTypes, values)
+		}
+		if err != nil {
+			fail(fmt.Errorf("ydb: import of row %d: %w", line, err))
+			break
+		}
+		if len(batch) == opts.BatchRows {
+			select {
+			case batches <- batch:
+			case <-ctx.Done():
+			}
+			batch = make([]types.Value, 0, opts.BatchRows)
+		}
+	}
+	if len(batch) > 0 && ctx.Err() == nil {
+		select {
+		case batches <- batch:
+		case <-ctx.Done():
+		}
+	}
+	close(batches)
+	wg.Wait()
+
+	if firstErr == nil {
+		firstErr = ctx.Err()
+	}
+	return imported, firstErr
+}
+
+func appendRow(batch []types.Value, columns []string, columnTypes map[string]types.Type, values []interface{}) ([]types.Value, error) {
+	if len(values) != len(columns) {
+		return batch, fmt.Errorf("%d values for %d columns", len(values), len(columns))
+	}
+	fields := make([]types.StructValueOption, len(columns))
+	for i, column := range columns {
+		v, err := coerceValue(columnTypes[column], values[i])
+		if err != nil {
+			return batch, fmt.Errorf("column %s: %w", column, err)
+		}
+		fields[i] = types.StructFieldValue(column, v)
+	}
+	return append(batch, types.StructValue(fields...)), nil
+}
+
+// coerceValue converts imported value to value of column type t
+func coerceValue(t types.Type, v interface{}) (types.Value, error) {
+	if value, ok := v.(types.Value); ok {
+		return value, nil
+	}
+	yql := t.Yql()
+	optional := strings.HasPrefix(yql, "Optional<")
+	if optional {
+		yql = strings.TrimSuffix(strings.TrimPrefix(yql, "Optional<"), ">")
+	}
+
+	var s string
+	switch v := v.(type) {
+	case nil:
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	case time.Time:
+		s = v.Format(time.RFC3339Nano)
+	default:
+		s = fmt.Sprint(v)
+	}
+	if v == nil || (s == "" && optional && yql != "String" && yql != "Utf8") {
+		if !optional {
+			return nil, fmt.Errorf("NULL for not null %s", yql)
+		}
+		baseType, err := primitiveType(yql)
+		if err != nil {
+			return nil, err
+		}
+		return types.NullValue(baseType), nil
+	}
+
+	value, err := parseValue(yql, s)
+	if err != nil {
+		return nil, err
+	}
+	if optional {
+		return types.OptionalValue(value), nil
+	}
+	return value, nil
+}
+
+func primitiveType(yql string) (types.Type, error) {
+	switch yql {
+	case "Bool":
+		return types.TypeBool, nil
+	case "Int8":
+		return types.TypeInt8, nil
+	case "Uint8":
+		return types.TypeUint8, nil
+	case "Int16":
+		return types.TypeInt16, nil
+	case "Uint16":
+		return types.TypeUint16, nil
+	case "Int32":
+		return types.TypeInt32, nil
+	case "Uint32":
+		return types.TypeUint32, nil
+	case "Int64":
+		return types.TypeInt64, nil
+	case "Uint64":
+		return types.TypeUint64, nil
+	case "Float":
+		return types.TypeFloat, nil
+	case "Double":
+		return types.TypeDouble, nil
+	case "Date":
+		return types.TypeDate, nil
+	case "Datetime":
+		return types.TypeDatetime, nil
+	case "Timestamp":
+		return types.TypeTimestamp, nil
+	case "Interval":
+		return types.TypeInterval, nil
+	case "TzDate":
+		return types.TypeTzDate, nil
+	case "TzDatetime":
+		return types.TypeTzDatetime, nil
+	case "TzTimestamp":
+		return types.TypeTzTimestamp, nil
+	case "String":
+		return types.TypeString, nil
+	case "Utf8":
+		return types.TypeUTF8, nil
+	case "Yson":
+		return types.TypeYSON, nil
+	case "Json":
+		return types.TypeJSON, nil
+	case "JsonDocument":
+		return types.TypeJSONDocument, nil
+	case "DyNumber":
+		return types.TypeDyNumber, nil
+	}
+	if precision, scale, ok := decimalParams(yql); ok {
+		return types.DecimalType(precision, scale), nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", yql)
+}
+
+func parseValue(yql string, s string) (types.Value, error) {
+	switch yql {
+	case "Bool":
+		v, err := strconv.ParseBool(s)
+		return types.BoolValue(v), err
+	case "Int8":
+		v, err := strconv.ParseInt(s, 10, 8)
+		return types.Int8Value(int8(v)), err
+	case "Uint8":
+		v, err := strconv.ParseUint(s, 10, 8)
+		return types.Uint8Value(uint8(v)), err
+	case "Int16":
+		v, err := strconv.ParseInt(s, 10, 16)
+		return types.Int16Value(int16(v)), err
+	case "Uint16":
+		v, err := strconv.ParseUint(s, 10, 16)
+		return types.Uint16Value(uint16(v)), err
+	case "Int32":
+		v, err := strconv.ParseInt(s, 10, 32)
+		return types.Int32Value(int32(v)), err
+	case "Uint32":
+		v, err := strconv.ParseUint(s, 10, 32)
+		return types.Uint32Value(uint32(v)), err
+	case "Int64":
+		v, err := strconv.ParseInt(s, 10, 64)
+		return types.Int64Value(v), err
+	case "Uint64":
+		v, err := strconv.ParseUint(s, 10, 64)
+		return types.Uint64Value(v), err
+	case "Float":
+		v, err := strconv.ParseFloat(s, 32)
+		return types.FloatValue(float32(v)), err
+	case "Double":
+		v, err := strconv.ParseFloat(s, 64)
+		return types.DoubleValue(v), err
+	case "Date":
+		t, err := parseTime(s)
+		return types.DateValueFromTime(t), err
+	case "Datetime":
+		t, err := parseTime(s)
+		return types.DatetimeValueFromTime(t), err
+	case "Timestamp":
+		t, err := parseTime(s)
+		return types.TimestampValueFromTime(t), err
+	case "Interval":
+		d, err := time.ParseDuration(s)
+		return types.IntervalValueFromDuration(d), err
+	case "TzDate":
+		return types.TzDateValue(s), nil
+	case "TzDatetime":
+		return types.TzDatetimeValue(s), nil
+	case "TzTimestamp":
+		return types.TzTimestampValue(s), nil
+	case "String":
+		return types.BytesValueFromString(s), nil
+	case "Utf8":
+		return types.TextValue(s), nil
+	case "Yson":
+		return types.YSONValue(s), nil
+	case "Json":
+		return types.JSONValue(s), nil
+	case "JsonDocument":
+		return types.JSONDocumentValue(s), nil
+	case "DyNumber":
+		return types.DyNumberValue(s), nil
+	}
+	if precision, scale, ok := decimalParams(yql); ok {
+		return parseDecimal(s, precision, scale)
+	}
+	return nil, fmt.Errorf("unsupported type %s", yql)
+}
+
+// parseTime parses times in RFC 3339 format and dates without time
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
+
+// decimalParams parses precision and scale of Decimal(precision,scale) type
+func decimalParams(yql string) (precision, scale uint32, ok bool) {
+	if !strings.HasPrefix(yql, "Decimal(") || !strings.HasSuffix(yql, ")") {
+		return 0, 0, false
+	}
+	params := strings.Split(yql[len("Decimal("):len(yql)-1], ",")
+	if len(params) != 2 {
+		return 0, 0, false
+	}
+	p, err := strconv.ParseUint(strings.TrimSpace(params[0]), 10, 32)
+	if err != nil {
+		return 0, 0, false
+	}
+	s, err := strconv.ParseUint(strings.TrimSpace(params[1]), 10, 32)
+	if err != nil {
+		return 0, 0, false
+	}
+	return uint32(p), uint32(s), true
+}
+
+func parseDecimal(s string, precision, scale uint32) (types.Value, error) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("invalid decimal %q", s)
+	}
+	r.Mul(r, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)))
+	if !r.IsInt() {
+		return nil, fmt.Errorf("decimal %q exceeds scale %d", s, scale)
+	}
+	return types.DecimalValueFromBigInt(r.Num(), precision, scale), nil
+}
+
+type csvDecoder struct {
+	r       *csv.Reader
+	columns []string
+}
+
+func (d *csvDecoder) Columns() []string {
+	return d.columns
+}
+
+func (d *csvDecoder) Decode() ([]interface{}, error) {
+	record, err := d.r.Read()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(record))
+	for i, field := range record {
+		values[i] = field
+	}
+	return values, nil
+}
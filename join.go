@@ -0,0 +1,100 @@
+package ydb
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// buildFrom renders FROM clause in YQL: aliases of joined tables are declared with AS,
+// current table of query with joins is aliased by its name so columns qualified by table name
+// (as gorm renders them for Joins and Preload) resolve, additional tables are CROSS JOINed
+// instead of listed with commas, index hints of gorm.io/hints read first table through secondary
+// index with VIEW
+func buildFrom(c clause.Clause, builder clause.Builder) {
+	from, ok := c.Expression.(clause.From)
+	if !ok {
+		c.Build(builder)
+		return
+	}
+
+	if c.BeforeExpression != nil {
+		c.BeforeExpression.Build(builder)
+		builder.WriteByte(' ')
+	}
+	builder.WriteString("FROM ")
+	if c.AfterNameExpression != nil {
+		c.AfterNameExpression.Build(builder)
+		builder.WriteByte(' ')
+	}
+	if len(from.Tables) == 0 {
+		from.Tables = []clause.Table{{Name: clause.CurrentTable}}
+	}
+	view := indexHint(c.AfterExpression)
+	for idx, table := range from.Tables {
+		if idx > 0 {
+			builder.WriteString(" CROSS JOIN ")
+			view = ""
+		}
+		writeTable(builder, table, view, len(from.Joins) > 0 || len(from.Tables) > 1)
+	}
+
+	for _, join := range from.Joins {
+		builder.WriteByte(' ')
+		buildJoin(join, builder)
+	}
+
+	if after := filterHints(c.AfterExpression, isIndexHint); after != nil {
+		builder.WriteByte(' ')
+		after.Build(builder)
+	}
+}
+
+func buildJoin(join clause.Join, builder clause.Builder) {
+	if join.Expression != nil {
+		join.Expression.Build(builder)
+		return
+	}
+
+	if join.Type != "" {
+		builder.WriteString(string(join.Type))
+		builder.WriteByte(' ')
+	}
+	builder.WriteString("JOIN ")
+	writeTable(builder, join.Table, "", false)
+
+	if len(join.ON.Exprs) > 0 {
+		builder.WriteString(" ON ")
+		join.ON.Build(builder)
+	} else if len(join.Using) > 0 {
+		builder.WriteString(" USING (")
+		for idx, c := range join.Using {
+			if idx > 0 {
+				builder.WriteByte(',')
+			}
+			builder.WriteQuoted(c)
+		}
+		builder.WriteByte(')')
+	}
+}
+
+// writeTable writes table read through index view with alias declared by AS, selfAlias aliases
+// current table by its name
+func writeTable(builder clause.Builder, table clause.Table, view string, selfAlias bool) {
+	alias := table.Alias
+	table.Alias = ""
+	builder.WriteQuoted(table)
+	if view != "" {
+		builder.WriteString(" VIEW ")
+		builder.WriteQuoted(view)
+	}
+
+	if alias == "" && selfAlias && table.Name == clause.CurrentTable {
+		if stmt, ok := builder.(*gorm.Statement); ok && stmt.TableExpr == nil && stmt.Table != "" {
+			alias = stmt.Table
+		}
+	}
+	if alias != "" {
+		builder.WriteString(" AS ")
+		builder.WriteQuoted(alias)
+	}
+}
@@ -0,0 +1,199 @@
+package ydb
+
+import (
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type joinCompany struct {
+	ID   int64 `gorm:"primaryKey;autoIncrement:false"`
+	Name string
+}
+
+type joinProfile struct {
+	ID     int64 `gorm:"primaryKey;autoIncrement:false"`
+	UserID int64
+	Bio    string
+}
+
+type joinUser struct {
+	ID        int64 `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	CompanyID int64
+	Company   joinCompany
+	Profile   joinProfile `gorm:"foreignKey:UserID"`
+}
+
+func TestBuildFromJoins(t *testing.T) {
+	db := openDryRunDB(t, Config{})
+	for _, tt := range []struct {
+		name  string
+		query func(tx *gorm.DB) *gorm.DB
+		want  string
+	}{
+		{"no joins", func(tx *gorm.DB) *gorm.DB {
+			return tx.Find(&[]joinUser{})
+		}, "SELECT * FROM `join_users`"},
+		{"belongs to", func(tx *gorm.DB) *gorm.DB {
+			return tx.Joins("Company").Find(&[]joinUser{})
+		}, "SELECT `join_users`.`id`,`join_users`.`name`,`join_users`.`company_id`,`Company`.`id` AS `Company__id`,`Company`.`name` AS `Company__name` FROM `join_users` AS `join_users` LEFT JOIN `join_companies` AS `Company` ON `join_users`.`company_id` = `Company`.`id`"},
+		{"has one and belongs to", func(tx *gorm.DB) *gorm.DB {
+			return tx.Joins("Company").Joins("Profile").Where("`Company`.`name` = ?", "ydb").Find(&[]joinUser{})
+		}, "SELECT `join_users`.`id`,`join_users`.`name`,`join_users`.`company_id`,`Company`.`id` AS `Company__id`,`Company`.`name` AS `Company__name`,`Profile`.`id` AS `Profile__id`,`Profile`.`user_id` AS `Profile__user_id`,`Profile`.`bio` AS `Profile__bio` FROM `join_users` AS `join_users` LEFT JOIN `join_companies` AS `Company` ON `join_users`.`company_id` = `Company`.`id` LEFT JOIN `join_profiles` AS `Profile` ON `join_users`.`id` = `Profile`.`user_id` WHERE `Company`.`name` = ?"},
+		{"join with conditions", func(tx *gorm.DB) *gorm.DB {
+			return tx.Joins("Company", tx.Where(&joinCompany{Name: "ydb"})).Find(&[]joinUser{})
+		}, "SELECT `join_users`.`id`,`join_users`.`name`,`join_users`.`company_id`,`Company`.`id` AS `Company__id`,`Company`.`name` AS `Company__name` FROM `join_users` AS `join_users` LEFT JOIN `join_companies` AS `Company` ON `join_users`.`company_id` = `Company`.`id` AND `Company`.`name` = ?"},
+		{"raw join", func(tx *gorm.DB) *gorm.DB {
+			return tx.Joins("JOIN `join_companies` AS c ON c.`id` = `join_users`.`company_id`").Find(&[]joinUser{})
+		}, "SELECT `join_users`.`id`,`join_users`.`name`,`join_users`.`company_id` FROM `join_users` AS `join_users` JOIN `join_companies` AS c ON c.`id` = `join_users`.`company_id`"},
+		{"tables", func(tx *gorm.DB) *gorm.DB {
+			return tx.Clauses(clause.From{Tables: []clause.Table{{Name: "join_users", Alias: "u"}, {Name: "join_companies", Alias: "c"}}}).
+				Where("u.company_id = c.id").Find(&[]joinUser{})
+		}, "SELECT * FROM `join_users` AS `u` CROSS JOIN `join_companies` AS `c` WHERE u.company_id = c.id"},
+	} {
+		if got := tt.query(db.Session(&gorm.Session{})).Statement.SQL.String(); got != tt.want {
+			t.Errorf("%s:\n got %s\nwant %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+type assocCompany struct {
+	ID   int64 `gorm:"primaryKey;autoIncrement:false"`
+	Name string
+}
+
+type assocProfile struct {
+	ID     int64 `gorm:"primaryKey;autoIncrement:false"`
+	UserID int64
+	Bio    string
+}
+
+type assocOrder struct {
+	ID     int64 `gorm:"primaryKey;autoIncrement:false"`
+	UserID int64
+	Amount int64
+}
+
+type assocLanguage struct {
+	ID   int64 `gorm:"primaryKey;autoIncrement:false"`
+	Code string
+}
+
+type assocTag struct {
+	ID        int64 `gorm:"primaryKey;autoIncrement:false"`
+	OwnerID   int64
+	OwnerType string
+	Name      string
+}
+
+type assocUser struct {
+	ID        int64 `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	CompanyID int64
+	Company   assocCompany
+	Profile   assocProfile    `gorm:"foreignKey:UserID"`
+	Orders    []assocOrder    `gorm:"foreignKey:UserID"`
+	Languages []assocLanguage `gorm:"many2many:assoc_user_languages"`
+	Tags      []assocTag      `gorm:"polymorphic:Owner"`
+	ManagerID *int64
+	Manager   *assocUser
+	Reports   []assocUser `gorm:"foreignKey:ManagerID"`
+}
+
+func TestAssociations(t *testing.T) {
+	db := openTestDB(t, nil)
+	migrateTestTables(t, db, &assocCompany{}, &assocProfile{}, &assocOrder{}, &assocLanguage{}, &assocTag{}, &assocUser{})
+
+	managerID := int64(1)
+	for _, value := range []interface{}{
+		&[]assocCompany{{ID: 1, Name: "ydb"}},
+		&[]assocProfile{{ID: 1, UserID: 1, Bio: "manager"}, {ID: 2, UserID: 2, Bio: "engineer"}},
+		&[]assocOrder{{ID: 1, UserID: 2, Amount: 10}, {ID: 2, UserID: 2, Amount: 20}},
+		&[]assocLanguage{{ID: 1, Code: "go"}, {ID: 2, Code: "yql"}},
+		&[]assocTag{{ID: 1, OwnerID: 2, OwnerType: "assoc_users", Name: "oncall"}},
+		&[]assocUser{{ID: 1, Name: "alice", CompanyID: 1}, {ID: 2, Name: "bob", CompanyID: 1, ManagerID: &managerID}},
+	} {
+		if err := db.Omit(clause.Associations).Create(value).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Table("assoc_user_languages").Create([]map[string]interface{}{
+		{"assoc_user_id": int64(2), "assoc_language_id": int64(1)},
+		{"assoc_user_id": int64(2), "assoc_language_id": int64(2)},
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Preload", func(t *testing.T) {
+		var user assocUser
+		err := db.Preload("Company").Preload("Profile").Preload("Orders", func(tx *gorm.DB) *gorm.DB {
+			return tx.Order("id")
+		}).Preload("Languages").Preload("Tags").Preload("Manager.Company").Take(&user, 2).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user.Company.Name != "ydb" {
+			t.Errorf("belongs to: company %+v", user.Company)
+		}
+		if user.Profile.Bio != "engineer" {
+			t.Errorf("has one: profile %+v", user.Profile)
+		}
+		if len(user.Orders) != 2 || user.Orders[0].Amount != 10 || user.Orders[1].Amount != 20 {
+			t.Errorf("has many: orders %+v", user.Orders)
+		}
+		if len(user.Languages) != 2 {
+			t.Errorf("many to many: languages %+v", user.Languages)
+		}
+		if len(user.Tags) != 1 || user.Tags[0].Name != "oncall" {
+			t.Errorf("polymorphic: tags %+v", user.Tags)
+		}
+		if user.Manager == nil || user.Manager.Name != "alice" || user.Manager.Company.Name != "ydb" {
+			t.Errorf("nested self-referential: manager %+v", user.Manager)
+		}
+
+		var manager assocUser
+		if err := db.Preload("Reports.Profile").Take(&manager, 1).Error; err != nil {
+			t.Fatal(err)
+		}
+		if len(manager.Reports) != 1 || manager.Reports[0].Profile.Bio != "engineer" {
+			t.Errorf("self-referential has many: reports %+v", manager.Reports)
+		}
+	})
+
+	t.Run("Joins", func(t *testing.T) {
+		var users []assocUser
+		err := db.Joins("Company").Joins("Profile").Where("`Company`.`name` = ?", "ydb").
+			Order("`assoc_users`.`id`").Find(&users).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(users) != 2 {
+			t.Fatalf("read %d users, want 2", len(users))
+		}
+		for _, user := range users {
+			if user.Company.Name != "ydb" || user.Profile.UserID != user.ID {
+				t.Errorf("user %d: company %+v, profile %+v", user.ID, user.Company, user.Profile)
+			}
+		}
+
+		var user assocUser
+		if err := db.Joins("Manager").Take(&user, "`assoc_users`.`id` = ?", 2).Error; err != nil {
+			t.Fatal(err)
+		}
+		if user.Manager == nil || user.Manager.Name != "alice" {
+			t.Errorf("self-referential: manager %+v", user.Manager)
+		}
+
+		var count int64
+		err = db.Model(&assocUser{}).Joins("JOIN `assoc_user_languages` AS ul ON ul.`assoc_user_id` = `assoc_users`.`id`").
+			Where("ul.`assoc_language_id` = ?", 2).Count(&count).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 1 {
+			t.Errorf("raw join: count %d, want 1", count)
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package ydb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/options"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// KV is key-value store of JSON values kept in table with TTL, for tables used as cache next to
+// OLTP tables. Expired entries are deleted by TTL of table in background and are not returned
+// before their deletion. KV has no type parameter of values as go.mod targets Go 1.14, Get
+// decodes value into dest like json.Unmarshal instead. Entries are read by key with ReadRow,
+// which stands in for ReadRows call missing in the driver
+//
+//	sessions, err := ydb.NewKV(ctx, db, "sessions")
+//	err = sessions.Set(ctx, token, &session, time.Hour)
+//	found, err := sessions.Get(ctx, token, &session)
+type KV struct {
+	db    *gorm.DB
+	table string
+}
+
+type kvEntry struct {
+	Key       string `gorm:"primaryKey"`
+	Value     []byte
+	ExpiresAt *time.Time
+}
+
+// NewKV returns store kept in table, table is created if it doesn't exist
+func NewKV(ctx context.Context, db *gorm.DB, table string) (*KV, error) {
+	err := createTable(ctx, db, table,
+		options.WithColumn("key", types.Optional(types.TypeUTF8)),
+		options.WithColumn("value", types.Optional(types.TypeString)),
+		options.WithColumn("expires_at", types.Optional(types.TypeTimestamp)),
+		options.WithPrimaryKeyColumn("key"),
+		options.WithTimeToLiveSettings(options.NewTTLSettings().ColumnDateType("expires_at")),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &KV{db: db.Session(&gorm.Session{NewDB: true, SkipHooks: true}), table: table}, nil
+}
+
+// Get decodes value of key into dest, reports whether key was found
+func (kv *KV) Get(ctx context.Context, key string, dest interface{}) (bool, error) {
+	var entry kvEntry
+	err := ReadRow(ctx, kv.db.Table(kv.table), &entry, key)
+	if errors.Is(err, gorm.ErrRecordNotFound) || err == nil && entry.ExpiresAt != nil && !entry.ExpiresAt.After(time.Now()) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, json.Unmarshal(entry.Value, dest)
+}
+
+// Set writes value of key encoded as JSON, entry expires in ttl or never if ttl isn't positive
+func (kv *KV) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	var expiresAt *time.Time
+	if ttl > 0 {
+		t := time.Now().Add(ttl)
+		expiresAt = &t
+	}
+	return kv.db.WithContext(ctx).Exec("UPSERT INTO ? (key, value, expires_at) VALUES (?, ?, ?)",
+		clause.Table{Name: kv.table}, key, data, expiresAt).Error
+}
+
+// Delete deletes key
+func (kv *KV) Delete(ctx context.Context, key string) error {
+	return kv.db.WithContext(ctx).Exec("DELETE FROM ? WHERE key = ?", clause.Table{Name: kv.table}, key).Error
+}
@@ -0,0 +1,93 @@
+package ydb
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/meta"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WithLabel returns statement modifier attaching label to requests of statement, labels are sent
+// in request type header of YDB requests ("service=checkout,handler=pay"), so YDB-side monitoring
+// breaks down load by them, labels override Config.Labels with the same key
+//
+//	db.Clauses(ydb.WithLabel("service", "checkout")).Create(&order)
+func WithLabel(key, value string) clause.Expression {
+	return label{key: key, value: value}
+}
+
+// WithTraceID returns statement modifier attaching trace id header to requests of statement
+func WithTraceID(traceID string) clause.Expression {
+	return traceIDModifier(traceID)
+}
+
+type label struct {
+	key, value string
+}
+
+func (l label) ModifyStatement(stmt *gorm.Statement) {
+	stmt.Context = withLabels(stmt.Context, map[string]string{l.key: l.value})
+}
+
+func (label) Build(clause.Builder) {}
+
+type traceIDModifier string
+
+func (id traceIDModifier) ModifyStatement(stmt *gorm.Statement) {
+	if stmt.Context == nil {
+		stmt.Context = context.Background()
+	}
+	stmt.Context = context.WithValue(meta.WithTraceID(stmt.Context, string(id)), traceIDKey{}, string(id))
+}
+
+func (traceIDModifier) Build(clause.Builder) {}
+
+type labelsKey struct{}
+
+func withLabels(ctx context.Context, labels map[string]string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	merged := make(map[string]string, len(labels))
+	if parent, ok := ctx.Value(labelsKey{}).(map[string]string); ok {
+		for k, v := range parent {
+			merged[k] = v
+		}
+	}
+	for k, v := range labels {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, labelsKey{}, merged)
+}
+
+// labelsContext sets request type header of ctx from labels of statement and config
+func (config *Config) labelsContext(ctx context.Context) context.Context {
+	labels, _ := ctx.Value(labelsKey{}).(map[string]string)
+	if config != nil && len(config.Labels) > 0 {
+		merged := make(map[string]string, len(config.Labels)+len(labels))
+		for k, v := range config.Labels {
+			merged[k] = v
+		}
+		for k, v := range labels {
+			merged[k] = v
+		}
+		labels = merged
+	}
+	if len(labels) == 0 {
+		return ctx
+	}
+
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+labels[k])
+	}
+	return meta.WithRequestType(ctx, strings.Join(pairs, ","))
+}
@@ -0,0 +1,141 @@
+package ydb
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"gorm.io/gorm"
+)
+
+// ErrNotReady returned by statements and Unwrap while native driver of LazyConnect is not connected
+var ErrNotReady = errors.New("ydb: database is not connected yet")
+
+// LazyConnect opens native driver in background retrying with exponential backoff, so Initialize
+// doesn't fail when cluster is unavailable at start. Until connected statements fail with
+// ErrNotReady, see Ready. Once connected, native driver rediscovers endpoints and reconnects
+// to nodes itself
+//
+//	db, err := gorm.Open(ydb.New(ydb.Config{
+//		DSN:         dsn,
+//		LazyConnect: &ydb.LazyConnect{MaxBackoff: time.Minute},
+//	}))
+//	go func() {
+//		<-ydb.Ready(db)
+//		markReady()
+//	}()
+type LazyConnect struct {
+	// InitialBackoff delay after first failed attempt doubled after each next one up to
+	// MaxBackoff, defaults to 100ms and 30 seconds
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// AttemptTimeout limits duration of single attempt, defaults to 10 seconds
+	AttemptTimeout time.Duration
+	// OnError called with error of failed attempt
+	OnError func(attempt int, err error)
+}
+
+func (l *LazyConnect) backoff(attempt int) time.Duration {
+	delay, maxDelay := l.InitialBackoff, l.MaxBackoff
+	if delay <= 0 {
+		delay = 100 * time.Millisecond
+	}
+	if maxDelay <= 0 {
+		maxDelay = 30 * time.Second
+	}
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+// lazyConnector is connector of the connection pool until native driver is connected
+type lazyConnector struct {
+	ready  chan struct{}
+	cancel context.CancelFunc
+	// nativeDriver and connector are set before ready is closed
+	nativeDriver ydb.Connection
+	connector    driver.Connector
+}
+
+func (c *lazyConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	select {
+	case <-c.ready:
+		return c.connector.Connect(ctx)
+	default:
+		return nil, ErrNotReady
+	}
+}
+
+func (c *lazyConnector) Driver() driver.Driver {
+	return lazyDriver{}
+}
+
+type lazyDriver struct{}
+
+func (lazyDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("ydb: connections are opened by connector")
+}
+
+// connected returns native driver when it is connected
+func (c *lazyConnector) connected() (ydb.Connection, error) {
+	select {
+	case <-c.ready:
+		return c.nativeDriver, nil
+	default:
+		return nil, ErrNotReady
+	}
+}
+
+// run opens native driver by open until success or cancel by Shutdown
+func (c *lazyConnector) run(ctx context.Context, l *LazyConnect, open func(context.Context) (ydb.Connection, driver.Connector, error)) {
+	timeout := l.AttemptTimeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	for attempt := 1; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+		nativeDriver, connector, err := open(attemptCtx)
+		cancel()
+		if err == nil && ctx.Err() != nil {
+			_ = nativeDriver.Close(context.Background())
+			return
+		}
+		if err == nil {
+			c.nativeDriver, c.connector = nativeDriver, connector
+			close(c.ready)
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if l.OnError != nil {
+			l.OnError(attempt, err)
+		}
+		select {
+		case <-time.After(l.backoff(attempt)):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+var closedReady = func() chan struct{} {
+	ready := make(chan struct{})
+	close(ready)
+	return ready
+}()
+
+// Ready returns channel closed when native driver of LazyConnect is connected, channel of db
+// opened without LazyConnect is closed
+func Ready(db *gorm.DB) <-chan struct{} {
+	if config := configOf(db); config != nil && config.lazy != nil {
+		return config.lazy.ready
+	}
+	return closedReady
+}
@@ -0,0 +1,97 @@
+package ydb
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+	"gorm.io/gorm"
+)
+
+// Limits of single YDB request validated before execution, zero value disables particular check
+type Limits struct {
+	// MaxQuerySize max length of query text in bytes
+	MaxQuerySize int
+	// MaxParamsSize max approximate size of all query parameters in bytes
+	MaxParamsSize int
+	// MaxRows max rows written by single statement
+	MaxRows int
+}
+
+// DefaultLimits conservative limits fitting default YDB server and gRPC settings
+var DefaultLimits = Limits{
+	MaxQuerySize:  10 << 20,
+	MaxParamsSize: 50 << 20,
+	MaxRows:       100000,
+}
+
+// LimitError returned when request would exceed one of Limits
+type LimitError struct {
+	Limit string
+	Value int
+	Max   int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("ydb: request exceeds %s limit (%d > %d)", e.Limit, e.Value, e.Max)
+}
+
+func (limits *Limits) checkQuery(query string, args []driver.NamedValue) error {
+	if limits.MaxQuerySize > 0 && len(query) > limits.MaxQuerySize {
+		return &LimitError{Limit: "MaxQuerySize", Value: len(query), Max: limits.MaxQuerySize}
+	}
+	if limits.MaxParamsSize > 0 {
+		var size int
+		for _, arg := range args {
+			size += len(arg.Name) + approximateSize(arg.Value)
+		}
+		if size > limits.MaxParamsSize {
+			return &LimitError{Limit: "MaxParamsSize", Value: size, Max: limits.MaxParamsSize}
+		}
+	}
+	return nil
+}
+
+func (limits *Limits) checkRows(db *gorm.DB) {
+	if db.Error != nil || limits.MaxRows <= 0 {
+		return
+	}
+	if rv := db.Statement.ReflectValue; rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		if rows := rv.Len(); rows > limits.MaxRows {
+			db.AddError(&LimitError{Limit: "MaxRows", Value: rows, Max: limits.MaxRows})
+		}
+	}
+}
+
+func approximateSize(v interface{}) int {
+	switch x := v.(type) {
+	case nil:
+		return 0
+	case types.Value:
+		return len(x.Yql())
+	case table.ParameterOption:
+		return len(x.Value().Yql())
+	case string:
+		return len(x)
+	case []byte:
+		return len(x)
+	case sensitive:
+		return approximateSize(x.value)
+	}
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.Len()
+	case reflect.Slice, reflect.Array:
+		var size int
+		for i := 0; i < rv.Len(); i++ {
+			size += approximateSize(rv.Index(i).Interface())
+		}
+		return size
+	case reflect.Invalid:
+		return 0
+	}
+	return int(rv.Type().Size())
+}
@@ -0,0 +1,108 @@
+package ydb
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+	"gorm.io/gorm/schema"
+)
+
+// isJSONField reports whether field of slice type or struct field tagged `type:Struct` is stored
+// as Json document, row tables of YDB don't support List<T> and Struct<> columns, so such values
+// fall back to Json, fields of associations are never stored
+func isJSONField(field *schema.Field) bool {
+	if field.DBName == "" || field.Serializer != nil || field.IndirectFieldType == nil {
+		return false
+	}
+	if field.Schema != nil {
+		if _, ok := field.Schema.Relationships.Relations[field.Name]; ok {
+			return false
+		}
+	}
+	switch field.IndirectFieldType.Kind() {
+	case reflect.Slice, reflect.Array:
+		if field.IndirectFieldType.Elem().Kind() == reflect.Uint8 {
+			return false
+		}
+	case reflect.Struct:
+		if !isStructField(field) {
+			return false
+		}
+	default:
+		return false
+	}
+	if _, ok := lookupTypeMapping(field.IndirectFieldType); ok {
+		return false
+	}
+	if _, ok := reflect.New(field.IndirectFieldType).Interface().(driver.Valuer); ok {
+		return false
+	}
+	switch strings.ToLower(string(field.DataType)) {
+	case "", "json", "jsondocument", "struct":
+		return true
+	}
+	return false
+}
+
+func isStructField(field *schema.Field) bool {
+	return strings.EqualFold(string(field.DataType), "Struct")
+}
+
+// jsonDataType returns column type of Json field, struct fields are stored as JsonDocument
+// with members named by their json tags
+func jsonDataType(field *schema.Field) string {
+	if isStructField(field) || strings.EqualFold(string(field.DataType), "JsonDocument") {
+		return "JsonDocument"
+	}
+	return "Json"
+}
+
+// patchJSONField encodes field value as Json parameter and decodes Json column into field
+func patchJSONField(field *schema.Field) {
+	document := jsonDataType(field) == "JsonDocument"
+	valueOf := field.ValueOf
+	field.ValueOf = func(ctx context.Context, rv reflect.Value) (interface{}, bool) {
+		v, zero := valueOf(ctx, rv)
+		return jsonValue{value: v, document: document}, zero
+	}
+	patchField(field, decodeJSON)
+}
+
+func decodeJSON(src interface{}, dst interface{}) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, dst)
+	case string:
+		return json.Unmarshal([]byte(data), dst)
+	}
+	return fmt.Errorf("ydb: failed to decode json from %T", src)
+}
+
+// jsonValue binds value as Json or JsonDocument parameter
+type jsonValue struct {
+	value    interface{}
+	document bool
+}
+
+func (v jsonValue) Value() (driver.Value, error) {
+	t := types.TypeJSON
+	if v.document {
+		t = types.TypeJSONDocument
+	}
+	if rv := reflect.ValueOf(v.value); !rv.IsValid() || (rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Slice) && rv.IsNil() {
+		return types.NullValue(t), nil
+	}
+	data, err := json.Marshal(v.value)
+	if err != nil {
+		return nil, err
+	}
+	if v.document {
+		return types.OptionalValue(types.JSONDocumentValueFromBytes(data)), nil
+	}
+	return types.OptionalValue(types.JSONValueFromBytes(data)), nil
+}
@@ -0,0 +1,256 @@
+package ydb
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb"
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/options"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// LockTable name of table keeping leases of distributed locks, created on first use
+	LockTable = "ydb_locks"
+	// LockTTL lease duration of distributed lock, lease is extended while lock is held
+	LockTTL = 30 * time.Second
+	// LockRetryInterval interval between attempts to acquire busy lock
+	LockRetryInterval = time.Second
+)
+
+// ErrLockLost returned by WithAppLock when lease of lock was lost while fn was running
+var ErrLockLost = errors.New("ydb: lock lost")
+
+// Lock is distributed lock held by the process
+type Lock struct {
+	db    *gorm.DB
+	name  string
+	owner string
+
+	stop chan struct{}
+	lost chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+type lockLease struct {
+	Name      string `gorm:"primaryKey"`
+	Owner     string
+	ExpiresAt time.Time
+}
+
+// DistributedLock acquires lock name waiting until it is released by other holder or ctx is done.
+// Coordination service semaphores are not exposed by the driver version used, so lock is a lease
+// row of LockTable written in serializable transaction and extended in background while held,
+// lease expires in LockTTL if holder dies. Clocks of processes are assumed to be synchronized
+//
+//	lock, err := ydb.DistributedLock(ctx, db, "migrations")
+//	if err != nil {
+//		return err
+//	}
+//	defer lock.Release(ctx)
+func DistributedLock(ctx context.Context, db *gorm.DB, name string) (*Lock, error) {
+	if err := createLockTable(ctx, db); err != nil {
+		return nil, err
+	}
+
+	owner := make([]byte, 16)
+	if _, err := rand.Read(owner); err != nil {
+		return nil, err
+	}
+	lock := &Lock{
+		db:    db.Session(&gorm.Session{NewDB: true}),
+		name:  name,
+		owner: hex.EncodeToString(owner),
+		stop:  make(chan struct{}),
+		lost:  make(chan struct{}),
+	}
+
+	var leased time.Time
+	for {
+		leased = time.Now()
+		acquired, err := lock.lease(ctx, false)
+		// transaction of contender acquiring the lock concurrently is aborted, the lock is busy then
+		if err != nil && !ydb.IsOperationError(err, Ydb.StatusIds_ABORTED) {
+			return nil, err
+		}
+		if acquired {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(LockRetryInterval):
+		}
+	}
+
+	lock.wg.Add(1)
+	go lock.keepAlive(leased.Add(LockTTL))
+	return lock, nil
+}
+
+// Lost is closed when lease of lock was taken by other holder or could not be extended for LockTTL
+// since last extension, so lock may be acquired by other holder
+func (lock *Lock) Lost() <-chan struct{} {
+	return lock.lost
+}
+
+// Release stops lease extension and releases lock
+func (lock *Lock) Release(ctx context.Context) error {
+	lock.once.Do(func() { close(lock.stop) })
+	lock.wg.Wait()
+	return lock.db.WithContext(ctx).Where("name = ? AND owner = ?", lock.name, lock.owner).Delete(&lockLease{}).Error
+}
+
+// WithAppLock runs fn holding lock key, for long business operations which can't be serialized
+// by transaction locks (transactions of YDB are optimistic and limited in duration). Context of
+// fn is canceled when lease of lock is lost and ErrLockLost is returned then unless fn failed.
+// Lock is released when fn returns, also on panic
+//
+//	err := ydb.WithAppLock(ctx, db, "invoice:"+id, func(ctx context.Context) error {
+//		return issueInvoice(ctx, db.WithContext(ctx), id)
+//	})
+func WithAppLock(ctx context.Context, db *gorm.DB, key string, fn func(ctx context.Context) error) (err error) {
+	lock, err := DistributedLock(ctx, db, key)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// lock is released with own context, ctx may be already canceled
+		releaseCtx, cancel := context.WithTimeout(context.Background(), LockTTL/3)
+		defer cancel()
+		if releaseErr := lock.Release(releaseCtx); err == nil {
+			err = releaseErr
+		}
+	}()
+
+	fnCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-lock.Lost():
+			cancel()
+		case <-done:
+		}
+	}()
+
+	err = fn(fnCtx)
+	select {
+	case <-lock.Lost():
+		if err == nil || errors.Is(err, context.Canceled) {
+			err = ErrLockLost
+		}
+	default:
+	}
+	return err
+}
+
+func (lockLease) TableName() string {
+	return LockTable
+}
+
+// lease acquires free or expired lock or extends lease of held one
+func (lock *Lock) lease(ctx context.Context, held bool) (acquired bool, err error) {
+	err = lock.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current lockLease
+		err := tx.Where("name = ?", lock.name).Take(&current).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if held {
+				return nil
+			}
+		case err != nil:
+			return err
+		case current.Owner != lock.owner && current.ExpiresAt.After(time.Now()):
+			return nil
+		case current.Owner != lock.owner && held:
+			return nil
+		}
+		acquired = true
+		return tx.Exec("UPSERT INTO ? (name, owner, expires_at) VALUES (?, ?, ?)",
+			clause.Table{Name: LockTable}, lock.name, lock.owner, time.Now().Add(LockTTL)).Error
+	})
+	if err != nil {
+		acquired = false
+	}
+	return acquired, err
+}
+
+// keepAlive extends lease expiring at expires until lock is released, lease is lost when it was
+// taken by other holder or expired before it was extended
+func (lock *Lock) keepAlive(expires time.Time) {
+	defer lock.wg.Done()
+	ticker := time.NewTicker(LockTTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-lock.stop:
+			return
+		case <-ticker.C:
+			leased := time.Now()
+			deadline := leased.Add(LockTTL / 3)
+			if expires.Before(deadline) {
+				deadline = expires
+			}
+			ctx, cancel := context.WithDeadline(context.Background(), deadline)
+			acquired, err := lock.lease(ctx, true)
+			cancel()
+			switch {
+			case err == nil && acquired:
+				expires = leased.Add(LockTTL)
+			case err == nil || !time.Now().Before(expires):
+				close(lock.lost)
+				return
+			}
+		}
+	}
+}
+
+// createLockTable creates LockTable if it doesn't exist
+func createLockTable(ctx context.Context, db *gorm.DB) error {
+	return createTable(ctx, db, LockTable,
+		options.WithColumn("name", types.Optional(types.TypeUTF8)),
+		options.WithColumn("owner", types.Optional(types.TypeUTF8)),
+		options.WithColumn("expires_at", types.Optional(types.TypeTimestamp)),
+		options.WithPrimaryKeyColumn("name"),
+	)
+}
+
+var createdTables sync.Map // table path -> struct{}
+
+// createTable creates service table of the dialect if it doesn't exist
+func createTable(ctx context.Context, db *gorm.DB, name string, opts ...options.CreateTableOption) error {
+	nativeDriver, err := Unwrap(db)
+	if err != nil {
+		return err
+	}
+	path := tablePath(nativeDriver, name)
+	if _, ok := createdTables.Load(path); ok {
+		return nil
+	}
+	err = nativeDriver.Table().Do(ctx, func(ctx context.Context, s table.Session) error {
+		if _, err := s.DescribeTable(ctx, path); err == nil {
+			return nil
+		}
+		err := s.CreateTable(ctx, path, opts...)
+		if ydb.IsOperationErrorAlreadyExistsError(err) {
+			return nil
+		}
+		return err
+	}, table.WithIdempotent())
+	if err != nil {
+		return err
+	}
+	createdTables.Store(path, struct{}{})
+	return nil
+}
@@ -0,0 +1,193 @@
+package ydb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/options"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+	"gorm.io/gorm"
+)
+
+// primaryKeyTypes table path -> map[string]types.Type, primary keys of tables can't be altered,
+// so entries are dropped only by Migrator on drop and creation of tables
+var primaryKeyTypes sync.Map
+
+// GetByKeys finds rows of model of dest (pointer to slice of models) by primary keys in one data
+// query: keys are passed as List<Struct> parameter joined to the table, so lookups by composite
+// keys don't expand into OR of conditions and queries of any count of keys share compiled plan.
+// keys is a slice of primary key values for models with single primary key column or of
+// slices of values in order of primary key columns, rows of missing keys are skipped. Table of
+// db overrides table of model
+//
+//	var users []User
+//	err := ydb.GetByKeys(ctx, db, &users, []uint64{1, 2, 3})
+//
+//	var items []OrderItem
+//	err := ydb.GetByKeys(ctx, db, &items, [][]interface{}{{orderID, 1}, {orderID, 2}})
+func GetByKeys(ctx context.Context, db *gorm.DB, dest interface{}, keys interface{}) error {
+	stmt, err := parseStatement(db, dest)
+	if err != nil {
+		return err
+	}
+	if db.Statement.Table != "" {
+		stmt.Table = db.Statement.Table
+	}
+	rv := reflect.ValueOf(keys)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return fmt.Errorf("ydb: keys must be a slice, got %T", keys)
+	}
+	if rv.Len() == 0 {
+		return nil
+	}
+	columns := stmt.Schema.PrimaryFieldDBNames
+	columnTypes, err := primaryKeyTypesOf(ctx, db, stmt.Table)
+	if err != nil {
+		return err
+	}
+	for _, column := range columns {
+		if _, ok := columnTypes[column]; !ok {
+			return fmt.Errorf("ydb: column %s is not primary key of table %s", column, stmt.Table)
+		}
+	}
+
+	rows := make([]types.Value, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		key := reflect.Indirect(rv.Index(i))
+		values := []interface{}{key.Interface()}
+		if len(columns) > 1 {
+			if key.Kind() != reflect.Slice && key.Kind() != reflect.Array || key.Len() != len(columns) {
+				return fmt.Errorf("ydb: key %d of %s must have %d values", i, stmt.Table, len(columns))
+			}
+			values = make([]interface{}, key.Len())
+			for j := range values {
+				values[j] = key.Index(j).Interface()
+			}
+		}
+		fields := make([]types.StructValueOption, len(columns))
+		for j, column := range columns {
+			v, err := coerceValue(columnTypes[column], values[j])
+			if err != nil {
+				return fmt.Errorf("ydb: key %d of %s, column %s: %w", i, stmt.Table, column, err)
+			}
+			fields[j] = types.StructFieldValue(column, v)
+		}
+		rows = append(rows, types.StructValue(fields...))
+	}
+
+	var sql strings.Builder
+	sql.WriteString("SELECT t.* FROM AS_TABLE($keys) AS k JOIN ")
+	stmt.QuoteTo(&sql, stmt.Table)
+	sql.WriteString(" AS t ON ")
+	for i, column := range columns {
+		if i > 0 {
+			sql.WriteString(" AND ")
+		}
+		sql.WriteString("t.")
+		stmt.QuoteTo(&sql, column)
+		sql.WriteString(" = k.")
+		stmt.QuoteTo(&sql, column)
+	}
+	return Raw(ctx, db, sql.String(), Param("$keys", types.ListValue(rows...))).Find(dest).Error
+}
+
+// primaryKeyTypesOf returns types of primary key columns of table by column names
+func primaryKeyTypesOf(ctx context.Context, db *gorm.DB, tableName string) (map[string]types.Type, error) {
+	nativeDriver, err := Unwrap(db)
+	if err != nil {
+		return nil, err
+	}
+	path := tablePath(nativeDriver, tableName)
+	if v, ok := primaryKeyTypes.Load(path); ok {
+		return v.(map[string]types.Type), nil
+	}
+
+	var desc options.Description
+	err = nativeDriver.Table().Do(ctx, func(ctx context.Context, s table.Session) (err error) {
+		desc, err = s.DescribeTable(ctx, path)
+		return err
+	}, table.WithIdempotent())
+	if err != nil {
+		return nil, err
+	}
+	columnTypes := make(map[string]types.Type, len(desc.Columns))
+	for _, column := range desc.Columns {
+		columnTypes[column.Name] = column.Type
+	}
+	keyTypes := make(map[string]types.Type, len(desc.PrimaryKey))
+	for _, key := range desc.PrimaryKey {
+		keyTypes[key] = columnTypes[key]
+	}
+	primaryKeyTypes.Store(path, keyTypes)
+	return keyTypes, nil
+}
+
+// forgetPrimaryKeyTypes drops cached types of primary key columns of dropped or created table
+func forgetPrimaryKeyTypes(db *gorm.DB, tableName string) {
+	if nativeDriver, err := Unwrap(db); err == nil {
+		primaryKeyTypes.Delete(tablePath(nativeDriver, tableName))
+	}
+}
+
+// ReadRow finds row of model of dest by values of primary key columns in order of the model,
+// returns gorm.ErrRecordNotFound if there is no such row, table of db overrides table of model.
+// ReadRows call of table service isn't exposed by the driver version used, so row is read by
+// single-row data query with typed parameters: text of query depends only on the table, so it
+// is compiled once and served by query cache of the server, outside of transactions it runs in
+// online read-only transaction
+//
+//	var user User
+//	err := ydb.ReadRow(ctx, db, &user, userID)
+func ReadRow(ctx context.Context, db *gorm.DB, dest interface{}, pk ...interface{}) error {
+	stmt, err := parseStatement(db, dest)
+	if err != nil {
+		return err
+	}
+	if db.Statement.Table != "" {
+		stmt.Table = db.Statement.Table
+	}
+	columns := stmt.Schema.PrimaryFieldDBNames
+	if len(pk) != len(columns) {
+		return fmt.Errorf("ydb: primary key of %s has %d columns, %d values given", stmt.Table, len(columns), len(pk))
+	}
+	columnTypes, err := primaryKeyTypesOf(ctx, db, stmt.Table)
+	if err != nil {
+		return err
+	}
+
+	var sql strings.Builder
+	sql.WriteString("SELECT * FROM ")
+	stmt.QuoteTo(&sql, stmt.Table)
+	sql.WriteString(" WHERE ")
+	params := make([]table.ParameterOption, len(columns))
+	for i, column := range columns {
+		t, ok := columnTypes[column]
+		if !ok {
+			return fmt.Errorf("ydb: column %s is not primary key of table %s", column, stmt.Table)
+		}
+		v, err := coerceValue(t, pk[i])
+		if err != nil {
+			return fmt.Errorf("ydb: key of %s, column %s: %w", stmt.Table, column, err)
+		}
+		name := "$k" + strconv.Itoa(i)
+		params[i] = Param(name, v)
+		if i > 0 {
+			sql.WriteString(" AND ")
+		}
+		stmt.QuoteTo(&sql, column)
+		sql.WriteString(" = ")
+		sql.WriteString(name)
+	}
+	sql.WriteString(" LIMIT 1")
+
+	if _, inTx := db.Statement.ConnPool.(gorm.TxCommitter); !inTx {
+		ctx = ydb.WithTxControl(ctx, table.TxControl(table.BeginTx(table.WithOnlineReadOnly()), table.CommitTx()))
+	}
+	return Raw(ctx, db, sql.String(), params...).Take(dest).Error
+}
@@ -0,0 +1,130 @@
+package ydb
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"github.com/ydb-platform/ydb-go-sdk/v3/trace"
+)
+
+// MetricsRegisterer registers metrics of dialector in metrics library, see Config.MetricsRegisterer.
+// Metrics are registered once by Initialize:
+//   - ydb_session_acquire_seconds{source} histogram of creating sessions of connections
+//     (source "create") and taking sessions of pool of table client (source "pool")
+//   - ydb_retries_total{source, reason} counter of failed attempts retried by table client
+//     (source "table") and statements retried by connector after scheme changes (source
+//     "statement"), reason is name of YDB status or transport error, e.g. ABORTED
+//   - ydb_result_truncations_total counter of results truncated by server
+//
+// Client of Prometheus isn't dependency of the module, its registerer is adapted by
+//
+//	type promRegisterer struct{ prometheus.Registerer }
+//
+//	func (r promRegisterer) Histogram(name, help string, buckets []float64, labels ...string) func(float64, ...string) {
+//		h := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: name, Help: help, Buckets: buckets}, labels)
+//		r.MustRegister(h)
+//		return func(v float64, labelValues ...string) { h.WithLabelValues(labelValues...).Observe(v) }
+//	}
+//
+//	func (r promRegisterer) Counter(name, help string, labels ...string) func(float64, ...string) {
+//		c := prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: help}, labels)
+//		r.MustRegister(c)
+//		return func(v float64, labelValues ...string) { c.WithLabelValues(labelValues...).Add(v) }
+//	}
+//
+//	db, err := gorm.Open(ydb.New(ydb.Config{DSN: dsn, MetricsRegisterer: promRegisterer{prometheus.DefaultRegisterer}}))
+type MetricsRegisterer interface {
+	// Histogram registers histogram, returned function observes value with values of labels
+	Histogram(name, help string, buckets []float64, labels ...string) func(value float64, labelValues ...string)
+	// Counter registers counter, returned function adds value with values of labels
+	Counter(name, help string, labels ...string) func(value float64, labelValues ...string)
+}
+
+// sessionAcquireBuckets are buckets of session acquire latency in seconds
+var sessionAcquireBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type metrics struct {
+	sessionAcquire    func(value float64, labelValues ...string)
+	retries           func(value float64, labelValues ...string)
+	resultTruncations func(value float64, labelValues ...string)
+}
+
+func newMetrics(registerer MetricsRegisterer) *metrics {
+	return &metrics{
+		sessionAcquire: registerer.Histogram("ydb_session_acquire_seconds",
+			"Latency of acquiring YDB sessions.", sessionAcquireBuckets, "source"),
+		retries: registerer.Counter("ydb_retries_total",
+			"Count of retried YDB requests by reason.", "source", "reason"),
+		resultTruncations: registerer.Counter("ydb_result_truncations_total",
+			"Count of YDB results truncated by server."),
+	}
+}
+
+// trace returns table trace of native driver observing sessions and retries, metrics of
+// Config.NativeDriver are collected only when it was opened with this trace
+func (m *metrics) trace() trace.Table {
+	if m == nil {
+		return trace.Table{}
+	}
+	observeAcquire := func(source string) func(error) {
+		start := time.Now()
+		return func(err error) {
+			if err == nil {
+				m.sessionAcquire(time.Since(start).Seconds(), source)
+			}
+		}
+	}
+	return trace.Table{
+		OnCreateSession: func(trace.TableCreateSessionStartInfo) func(trace.TableCreateSessionIntermediateInfo) func(trace.TableCreateSessionDoneInfo) {
+			done := observeAcquire("create")
+			return func(trace.TableCreateSessionIntermediateInfo) func(trace.TableCreateSessionDoneInfo) {
+				return func(info trace.TableCreateSessionDoneInfo) {
+					done(info.Error)
+				}
+			}
+		},
+		OnPoolGet: func(trace.TablePoolGetStartInfo) func(trace.TablePoolGetDoneInfo) {
+			done := observeAcquire("pool")
+			return func(info trace.TablePoolGetDoneInfo) {
+				done(info.Error)
+			}
+		},
+		OnDo: func(trace.TableDoStartInfo) func(trace.TableDoIntermediateInfo) func(trace.TableDoDoneInfo) {
+			return func(info trace.TableDoIntermediateInfo) func(trace.TableDoDoneInfo) {
+				m.retried("table", info.Error)
+				return nil
+			}
+		},
+		OnDoTx: func(trace.TableDoTxStartInfo) func(trace.TableDoTxIntermediateInfo) func(trace.TableDoTxDoneInfo) {
+			return func(info trace.TableDoTxIntermediateInfo) func(trace.TableDoTxDoneInfo) {
+				m.retried("table", info.Error)
+				return nil
+			}
+		},
+	}
+}
+
+// retried counts attempt failed with err and retried
+func (m *metrics) retried(source string, err error) {
+	if m == nil || err == nil {
+		return
+	}
+	m.retries(1, source, retryReason(err))
+}
+
+func retryReason(err error) string {
+	var ydbErr ydb.Error
+	if errors.As(err, &ydbErr) {
+		return ydbErr.Name()
+	}
+	return "other"
+}
+
+// truncated counts result truncated by server, which native driver reports by error of rows
+func (m *metrics) truncated(err error) {
+	if m != nil && err != nil && strings.Contains(err.Error(), "truncated result") {
+		m.resultTruncations(1)
+	}
+}
@@ -5,8 +5,13 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb"
+	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb_Table"
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/options"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 	"gorm.io/gorm/migrator"
@@ -43,6 +48,8 @@ var typeAliasMap = map[string][]string{
 	"bigint":   {"int8"},
 	"decimal":  {"numeric"},
 	"numeric":  {"decimal"},
+	// uint64 fields had Int64 columns before they were mapped to Uint64
+	"int64": {"uint64"},
 }
 
 type Migrator struct {
@@ -73,6 +80,31 @@ func (m Migrator) BuildIndexOptions(opts []schema.IndexOption, stmt *gorm.Statem
 	return
 }
 
+// FullDataTypeOf returns column definition in order of YDB syntax: type, FAMILY of field tagged
+// with family (family must exist, e.g. default or added by ALTER TABLE ADD FAMILY), NOT NULL
+// when server supports it (see Config.NotNullColumns) and DEFAULT on servers supporting
+// literal defaults. UNIQUE is not supported by YDB columns, unique indexes are created instead
+func (m Migrator) FullDataTypeOf(field *schema.Field) (expr clause.Expr) {
+	expr.SQL = m.DataTypeOf(field)
+
+	if family := field.TagSettings["FAMILY"]; family != "" {
+		expr.SQL += " FAMILY " + family
+	}
+
+	if m.notNull(field) {
+		expr.SQL += " NOT NULL"
+	}
+
+	if field.HasDefaultValue && m.supportsDefaults() {
+		if field.DefaultValueInterface != nil {
+			expr.SQL += " DEFAULT " + m.Dialector.Explain("?", field.DefaultValueInterface)
+		} else if field.DefaultValue != "" && field.DefaultValue != "(-)" {
+			expr.SQL += " DEFAULT " + field.DefaultValue
+		}
+	}
+	return
+}
+
 func (m Migrator) HasIndex(value interface{}, name string) bool {
 	var count int64
 	m.RunWithValue(value, func(stmt *gorm.Statement) error {
@@ -88,50 +120,160 @@ func (m Migrator) HasIndex(value interface{}, name string) bool {
 	return count > 0
 }
 
+// CreateIndex adds global index of model by ALTER TABLE ADD INDEX, see indexKind. Unique indexes
+// are created unique on servers supporting them, otherwise non-unique for UniqueIndexes plugin,
+// which must be used by db
 func (m Migrator) CreateIndex(value interface{}, name string) error {
 	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
 		if idx := stmt.Schema.LookIndex(name); idx != nil {
-			opts := m.BuildIndexOptions(idx.Fields, stmt)
-			values := []interface{}{clause.Column{Name: idx.Name}, m.CurrentTable(stmt), opts}
-
-			createIndexSQL := "CREATE "
-			if idx.Class != "" {
-				createIndexSQL += idx.Class + " "
-			}
-			createIndexSQL += "INDEX "
-
-			if strings.TrimSpace(strings.ToUpper(idx.Option)) == "CONCURRENTLY" {
-				createIndexSQL += "CONCURRENTLY "
-			}
-
-			createIndexSQL += "IF NOT EXISTS ? ON ?"
-
-			if idx.Type != "" {
-				createIndexSQL += " USING " + idx.Type + "(?)"
-			} else {
-				createIndexSQL += " ?"
+			kind, err := m.modelIndexKind(stmt, idx)
+			if err != nil {
+				return err
 			}
-
-			if idx.Where != "" {
-				createIndexSQL += " WHERE " + idx.Where
+			columns := make([]string, 0, len(idx.Fields))
+			for _, opt := range idx.Fields {
+				columns = append(columns, opt.DBName)
 			}
-
-			return m.DB.Exec(createIndexSQL, values...).Error
+			return m.execScheme("ALTER TABLE ? ADD INDEX ? "+kind+" ON ?",
+				m.CurrentTable(stmt), clause.Column{Name: idx.Name}, columnList(columns))
 		}
 
 		return fmt.Errorf("failed to create index with name %v", name)
 	})
 }
 
+// modelIndexKind returns kind of index of model in YQL, unique indexes are non-unique on servers
+// without unique indexes
+func (m Migrator) modelIndexKind(stmt *gorm.Statement, idx *schema.Index) (string, error) {
+	unique := strings.EqualFold(idx.Class, "UNIQUE")
+	if idx.Class != "" && !unique {
+		return "", fmt.Errorf("ydb: %s index %s is not supported", idx.Class, idx.Name)
+	}
+	if unique && !m.supportsUniqueIndexes() {
+		if _, ok := m.DB.Config.Plugins[uniqueIndexesKey]; !ok {
+			return "", fmt.Errorf("%w: index %s of %s", ErrUniqueIndexUnsupported, idx.Name, stmt.Table)
+		}
+		unique = false
+	}
+	return indexKind(idx, unique)
+}
+
+// indexKind returns kind of global index in YQL, e.g. GLOBAL UNIQUE SYNC: option of index tag
+// selects SYNC (the default) or ASYNC updates of index, unique indexes are synchronous. Types and
+// conditions of indexes are not supported by YDB
+func indexKind(idx *schema.Index, unique bool) (string, error) {
+	if idx.Type != "" || idx.Where != "" {
+		return "", fmt.Errorf("ydb: type and condition of index %s are not supported", idx.Name)
+	}
+	kind := "GLOBAL "
+	if unique {
+		kind += "UNIQUE "
+	}
+	switch option := strings.ToUpper(strings.TrimSpace(idx.Option)); option {
+	case "", "SYNC":
+		return kind + "SYNC", nil
+	case "ASYNC":
+		if unique {
+			return "", fmt.Errorf("ydb: unique index %s can't be ASYNC", idx.Name)
+		}
+		return kind + option, nil
+	default:
+		return "", fmt.Errorf("ydb: option %s of index %s is not supported", idx.Option, idx.Name)
+	}
+}
+
+// RenameIndex renames index by ALTER TABLE RENAME INDEX, on servers not supporting it index
+// with new name and columns of old one is added, built and old index is dropped
 func (m Migrator) RenameIndex(value interface{}, oldName, newName string) error {
 	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
-		return m.DB.Exec(
-			"ALTER INDEX ? RENAME TO ?",
-			clause.Column{Name: oldName}, clause.Column{Name: newName},
-		).Error
+		if idx := stmt.Schema.LookIndex(oldName); idx != nil {
+			oldName = idx.Name
+		}
+		err := m.execScheme("ALTER TABLE ? RENAME INDEX ? TO ?",
+			m.CurrentTable(stmt), clause.Column{Name: oldName}, clause.Column{Name: newName})
+		// statements unsupported by server fail to compile, other errors may be transient
+		if err == nil || !ydb.IsOperationError(err, Ydb.StatusIds_GENERIC_ERROR, Ydb.StatusIds_BAD_REQUEST, Ydb.StatusIds_UNSUPPORTED) {
+			return err
+		}
+
+		desc, describeErr := m.describeTable(stmt.Table)
+		if describeErr != nil {
+			return err
+		}
+		for _, index := range desc.Indexes {
+			if index.Name == oldName {
+				return m.recreateIndex(stmt, index, newName)
+			}
+		}
+		return err
 	})
 }
 
+// indexBuildPollInterval is interval of checks of status of index being built
+var indexBuildPollInterval = time.Second
+
+// recreateIndex adds copy of index named newName, waits until it is built and ready and drops
+// index. Descriptions of tables don't tell kinds of indexes, so kind is taken from index of model
+// named newName or index, GLOBAL SYNC for indexes unknown to model
+func (m Migrator) recreateIndex(stmt *gorm.Statement, index options.IndexDescription, newName string) error {
+	kind := "GLOBAL SYNC"
+	idx := stmt.Schema.LookIndex(newName)
+	if idx == nil {
+		idx = stmt.Schema.LookIndex(index.Name)
+	}
+	if idx != nil {
+		var err error
+		if kind, err = m.modelIndexKind(stmt, idx); err != nil {
+			return err
+		}
+	}
+	sql := "ALTER TABLE ? ADD INDEX ? " + kind + " ON ?"
+	values := []interface{}{m.CurrentTable(stmt), clause.Column{Name: newName}, columnList(index.IndexColumns)}
+	if len(index.DataColumns) > 0 {
+		sql += " COVER ?"
+		values = append(values, columnList(index.DataColumns))
+	}
+	if err := m.execScheme(sql, values...); err != nil {
+		return err
+	}
+
+	for {
+		desc, err := m.describeTable(stmt.Table)
+		if err != nil {
+			return err
+		}
+		var status Ydb_Table.TableIndexDescription_Status
+		for _, idx := range desc.Indexes {
+			if idx.Name == newName {
+				status = idx.Status
+			}
+		}
+		if status == Ydb_Table.TableIndexDescription_STATUS_READY {
+			break
+		}
+		// old index is kept when build of new one failed or was cancelled
+		if status != Ydb_Table.TableIndexDescription_STATUS_BUILDING {
+			return fmt.Errorf("ydb: index %s of %s copying index %s was not built, status %s",
+				newName, stmt.Table, index.Name, status)
+		}
+		select {
+		case <-time.After(indexBuildPollInterval):
+		case <-m.context().Done():
+			return m.context().Err()
+		}
+	}
+	return m.execScheme("ALTER TABLE ? DROP INDEX ?", m.CurrentTable(stmt), clause.Column{Name: index.Name})
+}
+
+// columnList returns parenthesized list of columns
+func columnList(names []string) []interface{} {
+	columns := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		columns = append(columns, clause.Column{Name: name})
+	}
+	return columns
+}
+
 func (m Migrator) DropIndex(value interface{}, name string) error {
 	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
 		if idx := stmt.Schema.LookIndex(name); idx != nil {
@@ -147,8 +289,39 @@ func (m Migrator) GetTables() (tableList []string, err error) {
 	return tableList, m.DB.Raw("SELECT table_name FROM information_schema.tables WHERE table_schema = ? AND table_type = ?", currentSchema, "BASE TABLE").Scan(&tableList).Error
 }
 
+// AutoMigrate ensures primary keys of join tables before migration, so join tables
+// created with AutoMigrate of existing models satisfy YDB, View models are skipped
+func (m Migrator) AutoMigrate(values ...interface{}) error {
+	values = withoutViews(values)
+	for _, value := range values {
+		if err := m.joinTablesPrimaryKeys(value); err != nil {
+			return err
+		}
+	}
+	return m.Migrator.AutoMigrate(values...)
+}
+
 func (m Migrator) CreateTable(values ...interface{}) (err error) {
-	if err = m.Migrator.CreateTable(values...); err != nil {
+	values = withoutViews(values)
+	for _, value := range values {
+		if err = m.joinTablesPrimaryKeys(value); err != nil {
+			return
+		}
+	}
+	var rowTables []interface{}
+	for _, value := range values {
+		if err = m.RunWithValue(value, func(stmt *gorm.Statement) error {
+			forgetPrimaryKeyTypes(m.DB, stmt.Table)
+			if !isColumnStore(stmt.Schema) {
+				rowTables = append(rowTables, value)
+				return nil
+			}
+			return m.createColumnTable(stmt, value)
+		}); err != nil {
+			return
+		}
+	}
+	if err = m.Migrator.CreateTable(rowTables...); err != nil {
 		return
 	}
 	for _, value := range m.ReorderModels(values, false) {
@@ -157,7 +330,7 @@ func (m Migrator) CreateTable(values ...interface{}) (err error) {
 				if field.Comment != "" {
 					if err := m.DB.Exec(
 						"COMMENT ON COLUMN ?.? IS ?",
-						m.CurrentTable(stmt), clause.Column{Name: field.DBName}, gorm.Expr(m.Migrator.Dialector.Explain("$1", field.Comment)),
+						m.CurrentTable(stmt), clause.Column{Name: field.DBName}, gorm.Expr(m.Migrator.Dialector.Explain("?", field.Comment)),
 					).Error; err != nil {
 						return err
 					}
@@ -171,6 +344,54 @@ func (m Migrator) CreateTable(values ...interface{}) (err error) {
 	return
 }
 
+// createColumnTable creates column-oriented table of ColumnStore model partitioned by hash of
+// primary key
+func (m Migrator) createColumnTable(stmt *gorm.Statement, value interface{}) error {
+	var options strings.Builder
+	options.WriteString(" PARTITION BY HASH(")
+	for i, column := range stmt.Schema.PrimaryFieldDBNames {
+		if i > 0 {
+			options.WriteByte(',')
+		}
+		stmt.QuoteTo(&options, column)
+	}
+	options.WriteString(") WITH (STORE = COLUMN)")
+	create := m.Migrator
+	create.DB = m.DB.Set("gorm:table_options", options.String())
+	return create.CreateTable(value)
+}
+
+// joinTablesPrimaryKeys makes foreign keys of many2many join tables without primary key
+// (custom join tables of SetupJoinTable) their composite primary key, YDB tables require one
+func (m Migrator) joinTablesPrimaryKeys(value interface{}) error {
+	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		if stmt.Schema == nil {
+			return nil
+		}
+		patchMu.Lock()
+		defer patchMu.Unlock()
+		for _, rel := range stmt.Schema.Relationships.Many2Many {
+			joinTable := rel.JoinTable
+			if joinTable == nil || len(joinTable.PrimaryFields) > 0 {
+				continue
+			}
+			for _, ref := range rel.References {
+				if ref.PrimaryKey == nil || ref.ForeignKey.Schema != joinTable || ref.ForeignKey.PrimaryKey {
+					continue
+				}
+				ref.ForeignKey.PrimaryKey = true
+				ref.ForeignKey.NotNull = true
+				joinTable.PrimaryFields = append(joinTable.PrimaryFields, ref.ForeignKey)
+				joinTable.PrimaryFieldDBNames = append(joinTable.PrimaryFieldDBNames, ref.ForeignKey.DBName)
+			}
+			if len(joinTable.PrimaryFields) == 1 {
+				joinTable.PrioritizedPrimaryField = joinTable.PrimaryFields[0]
+			}
+		}
+		return nil
+	})
+}
+
 func (m Migrator) HasTable(value interface{}) bool {
 	var count int64
 	m.RunWithValue(value, func(stmt *gorm.Statement) error {
@@ -181,11 +402,18 @@ func (m Migrator) HasTable(value interface{}) bool {
 }
 
 func (m Migrator) DropTable(values ...interface{}) error {
+	if err := m.checkDestructive("drop table"); err != nil {
+		return err
+	}
 	values = m.ReorderModels(values, false)
 	tx := m.DB.Session(&gorm.Session{})
 	for i := len(values) - 1; i >= 0; i-- {
 		if err := m.RunWithValue(values[i], func(stmt *gorm.Statement) error {
-			return tx.Exec("DROP TABLE IF EXISTS ? CASCADE", m.CurrentTable(stmt)).Error
+			if err := tx.Exec("DROP TABLE IF EXISTS ? CASCADE", m.CurrentTable(stmt)).Error; err != nil {
+				return err
+			}
+			forgetPrimaryKeyTypes(m.DB, stmt.Table)
+			return nil
 		}); err != nil {
 			return err
 		}
@@ -204,7 +432,7 @@ func (m Migrator) AddColumn(value interface{}, field string) error {
 			if field.Comment != "" {
 				if err := m.DB.Exec(
 					"COMMENT ON COLUMN ?.? IS ?",
-					m.CurrentTable(stmt), clause.Column{Name: field.DBName}, gorm.Expr(m.Migrator.Dialector.Explain("$1", field.Comment)),
+					m.CurrentTable(stmt), clause.Column{Name: field.DBName}, gorm.Expr(m.Migrator.Dialector.Explain("?", field.Comment)),
 				).Error; err != nil {
 					return err
 				}
@@ -257,7 +485,7 @@ func (m Migrator) MigrateColumn(value interface{}, field *schema.Field, columnTy
 		if field.Comment != "" && comment != description {
 			if err := m.DB.Exec(
 				"COMMENT ON COLUMN ?.? IS ?",
-				m.CurrentTable(stmt), clause.Column{Name: field.DBName}, gorm.Expr(m.Migrator.Dialector.Explain("$1", field.Comment)),
+				m.CurrentTable(stmt), clause.Column{Name: field.DBName}, gorm.Expr(m.Migrator.Dialector.Explain("?", field.Comment)),
 			).Error; err != nil {
 				return err
 			}
@@ -266,112 +494,6 @@ func (m Migrator) MigrateColumn(value interface{}, field *schema.Field, columnTy
 	})
 }
 
-// AlterColumn alter value's `field` column' type based on schema definition
-func (m Migrator) AlterColumn(value interface{}, field string) error {
-	err := m.RunWithValue(value, func(stmt *gorm.Statement) error {
-		if field := stmt.Schema.LookUpField(field); field != nil {
-			var (
-				columnTypes, _  = m.DB.Migrator().ColumnTypes(value)
-				fieldColumnType *migrator.ColumnType
-			)
-			for _, columnType := range columnTypes {
-				if columnType.Name() == field.DBName {
-					fieldColumnType, _ = columnType.(*migrator.ColumnType)
-				}
-			}
-
-			fileType := clause.Expr{SQL: m.DataTypeOf(field)}
-			// check for typeName and SQL name
-			isSameType := true
-			if fieldColumnType.DatabaseTypeName() != fileType.SQL {
-				isSameType = false
-				// if different, also check for aliases
-				aliases := m.GetTypeAliases(fieldColumnType.DatabaseTypeName())
-				for _, alias := range aliases {
-					if strings.HasPrefix(fileType.SQL, alias) {
-						isSameType = true
-						break
-					}
-				}
-			}
-
-			// not same, migrate
-			if !isSameType {
-				filedColumnAutoIncrement, _ := fieldColumnType.AutoIncrement()
-				if field.AutoIncrement && filedColumnAutoIncrement { // update
-					serialDatabaseType, _ := getSerialDatabaseType(fileType.SQL)
-					if t, _ := fieldColumnType.ColumnType(); t != serialDatabaseType {
-						if err := m.UpdateSequence(m.DB, stmt, field, serialDatabaseType); err != nil {
-							return err
-						}
-					}
-				} else if field.AutoIncrement && !filedColumnAutoIncrement { // create
-					serialDatabaseType, _ := getSerialDatabaseType(fileType.SQL)
-					if err := m.CreateSequence(m.DB, stmt, field, serialDatabaseType); err != nil {
-						return err
-					}
-				} else if !field.AutoIncrement && filedColumnAutoIncrement { // delete
-					if err := m.DeleteSequence(m.DB, stmt, field, fileType); err != nil {
-						return err
-					}
-				} else {
-					if err := m.DB.Exec("ALTER TABLE ? ALTER COLUMN ? TYPE ? USING ?::?",
-						m.CurrentTable(stmt), clause.Column{Name: field.DBName}, fileType, clause.Column{Name: field.DBName}, fileType).Error; err != nil {
-						return err
-					}
-				}
-			}
-
-			if null, _ := fieldColumnType.Nullable(); null == field.NotNull {
-				if field.NotNull {
-					if err := m.DB.Exec("ALTER TABLE ? ALTER COLUMN ? SET NOT NULL", m.CurrentTable(stmt), clause.Column{Name: field.DBName}).Error; err != nil {
-						return err
-					}
-				} else {
-					if err := m.DB.Exec("ALTER TABLE ? ALTER COLUMN ? DROP NOT NULL", m.CurrentTable(stmt), clause.Column{Name: field.DBName}).Error; err != nil {
-						return err
-					}
-				}
-			}
-
-			if uniq, _ := fieldColumnType.Unique(); !uniq && field.Unique {
-				idxName := clause.Column{Name: m.DB.Config.NamingStrategy.IndexName(stmt.Table, field.DBName)}
-				if err := m.DB.Exec("ALTER TABLE ? ADD CONSTRAINT ? UNIQUE(?)", m.CurrentTable(stmt), idxName, clause.Column{Name: field.DBName}).Error; err != nil {
-					return err
-				}
-			}
-
-			if v, ok := fieldColumnType.DefaultValue(); (field.DefaultValueInterface == nil && ok) || v != field.DefaultValue {
-				if field.HasDefaultValue && (field.DefaultValueInterface != nil || field.DefaultValue != "") {
-					if field.DefaultValueInterface != nil {
-						defaultStmt := &gorm.Statement{Vars: []interface{}{field.DefaultValueInterface}}
-						m.Dialector.BindVarTo(defaultStmt, defaultStmt, field.DefaultValueInterface)
-						if err := m.DB.Exec("ALTER TABLE ? ALTER COLUMN ? SET DEFAULT ?", m.CurrentTable(stmt), clause.Column{Name: field.DBName}, clause.Expr{SQL: m.Dialector.Explain(defaultStmt.SQL.String(), field.DefaultValueInterface)}).Error; err != nil {
-							return err
-						}
-					} else if field.DefaultValue != "(-)" {
-						if err := m.DB.Exec("ALTER TABLE ? ALTER COLUMN ? SET DEFAULT ?", m.CurrentTable(stmt), clause.Column{Name: field.DBName}, clause.Expr{SQL: field.DefaultValue}).Error; err != nil {
-							return err
-						}
-					} else {
-						if err := m.DB.Exec("ALTER TABLE ? ALTER COLUMN ? DROP DEFAULT", m.CurrentTable(stmt), clause.Column{Name: field.DBName}, clause.Expr{SQL: field.DefaultValue}).Error; err != nil {
-							return err
-						}
-					}
-				}
-			}
-			return nil
-		}
-		return fmt.Errorf("failed to look up field with name: %s", field)
-	})
-
-	if err != nil {
-		return err
-	}
-	m.resetPreparedStmts()
-	return nil
-}
-
 func (m Migrator) HasConstraint(value interface{}, name string) bool {
 	var count int64
 	m.RunWithValue(value, func(stmt *gorm.Statement) error {
@@ -728,6 +850,9 @@ func (m Migrator) resetPreparedStmts() {
 }
 
 func (m Migrator) DropColumn(dst interface{}, field string) error {
+	if err := m.checkDestructive("drop column " + field); err != nil {
+		return err
+	}
 	if err := m.Migrator.DropColumn(dst, field); err != nil {
 		return err
 	}
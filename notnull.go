@@ -0,0 +1,92 @@
+package ydb
+
+import (
+	"database/sql"
+	"reflect"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+var (
+	// notNullVersion is the first YDB version supporting NOT NULL columns in DDL
+	notNullVersion = [2]int{23, 1}
+	// defaultVersion is the first YDB version supporting DEFAULT literals of columns in DDL
+	defaultVersion = [2]int{24, 1}
+)
+
+// serverVersion is version of YDB detected once per dialector by Version() builtin of YQL,
+// detection is retried by next statements while Version() fails
+type serverVersion struct {
+	mu           sync.Mutex
+	detected     bool
+	major, minor int
+	known        bool
+}
+
+var versionMatcher = regexp.MustCompile(`(\d+)[.-](\d+)`)
+
+func (v *serverVersion) detect(db *gorm.DB) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.detected {
+		return
+	}
+	var version string
+	if err := db.Session(&gorm.Session{NewDB: true}).Raw("SELECT Version()").Row().Scan(&version); err != nil {
+		return
+	}
+	v.detected = true
+	if m := versionMatcher.FindStringSubmatch(version); m != nil {
+		v.major, _ = strconv.Atoi(m[1])
+		v.minor, _ = strconv.Atoi(m[2])
+		v.known = true
+	}
+}
+
+// atLeast reports whether detected version is not less than version, unknown version is
+// considered older than any
+func (v *serverVersion) atLeast(version [2]int) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.known && (v.major > version[0] || v.major == version[0] && v.minor >= version[1])
+}
+
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// notNull reports whether column of field is created NOT NULL: primary keys, fields tagged
+// with not null and, with Config.NotNullColumns, fields of non-nullable Go types, when server
+// supports NOT NULL columns
+func (m Migrator) notNull(field *schema.Field) bool {
+	config := configOf(m.DB)
+	if config == nil || config.version == nil {
+		return field.NotNull
+	}
+	if !field.PrimaryKey && !field.NotNull && !(config.NotNullColumns && nonNullable(field.FieldType)) {
+		return false
+	}
+	config.version.detect(m.DB)
+	return config.version.atLeast(notNullVersion)
+}
+
+// nonNullable reports whether values of t can't be nil or NULL
+func nonNullable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+		return false
+	}
+	return !reflect.PtrTo(t).Implements(scannerType)
+}
+
+// supportsDefaults reports whether server supports DEFAULT literals of columns
+func (m Migrator) supportsDefaults() bool {
+	config := configOf(m.DB)
+	if config == nil || config.version == nil {
+		return false
+	}
+	config.version.detect(m.DB)
+	return config.version.atLeast(defaultVersion)
+}
@@ -0,0 +1,101 @@
+package ydb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// NullStringPolicy defines how string fields of nullable columns are written and read
+type NullStringPolicy int
+
+const (
+	// NullStringsAsIs writes empty strings as "" and reads NULL into non-pointer strings as ""
+	NullStringsAsIs NullStringPolicy = iota
+	// NullStringsEmptyAsNull writes empty strings as NULL and reads NULL into non-pointer strings as "",
+	// matching behavior of databases treating empty strings as missing values
+	NullStringsEmptyAsNull
+	// NullStringsStrict writes empty strings as "" and fails scanning NULL into non-pointer strings
+	NullStringsStrict
+)
+
+func (policy NullStringPolicy) registerCallbacks(db *gorm.DB, patches *schemaPatches) error {
+	patchFields := func(db *gorm.DB) { policy.patchFields(db, patches) }
+	callback := db.Callback()
+	if err := callback.Create().Before("gorm:create").Register("ydb:null_strings", patchFields); err != nil {
+		return err
+	}
+	if err := callback.Query().Before("gorm:query").Register("ydb:null_strings", patchFields); err != nil {
+		return err
+	}
+	if err := callback.Update().Before("gorm:update").Register("ydb:null_strings", patchFields); err != nil {
+		return err
+	}
+	return callback.Row().Before("gorm:row").Register("ydb:null_strings", patchFields)
+}
+
+func (policy NullStringPolicy) patchFields(db *gorm.DB, patches *schemaPatches) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+	if _, ok := patches.nullStrings.Load(db.Statement.Schema); ok {
+		return
+	}
+
+	patchMu.Lock()
+	defer patchMu.Unlock()
+	policy.patchSchema(db.Statement.Schema, patches)
+	for _, rel := range db.Statement.Schema.Relationships.Relations {
+		if rel.FieldSchema != nil {
+			policy.patchSchema(rel.FieldSchema, patches)
+		}
+	}
+}
+
+func (policy NullStringPolicy) patchSchema(s *schema.Schema, patches *schemaPatches) {
+	if _, ok := patches.nullStrings.Load(s); ok {
+		return
+	}
+	for _, field := range s.Fields {
+		if !isNullableString(field) {
+			continue
+		}
+		switch policy {
+		case NullStringsEmptyAsNull:
+			valueOf := field.ValueOf
+			field.ValueOf = func(ctx context.Context, rv reflect.Value) (interface{}, bool) {
+				v, zero := valueOf(ctx, rv)
+				if str, ok := v.(string); ok && str == "" {
+					return types.NullValue(types.TypeUTF8), zero
+				}
+				return v, zero
+			}
+		case NullStringsStrict:
+			field := field
+			set := field.Set
+			field.Set = func(ctx context.Context, rv reflect.Value, v interface{}) error {
+				if data, ok := v.(**string); ok && (data == nil || *data == nil) {
+					return fmt.Errorf("ydb: NULL scanned into non-pointer string field %s", field.Name)
+				}
+				return set(ctx, rv, v)
+			}
+		}
+	}
+	patches.nullStrings.Store(s, struct{}{})
+}
+
+// isNullableString reports whether field is a plain non-pointer string of Optional column
+func isNullableString(field *schema.Field) bool {
+	if field.DBName == "" || field.PrimaryKey || field.NotNull || field.Serializer != nil {
+		return false
+	}
+	if field.FieldType.Kind() != reflect.String || field.DataType != schema.String {
+		return false
+	}
+	_, mapped := lookupTypeMapping(field.IndirectFieldType)
+	return !mapped
+}
@@ -0,0 +1,216 @@
+package ydb
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ColumnStore is implemented by models of column-oriented tables for analytical queries:
+// migrator creates their tables with STORE = COLUMN partitioned by hash of primary key, queries
+// outside of transactions run as scan queries, and logger warns about queries reading all
+// columns or filtering by predicates column shards can't evaluate, both materialize whole rows
+// instead of pushing work down to shards. Aggregates are pushed down by GroupAggregate
+//
+//	type Hit struct {
+//		Timestamp time.Time `gorm:"primaryKey"`
+//		URL       string    `gorm:"primaryKey"`
+//		Duration  int64
+//	}
+//
+//	func (Hit) ColumnStore() {}
+type ColumnStore interface {
+	ColumnStore()
+}
+
+func isColumnStore(s *schema.Schema) bool {
+	if s == nil {
+		return false
+	}
+	_, ok := reflect.New(s.ModelType).Interface().(ColumnStore)
+	return ok
+}
+
+func registerColumnStoreCallbacks(db *gorm.DB) error {
+	callback := db.Callback()
+	if err := callback.Query().Before("gorm:query").Register("ydb:column_store", prepareColumnStoreQuery); err != nil {
+		return err
+	}
+	return callback.Row().Before("gorm:row").Register("ydb:column_store", prepareColumnStoreQuery)
+}
+
+// prepareColumnStoreQuery runs queries of column tables as scan queries and warns about queries
+// which can't be pushed down
+func prepareColumnStoreQuery(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || !isColumnStore(stmt.Schema) {
+		return
+	}
+	// scan queries don't run in transactions
+	if _, inTx := stmt.ConnPool.(gorm.TxCommitter); !inTx {
+		stmt.Context = ydb.WithQueryMode(stmt.Context, ydb.ScanQueryMode)
+	}
+
+	var warnings []string
+	if selectsAllColumns(stmt) {
+		warnings = append(warnings, "reads all columns, select only needed columns")
+	}
+	if c, ok := stmt.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok {
+			for _, sql := range conditionsSQL(where.Exprs) {
+				if fn := columnFunction(sql); fn != "" {
+					warnings = append(warnings, fmt.Sprintf(
+						"condition %q applies %s to columns, compare columns with values instead", sql, fn))
+				}
+			}
+		}
+	}
+	if len(warnings) > 0 {
+		db.Logger.Warn(stmt.Context, "ydb: query of column table %s is not pushed down to column shards: %s",
+			stmt.Table, strings.Join(warnings, "; "))
+	}
+}
+
+func selectsAllColumns(stmt *gorm.Statement) bool {
+	if len(stmt.Selects) > 0 {
+		return false
+	}
+	c, ok := stmt.Clauses["SELECT"]
+	if !ok {
+		if stmt.QueryFields || !stmt.ReflectValue.IsValid() {
+			return !stmt.QueryFields
+		}
+		// gorm selects fields of destination structs smaller than model by name
+		t := stmt.ReflectValue.Type()
+		for t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		return t == stmt.Schema.ModelType
+	}
+	sel, ok := c.Expression.(clause.Select)
+	return ok && len(sel.Columns) == 0 && sel.Expression == nil
+}
+
+// conditionsSQL returns SQL of raw conditions of exprs
+func conditionsSQL(exprs []clause.Expression) (sql []string) {
+	for _, expr := range exprs {
+		switch e := expr.(type) {
+		case clause.Expr:
+			sql = append(sql, e.SQL)
+		case clause.NamedExpr:
+			sql = append(sql, e.SQL)
+		case clause.AndConditions:
+			sql = append(sql, conditionsSQL(e.Exprs)...)
+		case clause.OrConditions:
+			sql = append(sql, conditionsSQL(e.Exprs)...)
+		case clause.NotConditions:
+			sql = append(sql, conditionsSQL(e.Exprs)...)
+		}
+	}
+	return sql
+}
+
+var functionCall = regexp.MustCompile(`(?i)\b([a-z_][a-z0-9_:]*)\s*\(`)
+
+// conditionKeywords are followed by parentheses in conditions without calling functions
+var conditionKeywords = map[string]bool{"IN": true, "AND": true, "OR": true, "NOT": true, "EXISTS": true, "SELECT": true}
+
+// columnFunction returns name of function called by condition, empty if there is none
+func columnFunction(sql string) string {
+	for _, m := range functionCall.FindAllStringSubmatch(sql, -1) {
+		if name := strings.ToUpper(m[1]); !conditionKeywords[name] {
+			return name
+		}
+	}
+	return ""
+}
+
+// AggregateFunc is aggregate of column pushed down to column shards, see GroupAggregate
+type AggregateFunc struct {
+	// Func is COUNT, SUM, MIN, MAX or AVG
+	Func string
+	// Column is aggregated column, empty for COUNT(*)
+	Column string
+	// Alias is name of result column
+	Alias string
+}
+
+// CountRows returns COUNT(*) aggregate named alias
+func CountRows(alias string) AggregateFunc {
+	return AggregateFunc{Func: "COUNT", Alias: alias}
+}
+
+// SumOf returns SUM aggregate of column named alias
+func SumOf(column, alias string) AggregateFunc {
+	return AggregateFunc{Func: "SUM", Column: column, Alias: alias}
+}
+
+// MinOf returns MIN aggregate of column named alias
+func MinOf(column, alias string) AggregateFunc {
+	return AggregateFunc{Func: "MIN", Column: column, Alias: alias}
+}
+
+// MaxOf returns MAX aggregate of column named alias
+func MaxOf(column, alias string) AggregateFunc {
+	return AggregateFunc{Func: "MAX", Column: column, Alias: alias}
+}
+
+// AvgOf returns AVG aggregate of column named alias
+func AvgOf(column, alias string) AggregateFunc {
+	return AggregateFunc{Func: "AVG", Column: column, Alias: alias}
+}
+
+// GroupAggregate returns scope selecting aggregates of rows grouped by columns in the form column
+// shards compute them: aggregates of plain columns grouped by plain columns, so shards return
+// partial aggregates instead of rows
+//
+//	var stats []struct {
+//		URL   string
+//		Hits  uint64
+//		Total int64
+//	}
+//	db.Model(&Hit{}).Where("timestamp >= ?", since).
+//		Scopes(ydb.GroupAggregate([]string{"url"}, ydb.CountRows("hits"), ydb.SumOf("duration", "total"))).
+//		Find(&stats)
+func GroupAggregate(groupBy []string, aggregates ...AggregateFunc) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		groups := clause.GroupBy{}
+		for _, column := range groupBy {
+			groups.Columns = append(groups.Columns, clause.Column{Name: column})
+		}
+		var sql strings.Builder
+		var vars []interface{}
+		for i, column := range groupBy {
+			if i > 0 {
+				sql.WriteByte(',')
+			}
+			sql.WriteByte('?')
+			vars = append(vars, clause.Column{Name: column})
+		}
+		for _, agg := range aggregates {
+			if sql.Len() > 0 {
+				sql.WriteByte(',')
+			}
+			sql.WriteString(strings.ToUpper(agg.Func))
+			if agg.Column == "" {
+				sql.WriteString("(*)")
+			} else {
+				sql.WriteString("(?)")
+				vars = append(vars, clause.Column{Name: agg.Column})
+			}
+			sql.WriteString(" AS ?")
+			vars = append(vars, clause.Column{Name: agg.Alias})
+		}
+		db = db.Clauses(clause.Select{Expression: clause.Expr{SQL: sql.String(), Vars: vars}})
+		if len(groupBy) > 0 {
+			db = db.Clauses(groups)
+		}
+		return db
+	}
+}
@@ -0,0 +1,309 @@
+package ydb
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/topic/topicoptions"
+	"github.com/ydb-platform/ydb-go-sdk/v3/topic/topicwriter"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// ErrOutboxNotRegistered returned by AppendOutbox when Outbox plugin is not used by db
+var ErrOutboxNotRegistered = errors.New("ydb: outbox plugin is not registered")
+
+// OutboxEvent is a row of outbox table, event waiting for publishing by OutboxRelay
+type OutboxEvent struct {
+	ID string `gorm:"primaryKey"`
+	// Topic path of topic the event is published to
+	Topic string
+	// Payload of message, JSON of Value if empty
+	Payload []byte
+	Value   interface{} `gorm:"-"`
+	// SeqNo assigned by relay before publishing, deduplicates messages published again
+	SeqNo     *uint64
+	CreatedAt time.Time
+}
+
+// OutboxModel is implemented by models emitting events on creates, updates and deletes,
+// operation is create, update or delete
+type OutboxModel interface {
+	OutboxEvents(operation string) []OutboxEvent
+}
+
+var outboxModelType = reflect.TypeOf((*OutboxModel)(nil)).Elem()
+
+// emitsOutboxEvents reports whether models of schema implement OutboxModel
+func emitsOutboxEvents(s *schema.Schema) bool {
+	return s != nil && (s.ModelType.Implements(outboxModelType) || reflect.PtrTo(s.ModelType).Implements(outboxModelType))
+}
+
+// Outbox is a gorm plugin of transactional outbox: events of models implementing OutboxModel
+// and events passed to AppendOutbox are written to outbox table in transaction of the write,
+// so events are stored only when the write commits, OutboxRelay publishes them afterwards.
+// Writes executed with gorm.Config.SkipDefaultTransaction outside of transactions aren't atomic
+// with their events
+//
+//	db.Use(&ydb.Outbox{})
+//	db.Transaction(func(tx *gorm.DB) error {
+//		if err := tx.Create(&order).Error; err != nil {
+//			return err
+//		}
+//		return ydb.AppendOutbox(tx, ydb.OutboxEvent{Topic: "orders/events", Value: OrderCreated{ID: order.ID}})
+//	})
+type Outbox struct {
+	// Table of events created on initialization, defaults to _outbox
+	Table string
+}
+
+const outboxKey = "ydb:outbox"
+
+func (o *Outbox) Name() string {
+	return outboxKey
+}
+
+func (o *Outbox) Initialize(db *gorm.DB) error {
+	if o.Table == "" {
+		o.Table = "_outbox"
+	}
+	if !db.DryRun {
+		if err := db.Session(&gorm.Session{NewDB: true}).Table(o.Table).AutoMigrate(&OutboxEvent{}); err != nil {
+			return err
+		}
+	}
+
+	callback := db.Callback()
+	if err := callback.Create().After("gorm:create").Register(outboxKey, o.emit("create")); err != nil {
+		return err
+	}
+	if err := callback.Update().After("gorm:update").Register(outboxKey, o.emit("update")); err != nil {
+		return err
+	}
+	return callback.Delete().After("gorm:delete").Register(outboxKey, o.emit("delete"))
+}
+
+// emit appends events of models of statement in its transaction
+func (o *Outbox) emit(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Error != nil || db.DryRun || db.Statement.Table == o.Table || !db.Statement.ReflectValue.IsValid() || Replayed(db) {
+			return
+		}
+		var events []OutboxEvent
+		collect := func(rv reflect.Value) {
+			if rv.Kind() != reflect.Ptr && rv.CanAddr() {
+				rv = rv.Addr()
+			}
+			if model, ok := rv.Interface().(OutboxModel); ok {
+				events = append(events, model.OutboxEvents(operation)...)
+			}
+		}
+		switch rv := reflect.Indirect(db.Statement.ReflectValue); rv.Kind() {
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < rv.Len(); i++ {
+				collect(rv.Index(i))
+			}
+		case reflect.Struct:
+			collect(rv)
+		}
+		if len(events) > 0 {
+			if err := o.append(db, events); err != nil {
+				db.AddError(err)
+			}
+		}
+	}
+}
+
+// AppendOutbox writes events to outbox table in transaction of tx
+func AppendOutbox(tx *gorm.DB, events ...OutboxEvent) error {
+	o, ok := tx.Config.Plugins[outboxKey].(*Outbox)
+	if !ok {
+		return ErrOutboxNotRegistered
+	}
+	return o.append(tx, events)
+}
+
+func (o *Outbox) append(db *gorm.DB, events []OutboxEvent) error {
+	now := time.Now()
+	for i := range events {
+		event := &events[i]
+		if event.ID == "" {
+			id := make([]byte, 16)
+			if _, err := rand.Read(id); err != nil {
+				return err
+			}
+			event.ID = hex.EncodeToString(id)
+		}
+		if len(event.Payload) == 0 && event.Value != nil {
+			payload, err := json.Marshal(event.Value)
+			if err != nil {
+				return err
+			}
+			event.Payload = payload
+		}
+		if event.CreatedAt.IsZero() {
+			event.CreatedAt = now
+		}
+		event.SeqNo = nil
+	}
+	return db.Session(&gorm.Session{NewDB: true, SkipHooks: true, SkipDefaultTransaction: true}).
+		Table(o.Table).Create(&events).Error
+}
+
+// OutboxRelay publishes events of outbox table in order of their writes and deletes published
+// events. Only one relay of table runs at once, others wait for lock of table (see WithAppLock).
+// Events get increasing sequence numbers before publishing, topics written by the same
+// ProducerID deduplicate messages published again by relay restarted after failure, so each
+// event is published once; Publish callbacks may deduplicate events by SeqNo
+//
+//	relay := &ydb.OutboxRelay{ProducerID: "orders-service"}
+//	go relay.Run(ctx, db)
+type OutboxRelay struct {
+	// Table of events, defaults to _outbox
+	Table string
+	// ProducerID of topic writers, defaults to outbox table name
+	ProducerID string
+	// BatchSize max count of events published at once, defaults to 100
+	BatchSize int
+	// PollInterval delay of reading new events after outbox table was drained, defaults to one second
+	PollInterval time.Duration
+	// Publish called with events instead of writing them to their topics
+	Publish func(ctx context.Context, events []OutboxEvent) error
+	// OnError called with errors of reading and publishing events, publishing is retried
+	OnError func(err error)
+
+	db      *gorm.DB
+	writers map[string]*TopicWriter
+}
+
+// Run publishes events until ctx is done, returns ctx error or error of lock of table
+func (r *OutboxRelay) Run(ctx context.Context, db *gorm.DB) error {
+	if r.Table == "" {
+		r.Table = "_outbox"
+	}
+	if r.ProducerID == "" {
+		r.ProducerID = r.Table
+	}
+	if r.BatchSize <= 0 {
+		r.BatchSize = 100
+	}
+	if r.PollInterval <= 0 {
+		r.PollInterval = time.Second
+	}
+	r.db = db
+	r.writers = map[string]*TopicWriter{}
+	defer func() {
+		for _, w := range r.writers {
+			_ = w.Close(context.Background())
+		}
+	}()
+
+	return WithAppLock(ctx, db, "outbox:"+r.Table, func(ctx context.Context) error {
+		tx := db.Session(&gorm.Session{NewDB: true, SkipHooks: true, Context: ctx})
+		for {
+			n, err := r.relay(ctx, tx)
+			if err != nil && ctx.Err() == nil && r.OnError != nil {
+				r.OnError(err)
+			}
+			if err != nil || n < r.BatchSize {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(r.PollInterval):
+				}
+			}
+		}
+	})
+}
+
+// relay publishes batch of events and deletes them, returns count of published events
+func (r *OutboxRelay) relay(ctx context.Context, db *gorm.DB) (int, error) {
+	// events numbered by interrupted relay are published first, in order of their numbers
+	var events []OutboxEvent
+	if err := db.Table(r.Table).Where("seq_no IS NOT NULL").Order("seq_no").Limit(r.BatchSize).Find(&events).Error; err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		if err := db.Table(r.Table).Where("seq_no IS NULL").Order("created_at, id").Limit(r.BatchSize).Find(&events).Error; err != nil {
+			return 0, err
+		}
+		if len(events) == 0 {
+			return 0, nil
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			for i := range events {
+				seqNo, err := NextID(ctx, db, "outbox:"+r.Table)
+				if err != nil {
+					return err
+				}
+				events[i].SeqNo = &seqNo
+				if err = tx.Table(r.Table).Where("id = ?", events[i].ID).Update("seq_no", seqNo).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	publish := r.Publish
+	if publish == nil {
+		publish = r.publishTopics
+	}
+	if err := publish(ctx, events); err != nil {
+		return 0, err
+	}
+	ids := make([]string, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	if err := db.Table(r.Table).Where("id IN ?", ids).Delete(&OutboxEvent{}).Error; err != nil {
+		return 0, err
+	}
+	return len(events), nil
+}
+
+// publishTopics writes events to their topics with sequence numbers and waits for acks
+func (r *OutboxRelay) publishTopics(ctx context.Context, events []OutboxEvent) error {
+	var (
+		topics   []string
+		messages = map[string][]topicwriter.Message{}
+	)
+	for _, event := range events {
+		if _, ok := messages[event.Topic]; !ok {
+			topics = append(topics, event.Topic)
+		}
+		messages[event.Topic] = append(messages[event.Topic], topicwriter.Message{
+			SeqNo:     int64(*event.SeqNo),
+			CreatedAt: event.CreatedAt,
+			Data:      bytes.NewReader(event.Payload),
+		})
+	}
+	for _, topic := range topics {
+		w, ok := r.writers[topic]
+		if !ok {
+			var err error
+			w, err = NewTopicWriter(r.db, r.ProducerID, topic,
+				topicoptions.WithWriterSetAutoSeqNo(false), topicoptions.WithSyncWrite(true))
+			if err != nil {
+				return err
+			}
+			r.writers[topic] = w
+		}
+		if err := w.writer.Write(ctx, messages[topic]...); err != nil {
+			// write session may be broken, next attempt restarts it
+			_ = w.Close(ctx)
+			delete(r.writers, topic)
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,768 @@
+package ydb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"time"
+)
+
+// ImportParquet is import format reading flat Apache Parquet files of plain and dictionary
+// encoded pages (v1 and v2), uncompressed or compressed with Snappy or gzip. Files are read by
+// row groups at their offsets when r is io.ReaderAt with io.Seeker (e.g. *os.File), other
+// readers are read into memory. Strings are imported as text, dates and timestamps as times,
+// decimals with their scale. Nested columns, other encodings (e.g. DELTA_BINARY_PACKED) and
+// codecs (e.g. ZSTD) fail import
+var ImportParquet ImportFormat = func(r io.Reader) (ImportDecoder, error) {
+	file, ok := r.(parquetFile)
+	var size int64
+	if ok {
+		var err error
+		if size, err = file.Seek(0, io.SeekEnd); err != nil {
+			return nil, err
+		}
+	} else {
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		file, size = bytes.NewReader(data), int64(len(data))
+	}
+
+	tail := make([]byte, 8)
+	if size < 12 {
+		return nil, errNotParquet
+	}
+	if _, err := file.ReadAt(tail, size-8); err != nil {
+		return nil, err
+	}
+	footerSize := int64(binary.LittleEndian.Uint32(tail))
+	if string(tail[4:]) != parquetMagic || footerSize > size-12 {
+		return nil, errNotParquet
+	}
+	footer := make([]byte, footerSize)
+	if _, err := file.ReadAt(footer, size-8-footerSize); err != nil {
+		return nil, err
+	}
+	metadata, err := (&thriftReader{buf: footer}).readStruct(0)
+	if err != nil {
+		return nil, fmt.Errorf("ydb: Parquet metadata: %w", err)
+	}
+
+	decoder := &parquetDecoder{r: file, size: size, rowGroups: metadata.list(4)}
+	schema := metadata.list(2)
+	if len(schema) == 0 || int(thriftStructOf(schema[0]).int(5)) != len(schema)-1 {
+		return nil, errors.New("ydb: nested Parquet schema isn't supported")
+	}
+	for _, element := range schema[1:] {
+		column, err := parquetColumnOf(thriftStructOf(element))
+		if err != nil {
+			return nil, err
+		}
+		decoder.columns = append(decoder.columns, column)
+		decoder.names = append(decoder.names, column.name)
+	}
+	return decoder, nil
+}
+
+const parquetMagic = "PAR1"
+
+var errNotParquet = errors.New("ydb: not a Parquet file")
+
+// parquetFile is Parquet file read at offsets
+type parquetFile interface {
+	io.ReaderAt
+	io.Seeker
+}
+
+// parquetKind is kind of values of Parquet column defined by its logical or converted type
+type parquetKind int
+
+const (
+	parquetPlain parquetKind = iota
+	parquetString
+	parquetUnsigned
+	parquetDate
+	parquetDecimal
+	parquetTimestamp
+	parquetTime
+)
+
+// physical types of Parquet columns
+const (
+	parquetBoolean = iota
+	parquetInt32
+	parquetInt64
+	parquetInt96
+	parquetFloat
+	parquetDouble
+	parquetByteArray
+	parquetFixedLenByteArray
+)
+
+// parquetColumn is leaf column of flat Parquet schema, unit is of timestamps and times
+type parquetColumn struct {
+	name       string
+	typ        int64
+	typeLength int
+	optional   bool
+	kind       parquetKind
+	scale      int
+	unit       time.Duration
+}
+
+// parquetColumnOf returns column of SchemaElement
+func parquetColumnOf(element thriftStruct) (parquetColumn, error) {
+	column := parquetColumn{
+		name:       string(element.bytes(4)),
+		typ:        element.int(1),
+		typeLength: int(element.int(2)),
+		optional:   element.int(3) == 1,
+	}
+	if element.int(5) > 0 || element.int(3) == 2 {
+		return column, fmt.Errorf("ydb: nested Parquet column %s isn't supported", column.name)
+	}
+	if _, ok := element[10]; ok {
+		logical := element.strct(10)
+		switch {
+		case logical.has(1), logical.has(4), logical.has(12):
+			column.kind = parquetString
+		case logical.has(5):
+			column.kind, column.scale = parquetDecimal, int(logical.strct(5).int(1))
+		case logical.has(6):
+			column.kind = parquetDate
+		case logical.has(7):
+			column.kind, column.unit = parquetTime, parquetUnit(logical.strct(7).strct(2))
+		case logical.has(8):
+			column.kind, column.unit = parquetTimestamp, parquetUnit(logical.strct(8).strct(2))
+		case logical.has(10) && !logical.strct(10).bool(2, true):
+			column.kind = parquetUnsigned
+		}
+		return column, nil
+	}
+	if _, ok := element[6]; !ok {
+		return column, nil
+	}
+	switch converted := element.int(6); converted {
+	case 0, 4, 19: // UTF8, ENUM, JSON
+		column.kind = parquetString
+	case 5:
+		column.kind, column.scale = parquetDecimal, int(element.int(7))
+	case 6:
+		column.kind = parquetDate
+	case 7, 8:
+		column.kind, column.unit = parquetTime, time.Millisecond
+		if converted == 8 {
+			column.unit = time.Microsecond
+		}
+	case 9, 10:
+		column.kind, column.unit = parquetTimestamp, time.Millisecond
+		if converted == 10 {
+			column.unit = time.Microsecond
+		}
+	case 11, 12, 13, 14: // UINT_8, UINT_16, UINT_32, UINT_64
+		column.kind = parquetUnsigned
+	}
+	return column, nil
+}
+
+// parquetUnit returns duration of TimeUnit
+func parquetUnit(unit thriftStruct) time.Duration {
+	switch {
+	case unit.has(1):
+		return time.Millisecond
+	case unit.has(3):
+		return time.Nanosecond
+	}
+	return time.Microsecond
+}
+
+// value converts physical value v of column to value imported by coerceValue
+func (c parquetColumn) value(v interface{}) interface{} {
+	var i int64
+	switch v := v.(type) {
+	case int32:
+		i = int64(v)
+	case int64:
+		i = v
+	}
+	switch c.kind {
+	case parquetString:
+		if b, ok := v.([]byte); ok {
+			return string(b)
+		}
+	case parquetUnsigned:
+		if v, ok := v.(int32); ok {
+			return uint32(v)
+		}
+		return uint64(i)
+	case parquetDate:
+		return time.Unix(i*24*60*60, 0).UTC()
+	case parquetDecimal:
+		unscaled := big.NewInt(i)
+		if b, ok := v.([]byte); ok {
+			unscaled.SetBytes(b)
+			if len(b) > 0 && b[0]&0x80 != 0 {
+				unscaled.Sub(unscaled, new(big.Int).Lsh(big.NewInt(1), uint(8*len(b))))
+			}
+		}
+		scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(c.scale)), nil)
+		return new(big.Rat).SetFrac(unscaled, scale).FloatString(c.scale)
+	case parquetTimestamp:
+		perSecond := int64(time.Second / c.unit)
+		return time.Unix(i/perSecond, i%perSecond*int64(c.unit)).UTC()
+	case parquetTime:
+		return time.Duration(i) * c.unit
+	}
+	if b, ok := v.([]byte); ok && c.typ == parquetInt96 {
+		// nanoseconds of day and Julian day of legacy timestamps
+		days := int64(binary.LittleEndian.Uint32(b[8:])) - 2440588
+		return time.Unix(days*24*60*60, int64(binary.LittleEndian.Uint64(b))).UTC()
+	}
+	return v
+}
+
+type parquetDecoder struct {
+	r         io.ReaderAt
+	size      int64
+	columns   []parquetColumn
+	names     []string
+	rowGroups []interface{}
+	// values of columns of current row group
+	values [][]interface{}
+	row    int
+}
+
+func (d *parquetDecoder) Columns() []string {
+	return d.names
+}
+
+func (d *parquetDecoder) Decode() ([]interface{}, error) {
+	for len(d.values) == 0 || d.row == len(d.values[0]) {
+		if len(d.rowGroups) == 0 {
+			return nil, io.EOF
+		}
+		if err := d.readRowGroup(thriftStructOf(d.rowGroups[0])); err != nil {
+			return nil, err
+		}
+		d.rowGroups = d.rowGroups[1:]
+	}
+	row := make([]interface{}, len(d.values))
+	for i := range d.values {
+		row[i] = d.values[i][d.row]
+	}
+	d.row++
+	return row, nil
+}
+
+// readRowGroup reads values of columns of RowGroup
+func (d *parquetDecoder) readRowGroup(rowGroup thriftStruct) error {
+	chunks := rowGroup.list(1)
+	if len(chunks) != len(d.columns) {
+		return fmt.Errorf("ydb: Parquet row group of %d columns, want %d", len(chunks), len(d.columns))
+	}
+	rows := int(rowGroup.int(3))
+	d.values, d.row = make([][]interface{}, len(d.columns)), 0
+	for i, column := range d.columns {
+		values, err := d.readColumn(column, thriftStructOf(chunks[i]))
+		if err != nil {
+			return fmt.Errorf("ydb: Parquet column %s: %w", column.name, err)
+		}
+		if len(values) != rows {
+			return fmt.Errorf("ydb: Parquet column %s of %d values, want %d", column.name, len(values), rows)
+		}
+		d.values[i] = values
+	}
+	return nil
+}
+
+// page types of Parquet
+const (
+	parquetDataPage       = 0
+	parquetDictionaryPage = 2
+	parquetDataPageV2     = 3
+)
+
+// readColumn reads values of ColumnChunk
+func (d *parquetDecoder) readColumn(column parquetColumn, chunk thriftStruct) ([]interface{}, error) {
+	if chunk.has(1) {
+		return nil, errors.New("column chunks of other files aren't supported")
+	}
+	meta := chunk.strct(3)
+	codec := meta.int(4)
+	start, size := meta.int(9), meta.int(7)
+	if offset := meta.int(11); offset > 0 && offset < start {
+		start = offset
+	}
+	if start < 0 || size < 0 || start+size > d.size {
+		return nil, io.ErrUnexpectedEOF
+	}
+	data := make([]byte, size)
+	if _, err := d.r.ReadAt(data, start); err != nil {
+		return nil, err
+	}
+
+	total := int(meta.int(5))
+	var values, dictionary []interface{}
+	r := &thriftReader{buf: data}
+	for len(values) < total {
+		header, err := r.readStruct(0)
+		if err != nil {
+			return nil, err
+		}
+		pageSize, uncompressedSize := int(header.int(3)), int(header.int(2))
+		if pageSize < 0 || r.pos+pageSize > len(data) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		page := data[r.pos : r.pos+pageSize]
+		r.pos += pageSize
+
+		var levels []byte
+		switch header.int(1) {
+		case parquetDictionaryPage:
+			if page, err = decompress(codec, page, uncompressedSize); err != nil {
+				return nil, err
+			}
+			if dictionary, err = decodePlain(column, page, int(header.strct(7).int(1))); err != nil {
+				return nil, err
+			}
+		case parquetDataPage:
+			if page, err = decompress(codec, page, uncompressedSize); err != nil {
+				return nil, err
+			}
+			if column.optional {
+				if len(page) < 4 || 4+int(binary.LittleEndian.Uint32(page)) > len(page) {
+					return nil, io.ErrUnexpectedEOF
+				}
+				end := 4 + int(binary.LittleEndian.Uint32(page))
+				levels, page = page[4:end], page[end:]
+			}
+			dataPage := header.strct(5)
+			values, err = appendPage(values, column, dataPage.int(2), page, levels, int(dataPage.int(1)), dictionary)
+		case parquetDataPageV2:
+			dataPage := header.strct(8)
+			repetitionSize, definitionSize := int(dataPage.int(6)), int(dataPage.int(5))
+			if repetitionSize < 0 || definitionSize < 0 || repetitionSize+definitionSize > len(page) {
+				return nil, io.ErrUnexpectedEOF
+			}
+			levels, page = page[repetitionSize:repetitionSize+definitionSize], page[repetitionSize+definitionSize:]
+			if dataPage.bool(7, true) {
+				if page, err = decompress(codec, page, uncompressedSize-repetitionSize-definitionSize); err != nil {
+					return nil, err
+				}
+			}
+			values, err = appendPage(values, column, dataPage.int(4), page, levels, int(dataPage.int(1)), dictionary)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// appendPage appends n values of data page of encoding with definition levels of optional
+// column to values
+func appendPage(values []interface{}, column parquetColumn, encoding int64, page, levels []byte, n int, dictionary []interface{}) ([]interface{}, error) {
+	defined := n
+	var definitions []uint32
+	if column.optional {
+		var err error
+		if definitions, err = decodeHybrid(levels, 1, n); err != nil {
+			return nil, err
+		}
+		defined = 0
+		for _, level := range definitions {
+			defined += int(level)
+		}
+	}
+
+	var encoded []interface{}
+	switch encoding {
+	case 0: // PLAIN
+		var err error
+		if encoded, err = decodePlain(column, page, defined); err != nil {
+			return nil, err
+		}
+	case 2, 8: // PLAIN_DICTIONARY, RLE_DICTIONARY
+		if len(page) == 0 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		indices, err := decodeHybrid(page[1:], int(page[0]), defined)
+		if err != nil {
+			return nil, err
+		}
+		for _, index := range indices {
+			if int(index) >= len(dictionary) {
+				return nil, fmt.Errorf("index %d of dictionary of %d values", index, len(dictionary))
+			}
+			encoded = append(encoded, dictionary[index])
+		}
+	default:
+		return nil, fmt.Errorf("encoding %d isn't supported", encoding)
+	}
+
+	for i := 0; i < n; i++ {
+		if definitions != nil && definitions[i] == 0 {
+			values = append(values, nil)
+			continue
+		}
+		values = append(values, column.value(encoded[0]))
+		encoded = encoded[1:]
+	}
+	return values, nil
+}
+
+// decodePlain decodes n values of PLAIN encoding
+func decodePlain(column parquetColumn, data []byte, n int) ([]interface{}, error) {
+	size := map[int64]int{
+		parquetInt32: 4, parquetInt64: 8, parquetInt96: 12, parquetFloat: 4, parquetDouble: 8,
+		parquetFixedLenByteArray: column.typeLength,
+	}[column.typ]
+	switch {
+	case n < 0:
+		return nil, io.ErrUnexpectedEOF
+	case column.typ == parquetBoolean && (n+7)/8 > len(data):
+		return nil, io.ErrUnexpectedEOF
+	case size > 0 && n > len(data)/size:
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	values := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		var v interface{}
+		switch column.typ {
+		case parquetBoolean:
+			v = data[i/8]&(1<<(i%8)) != 0
+		case parquetInt32:
+			v = int32(binary.LittleEndian.Uint32(data[4*i:]))
+		case parquetInt64:
+			v = int64(binary.LittleEndian.Uint64(data[8*i:]))
+		case parquetFloat:
+			v = math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:]))
+		case parquetDouble:
+			v = math.Float64frombits(binary.LittleEndian.Uint64(data[8*i:]))
+		case parquetInt96, parquetFixedLenByteArray:
+			v = data[size*i : size*(i+1)]
+		case parquetByteArray:
+			if len(data) < 4 || 4+int(binary.LittleEndian.Uint32(data)) > len(data) {
+				return nil, io.ErrUnexpectedEOF
+			}
+			length := 4 + int(binary.LittleEndian.Uint32(data))
+			v, data = data[4:length], data[length:]
+		default:
+			return nil, fmt.Errorf("physical type %d isn't supported", column.typ)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// decodeHybrid decodes n values of bitWidth of RLE and bit-packing hybrid encoding
+func decodeHybrid(data []byte, bitWidth int, n int) ([]uint32, error) {
+	if bitWidth > 32 {
+		return nil, fmt.Errorf("bit width %d", bitWidth)
+	}
+	var values []uint32
+	for len(values) < n {
+		header, size := binary.Uvarint(data)
+		if size <= 0 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		data = data[size:]
+		if header&1 == 0 {
+			width := (bitWidth + 7) / 8
+			if len(data) < width {
+				return nil, io.ErrUnexpectedEOF
+			}
+			var v uint32
+			for i := 0; i < width; i++ {
+				v |= uint32(data[i]) << (8 * i)
+			}
+			data = data[width:]
+			for count := header >> 1; count > 0 && len(values) < n; count-- {
+				values = append(values, v)
+			}
+			continue
+		}
+		// groups of 8 values, the last group may be truncated
+		count := int(header>>1) * 8
+		for i := 0; i < count && len(values) < n; i++ {
+			var v uint32
+			for b := 0; b < bitWidth; b++ {
+				bit := i*bitWidth + b
+				if bit/8 >= len(data) {
+					return nil, io.ErrUnexpectedEOF
+				}
+				v |= uint32(data[bit/8]>>(bit%8)&1) << b
+			}
+			values = append(values, v)
+		}
+		if size := count / 8 * bitWidth; size < len(data) {
+			data = data[size:]
+		} else {
+			data = nil
+		}
+	}
+	return values, nil
+}
+
+// decompress decompresses page compressed with codec to size bytes
+func decompress(codec int64, page []byte, size int) ([]byte, error) {
+	var (
+		data []byte
+		err  error
+	)
+	switch codec {
+	case 0:
+		return page, nil
+	case 1:
+		data, err = decodeSnappy(page)
+	case 2:
+		var r *gzip.Reader
+		if r, err = gzip.NewReader(bytes.NewReader(page)); err == nil {
+			data, err = ioutil.ReadAll(r)
+		}
+	default:
+		return nil, fmt.Errorf("compression codec %d isn't supported", codec)
+	}
+	if err == nil && len(data) != size {
+		err = fmt.Errorf("page of %d bytes, want %d", len(data), size)
+	}
+	return data, err
+}
+
+// decodeSnappy decodes block of Snappy format
+func decodeSnappy(src []byte) ([]byte, error) {
+	size, n := binary.Uvarint(src)
+	if n <= 0 || size > math.MaxInt32 {
+		return nil, errors.New("invalid Snappy block")
+	}
+	dst := make([]byte, 0, size)
+	for s := n; s < len(src); {
+		tag := src[s]
+		var length, offset, header int
+		switch tag & 3 {
+		case 0:
+			length, header = int(tag>>2)+1, 1
+			if extra := length - 60; extra > 0 {
+				if s+1+extra > len(src) {
+					return nil, io.ErrUnexpectedEOF
+				}
+				length = 1
+				for i := 0; i < extra; i++ {
+					length += int(src[s+1+i]) << (8 * i)
+				}
+				header += extra
+			}
+			if length < 0 || s+header+length > len(src) {
+				return nil, io.ErrUnexpectedEOF
+			}
+			dst = append(dst, src[s+header:s+header+length]...)
+			s += header + length
+			continue
+		case 1:
+			if s+2 > len(src) {
+				return nil, io.ErrUnexpectedEOF
+			}
+			length, offset, header = 4+int(tag>>2)&7, int(tag&0xe0)<<3|int(src[s+1]), 2
+		case 2:
+			if s+3 > len(src) {
+				return nil, io.ErrUnexpectedEOF
+			}
+			length, offset, header = 1+int(tag>>2), int(binary.LittleEndian.Uint16(src[s+1:])), 3
+		case 3:
+			if s+5 > len(src) {
+				return nil, io.ErrUnexpectedEOF
+			}
+			length, offset, header = 1+int(tag>>2), int(binary.LittleEndian.Uint32(src[s+1:])), 5
+		}
+		if offset <= 0 || offset > len(dst) || len(dst)+length > int(size) {
+			return nil, errors.New("invalid Snappy block")
+		}
+		for i := 0; i < length; i++ {
+			dst = append(dst, dst[len(dst)-offset])
+		}
+		s += header
+	}
+	if len(dst) != int(size) {
+		return nil, errors.New("invalid Snappy block")
+	}
+	return dst, nil
+}
+
+// thriftStruct is struct of Thrift compact protocol by field ids, values are bools, int64s
+// of integers, float64s, []bytes of binaries and strings, []interface{} of lists and sets
+// and thriftStructs, maps are skipped
+type thriftStruct map[int16]interface{}
+
+func thriftStructOf(v interface{}) thriftStruct {
+	s, _ := v.(thriftStruct)
+	return s
+}
+
+func (s thriftStruct) has(id int16) bool {
+	_, ok := s[id]
+	return ok
+}
+
+func (s thriftStruct) int(id int16) int64 {
+	v, _ := s[id].(int64)
+	return v
+}
+
+func (s thriftStruct) bool(id int16, missing bool) bool {
+	if v, ok := s[id].(bool); ok {
+		return v
+	}
+	return missing
+}
+
+func (s thriftStruct) bytes(id int16) []byte {
+	v, _ := s[id].([]byte)
+	return v
+}
+
+func (s thriftStruct) list(id int16) []interface{} {
+	v, _ := s[id].([]interface{})
+	return v
+}
+
+func (s thriftStruct) strct(id int16) thriftStruct {
+	return thriftStructOf(s[id])
+}
+
+// thriftReader reads Thrift compact protocol of Parquet metadata
+type thriftReader struct {
+	buf []byte
+	pos int
+}
+
+// thriftMaxDepth limits nesting of structs and lists
+const thriftMaxDepth = 64
+
+func (r *thriftReader) byte() (byte, error) {
+	if r.pos >= len(r.buf) {
+		return 0, io.ErrUnexpectedEOF
+	}
+	r.pos++
+	return r.buf[r.pos-1], nil
+}
+
+func (r *thriftReader) varint() (uint64, error) {
+	v, n := binary.Uvarint(r.buf[r.pos:])
+	if n <= 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	r.pos += n
+	return v, nil
+}
+
+func (r *thriftReader) zigzag() (int64, error) {
+	v, err := r.varint()
+	return int64(v>>1) ^ -int64(v&1), err
+}
+
+func (r *thriftReader) readStruct(depth int) (thriftStruct, error) {
+	if depth > thriftMaxDepth {
+		return nil, errors.New("too deep Thrift struct")
+	}
+	s := thriftStruct{}
+	var id int16
+	for {
+		header, err := r.byte()
+		if err != nil || header == 0 {
+			return s, err
+		}
+		if delta := header >> 4; delta != 0 {
+			id += int16(delta)
+		} else {
+			v, err := r.zigzag()
+			if err != nil {
+				return nil, err
+			}
+			id = int16(v)
+		}
+		switch typ := header & 0x0f; typ {
+		case 1, 2:
+			s[id] = typ == 1
+		default:
+			if s[id], err = r.readValue(typ, depth); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func (r *thriftReader) readValue(typ byte, depth int) (interface{}, error) {
+	switch typ {
+	case 1, 2: // bools of lists
+		b, err := r.byte()
+		return b == 1, err
+	case 3:
+		b, err := r.byte()
+		return int64(int8(b)), err
+	case 4, 5, 6:
+		return r.zigzag()
+	case 7:
+		if r.pos+8 > len(r.buf) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		r.pos += 8
+		return math.Float64frombits(binary.LittleEndian.Uint64(r.buf[r.pos-8:])), nil
+	case 8:
+		n, err := r.varint()
+		if err != nil {
+			return nil, err
+		}
+		if n > uint64(len(r.buf)-r.pos) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		r.pos += int(n)
+		return r.buf[r.pos-int(n) : r.pos], nil
+	case 9, 10:
+		header, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		n := uint64(header >> 4)
+		if n == 15 {
+			if n, err = r.varint(); err != nil {
+				return nil, err
+			}
+		}
+		if n > uint64(len(r.buf)-r.pos) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		list := make([]interface{}, n)
+		for i := range list {
+			if list[i], err = r.readValue(header&0x0f, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	case 11:
+		n, err := r.varint()
+		if err != nil || n == 0 {
+			return nil, err
+		}
+		if n > uint64(len(r.buf)-r.pos) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		types, err := r.byte()
+		for i := uint64(0); i < n && err == nil; i++ {
+			if _, err = r.readValue(types>>4, depth+1); err == nil {
+				_, err = r.readValue(types&0x0f, depth+1)
+			}
+		}
+		return nil, err
+	case 12:
+		return r.readStruct(depth + 1)
+	}
+	return nil, fmt.Errorf("invalid Thrift type %d", typ)
+}
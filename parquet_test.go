@@ -0,0 +1,420 @@
+package ydb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// thriftField is field of struct written by writeThrift, values are bools, int32s, int64s,
+// strings, thriftLists and []thriftFields of structs
+type thriftField struct {
+	id    int16
+	value interface{}
+}
+
+type thriftList []interface{}
+
+func thriftType(v interface{}) byte {
+	switch v.(type) {
+	case bool:
+		return 1
+	case int32:
+		return 5
+	case int64:
+		return 6
+	case string:
+		return 8
+	case thriftList:
+		return 9
+	}
+	return 12
+}
+
+func writeThriftValue(buf *bytes.Buffer, v interface{}) {
+	var varint [binary.MaxVarintLen64]byte
+	switch v := v.(type) {
+	case bool:
+		if v {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+	case int32:
+		buf.Write(varint[:binary.PutVarint(varint[:], int64(v))])
+	case int64:
+		buf.Write(varint[:binary.PutVarint(varint[:], v)])
+	case string:
+		buf.Write(varint[:binary.PutUvarint(varint[:], uint64(len(v)))])
+		buf.WriteString(v)
+	case thriftList:
+		var typ byte = 12
+		if len(v) > 0 {
+			typ = thriftType(v[0])
+		}
+		if len(v) < 15 {
+			buf.WriteByte(byte(len(v))<<4 | typ)
+		} else {
+			buf.WriteByte(0xf0 | typ)
+			buf.Write(varint[:binary.PutUvarint(varint[:], uint64(len(v)))])
+		}
+		for _, item := range v {
+			writeThriftValue(buf, item)
+		}
+	case []thriftField:
+		writeThrift(buf, v...)
+	}
+}
+
+// writeThrift writes struct of fields in Thrift compact protocol
+func writeThrift(buf *bytes.Buffer, fields ...thriftField) {
+	var last int16
+	for _, field := range fields {
+		typ := thriftType(field.value)
+		if v, ok := field.value.(bool); ok && !v {
+			typ = 2
+		}
+		if delta := field.id - last; delta > 0 && delta <= 15 {
+			buf.WriteByte(byte(delta)<<4 | typ)
+		} else {
+			buf.WriteByte(typ)
+			writeThriftValue(buf, int32(field.id))
+		}
+		last = field.id
+		if typ > 2 {
+			writeThriftValue(buf, field.value)
+		}
+	}
+	buf.WriteByte(0)
+}
+
+// testParquetColumn is column written by writeTestParquet, converted is ConvertedType or -1
+type testParquetColumn struct {
+	name       string
+	typ        int32
+	optional   bool
+	converted  int32
+	scale      int32
+	logical    []thriftField
+	dictionary bool
+	v2         bool
+	codec      int32
+}
+
+// encodeSnappy encodes data as Snappy block of literals
+func encodeSnappy(data []byte) []byte {
+	var varint [binary.MaxVarintLen64]byte
+	block := append([]byte(nil), varint[:binary.PutUvarint(varint[:], uint64(len(data)))]...)
+	for len(data) > 0 {
+		n := len(data)
+		if n > 256 {
+			n = 256
+		}
+		if n <= 60 {
+			block = append(block, byte(n-1)<<2)
+		} else {
+			block = append(block, 60<<2, byte(n-1))
+		}
+		block, data = append(block, data[:n]...), data[n:]
+	}
+	return block
+}
+
+func compressTestPage(t *testing.T, codec int32, data []byte) []byte {
+	switch codec {
+	case 1:
+		return encodeSnappy(data)
+	case 2:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	return data
+}
+
+// encodeBitPacked encodes values of width as single bit-packed run of hybrid encoding
+func encodeBitPacked(values []uint32, width int) []byte {
+	groups := (len(values) + 7) / 8
+	var varint [binary.MaxVarintLen64]byte
+	run := append([]byte(nil), varint[:binary.PutUvarint(varint[:], uint64(groups<<1|1))]...)
+	packed := make([]byte, groups*width)
+	for i, v := range values {
+		for b := 0; b < width; b++ {
+			bit := i*width + b
+			packed[bit/8] |= byte(v>>b&1) << (bit % 8)
+		}
+	}
+	return append(run, packed...)
+}
+
+func encodeTestPlain(values []interface{}) []byte {
+	var buf bytes.Buffer
+	var bits []uint32
+	for _, v := range values {
+		switch v := v.(type) {
+		case bool:
+			if v {
+				bits = append(bits, 1)
+			} else {
+				bits = append(bits, 0)
+			}
+		case string:
+			binary.Write(&buf, binary.LittleEndian, uint32(len(v)))
+			buf.WriteString(v)
+		default:
+			binary.Write(&buf, binary.LittleEndian, v)
+		}
+	}
+	if bits != nil {
+		// bit-packed values without header of run
+		return encodeBitPacked(bits, 1)[1:]
+	}
+	return buf.Bytes()
+}
+
+// writeTestParquet writes Parquet file of row groups of rows of columns
+func writeTestParquet(t *testing.T, columns []testParquetColumn, rowGroups ...[][]interface{}) []byte {
+	var file bytes.Buffer
+	file.WriteString(parquetMagic)
+	var groups thriftList
+	for _, rows := range rowGroups {
+		var chunks thriftList
+		for i, column := range columns {
+			var (
+				defined     []interface{}
+				definitions []uint32
+			)
+			for _, row := range rows {
+				if row[i] != nil {
+					defined = append(defined, row[i])
+					definitions = append(definitions, 1)
+				} else {
+					definitions = append(definitions, 0)
+				}
+			}
+
+			start := int64(file.Len())
+			var dictionaryOffset int64
+			encoding, data := int32(0), encodeTestPlain(defined)
+			if column.dictionary {
+				var (
+					dictionary []interface{}
+					indices    []uint32
+				)
+				for _, v := range defined {
+					index := len(dictionary)
+					for j, d := range dictionary {
+						if d == v {
+							index = j
+						}
+					}
+					if index == len(dictionary) {
+						dictionary = append(dictionary, v)
+					}
+					indices = append(indices, uint32(index))
+				}
+				plain := encodeTestPlain(dictionary)
+				page := compressTestPage(t, column.codec, plain)
+				writeThrift(&file,
+					thriftField{1, int32(parquetDictionaryPage)},
+					thriftField{2, int32(len(plain))},
+					thriftField{3, int32(len(page))},
+					thriftField{7, []thriftField{{1, int32(len(dictionary))}, {2, int32(0)}}},
+				)
+				file.Write(page)
+				dictionaryOffset = start
+				encoding, data = 8, append([]byte{2}, encodeBitPacked(indices, 2)...)
+			}
+
+			dataOffset := int64(file.Len())
+			var levels []byte
+			if column.optional {
+				levels = encodeBitPacked(definitions, 1)
+			}
+			if column.v2 {
+				page := append(append([]byte(nil), levels...), compressTestPage(t, column.codec, data)...)
+				writeThrift(&file,
+					thriftField{1, int32(parquetDataPageV2)},
+					thriftField{2, int32(len(levels) + len(data))},
+					thriftField{3, int32(len(page))},
+					thriftField{8, []thriftField{
+						{1, int32(len(rows))}, {2, int32(len(rows) - len(defined))}, {3, int32(len(rows))},
+						{4, encoding}, {5, int32(len(levels))}, {6, int32(0)},
+					}},
+				)
+				file.Write(page)
+			} else {
+				var body []byte
+				if column.optional {
+					body = make([]byte, 4)
+					binary.LittleEndian.PutUint32(body, uint32(len(levels)))
+					body = append(body, levels...)
+				}
+				body = append(body, data...)
+				page := compressTestPage(t, column.codec, body)
+				writeThrift(&file,
+					thriftField{1, int32(parquetDataPage)},
+					thriftField{2, int32(len(body))},
+					thriftField{3, int32(len(page))},
+					thriftField{5, []thriftField{{1, int32(len(rows))}, {2, encoding}, {3, int32(3)}, {4, int32(3)}}},
+				)
+				file.Write(page)
+			}
+
+			meta := []thriftField{
+				{1, column.typ},
+				{2, thriftList{int32(0), int32(3), int32(8)}},
+				{3, thriftList{column.name}},
+				{4, column.codec},
+				{5, int64(len(rows))},
+				{6, int64(file.Len()) - start},
+				{7, int64(file.Len()) - start},
+				{9, dataOffset},
+			}
+			if column.dictionary {
+				meta = append(meta, thriftField{11, dictionaryOffset})
+			}
+			chunks = append(chunks, []thriftField{{2, start}, {3, meta}})
+		}
+		groups = append(groups, []thriftField{{1, chunks}, {2, int64(0)}, {3, int64(len(rows))}})
+	}
+
+	schema := thriftList{[]thriftField{{4, "schema"}, {5, int32(len(columns))}}}
+	for _, column := range columns {
+		element := []thriftField{{1, column.typ}}
+		if column.optional {
+			element = append(element, thriftField{3, int32(1)})
+		} else {
+			element = append(element, thriftField{3, int32(0)})
+		}
+		element = append(element, thriftField{4, column.name})
+		if column.converted >= 0 {
+			element = append(element, thriftField{6, column.converted}, thriftField{7, column.scale})
+		}
+		if column.logical != nil {
+			element = append(element, thriftField{10, column.logical})
+		}
+		schema = append(schema, element)
+	}
+
+	footer := file.Len()
+	writeThrift(&file,
+		thriftField{1, int32(1)},
+		thriftField{2, schema},
+		thriftField{3, int64(0)},
+		thriftField{4, groups},
+		thriftField{5, thriftList{[]thriftField{{1, "writer"}, {2, "test"}}}},
+	)
+	binary.Write(&file, binary.LittleEndian, uint32(file.Len()-footer))
+	file.WriteString(parquetMagic)
+	return file.Bytes()
+}
+
+// onlyReader hides methods of reader other than Read
+type onlyReader struct {
+	io.Reader
+}
+
+func TestImportParquet(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 30, 0, 123456000, time.UTC)
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	days := int32(day.Unix() / (24 * 60 * 60))
+	columns := []testParquetColumn{
+		{name: "id", typ: parquetInt64, converted: -1},
+		{name: "count", typ: parquetInt32, converted: 13},
+		{name: "name", typ: parquetByteArray, optional: true, converted: -1, logical: []thriftField{{1, []thriftField{}}},
+			dictionary: true, codec: 1},
+		{name: "at", typ: parquetInt64, optional: true, converted: -1, logical: []thriftField{{8, []thriftField{
+			{1, true}, {2, []thriftField{{2, []thriftField{}}}},
+		}}}, v2: true, codec: 2},
+		{name: "price", typ: parquetInt32, converted: 5, scale: 2},
+		{name: "day", typ: parquetInt32, converted: 6},
+		{name: "flag", typ: parquetBoolean, optional: true, converted: -1},
+		{name: "ratio", typ: parquetDouble, converted: -1, v2: true},
+		{name: "data", typ: parquetByteArray, converted: -1},
+	}
+	micros := at.UnixNano() / 1e3
+	file := writeTestParquet(t, columns,
+		[][]interface{}{
+			{int64(1), int32(-1), "a", micros, int32(1234), days, true, 0.5, "\x00\x01"},
+			{int64(2), int32(2), nil, nil, int32(-5), int32(0), nil, 1.5, ""},
+		},
+		[][]interface{}{
+			{int64(3), int32(3), "a", micros + 1e6, int32(100), int32(1), false, -2.0, "x"},
+			{int64(4), int32(4), "b", nil, int32(0), int32(1), true, 0.0, "y"},
+		},
+	)
+	want := [][]interface{}{
+		{int64(1), uint32(1<<32 - 1), "a", at, "12.34", day, true, 0.5, []byte{0, 1}},
+		{int64(2), uint32(2), nil, nil, "-0.05", time.Unix(0, 0).UTC(), nil, 1.5, []byte{}},
+		{int64(3), uint32(3), "a", at.Add(time.Second), "1.00", time.Unix(24*60*60, 0).UTC(), false, -2.0, []byte("x")},
+		{int64(4), uint32(4), "b", nil, "0.00", time.Unix(24*60*60, 0).UTC(), true, 0.0, []byte("y")},
+	}
+
+	for _, r := range []io.Reader{bytes.NewReader(file), onlyReader{bytes.NewReader(file)}} {
+		decoder, err := ImportParquet(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantColumns := []string{"id", "count", "name", "at", "price", "day", "flag", "ratio", "data"}
+		if got := decoder.Columns(); !reflect.DeepEqual(got, wantColumns) {
+			t.Errorf("Columns() = %v, want %v", got, wantColumns)
+		}
+		var got [][]interface{}
+		for {
+			row, err := decoder.Decode()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, row)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Decode() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestImportParquetInvalid(t *testing.T) {
+	if _, err := ImportParquet(bytes.NewReader([]byte("id,name\n1,a\n"))); err != errNotParquet {
+		t.Errorf("ImportParquet(CSV) error = %v, want %v", err, errNotParquet)
+	}
+
+	file := writeTestParquet(t, []testParquetColumn{{name: "id", typ: parquetInt64, converted: -1, codec: 6}},
+		[][]interface{}{{int64(1)}})
+	decoder, err := ImportParquet(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decoder.Decode(); err == nil {
+		t.Error("column compressed with ZSTD is decoded")
+	}
+}
+
+func TestDecodeSnappy(t *testing.T) {
+	// literal "abc", copy of 6 bytes at offset 3 with 1 byte offset, copy of 2 bytes at
+	// offset 4 with 2 bytes offset
+	block := []byte{11, 2 << 2, 'a', 'b', 'c', (6-4)<<2 | 1, 3, (2-1)<<2 | 2, 4, 0}
+	got, err := decodeSnappy(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "abcabcabcca" {
+		t.Errorf("decodeSnappy() = %q, want %q", got, "abcabcabcca")
+	}
+	if _, err := decodeSnappy([]byte{5, 0 << 2, 'a', 1, 5}); err == nil {
+		t.Error("copy at offset beyond decoded bytes is decoded")
+	}
+}
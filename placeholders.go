@@ -0,0 +1,130 @@
+package ydb
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+)
+
+// Statements are built with positional ? placeholders, so subqueries and conditions of other
+// *gorm.DB are embedded by gorm without renumbering. Before execution connector replaces them
+// with unique named parameters $p1, $p2... and declares types of parameters
+
+const positionalPrefix = "p"
+
+// positionalName returns parameter name of positional argument
+func positionalName(ordinal int) string {
+	return positionalPrefix + strconv.Itoa(ordinal)
+}
+
+// bindParams replaces ? placeholders of query outside of literals, quoted identifiers and comments
+// with names of args and prepends DECLARE of args not declared by query
+func bindParams(query string, args []driver.NamedValue) (string, error) {
+	if len(args) == 0 {
+		return query, nil
+	}
+	for _, arg := range args {
+		// parameters named by caller (table.ParameterOption, *table.QueryParameters)
+		if arg.Name == "" {
+			return declareParams(query, args), nil
+		}
+	}
+
+	var (
+		sb        strings.Builder
+		n         int
+		quote     byte
+		inComment byte // '-' for line comment, '*' for block comment
+	)
+	sb.Grow(len(query) + len(args)*4)
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case inComment == '-':
+			if c == '\n' {
+				inComment = 0
+			}
+		case inComment == '*':
+			if c == '*' && i+1 < len(query) && query[i+1] == '/' {
+				sb.WriteByte(c)
+				i++
+				c = query[i]
+				inComment = 0
+			}
+		case quote != 0:
+			if c == '\\' && quote != '`' && i+1 < len(query) {
+				sb.WriteByte(c)
+				i++
+				c = query[i]
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '-' && i+1 < len(query) && query[i+1] == '-':
+			inComment = '-'
+		case c == '/' && i+1 < len(query) && query[i+1] == '*':
+			inComment = '*'
+		case c == '?' && isPlaceholder(query, i):
+			if n >= len(args) {
+				return "", fmt.Errorf("ydb: query has more placeholders than %d args", len(args))
+			}
+			sb.WriteByte('$')
+			sb.WriteString(args[n].Name)
+			n++
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	if n > 0 && n != len(args) {
+		return "", fmt.Errorf("ydb: query has %d placeholders, but %d args given", n, len(args))
+	}
+	return declareParams(sb.String(), args), nil
+}
+
+// isPlaceholder reports whether ? at i of query is placeholder, not part of ?? operator or of
+// optional type like Int32? following type name
+func isPlaceholder(query string, i int) bool {
+	if i+1 < len(query) && query[i+1] == '?' || i > 0 && query[i-1] == '?' {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+	c := query[i-1]
+	return !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9')
+}
+
+// declareParams prepends DECLARE of args not declared by query
+func declareParams(query string, args []driver.NamedValue) string {
+	var declares strings.Builder
+	declare := func(name string, v types.Value) {
+		name = "$" + strings.TrimPrefix(name, "$")
+		if strings.Contains(query, "DECLARE "+name+" ") {
+			return
+		}
+		declares.WriteString("DECLARE ")
+		declares.WriteString(name)
+		declares.WriteString(" AS ")
+		declares.WriteString(v.Type().Yql())
+		declares.WriteString(";\n")
+	}
+	for _, arg := range args {
+		switch v := arg.Value.(type) {
+		case types.Value:
+			declare(arg.Name, v)
+		case table.ParameterOption:
+			declare(v.Name(), v.Value())
+		case *table.QueryParameters:
+			v.Each(declare)
+		}
+	}
+	if declares.Len() == 0 {
+		return query
+	}
+	return declares.String() + query
+}
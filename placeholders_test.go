@@ -0,0 +1,72 @@
+package ydb
+
+import (
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+)
+
+func TestBindParams(t *testing.T) {
+	args := []driver.NamedValue{
+		{Name: positionalName(1), Ordinal: 1, Value: types.Int32Value(1)},
+		{Name: positionalName(2), Ordinal: 2, Value: types.UTF8Value("a")},
+	}
+	for _, tt := range []struct {
+		query string
+		want  string
+	}{
+		{
+			"SELECT * FROM `t` WHERE id = ? AND name = ?",
+			"SELECT * FROM `t` WHERE id = $p1 AND name = $p2",
+		},
+		{
+			"SELECT id ?? ? FROM t WHERE name = ?",
+			"SELECT id ?? $p1 FROM t WHERE name = $p2",
+		},
+		{
+			"SELECT CAST(? AS Int32?), ?",
+			"SELECT CAST($p1 AS Int32?), $p2",
+		},
+		{
+			"DECLARE $x AS Utf8?; SELECT $x, '?' /* ? */ -- ?\n, ?, `?`, ?",
+			"DECLARE $x AS Utf8?; SELECT $x, '?' /* ? */ -- ?\n, $p1, `?`, $p2",
+		},
+	} {
+		got, err := bindParams(tt.query, args)
+		if err != nil {
+			t.Errorf("bindParams(%q): %v", tt.query, err)
+			continue
+		}
+		want := "DECLARE $p1 AS Int32;\nDECLARE $p2 AS Utf8;\n" + tt.want
+		if got != want {
+			t.Errorf("bindParams(%q):\n got %q\nwant %q", tt.query, got, want)
+		}
+	}
+
+	if _, err := bindParams("SELECT ?", args); err == nil {
+		t.Error("bindParams of less placeholders than args: no error")
+	}
+	if _, err := bindParams("SELECT ?, ?, ?", args); err == nil {
+		t.Error("bindParams of more placeholders than args: no error")
+	}
+}
+
+func TestExplain(t *testing.T) {
+	dialector := Dialector{Config: &Config{}}
+	for _, tt := range []struct {
+		sql  string
+		vars []interface{}
+		want string
+	}{
+		{"SELECT * FROM t WHERE id = ? AND name = ?", []interface{}{1, "a"}, "SELECT * FROM t WHERE id = 1 AND name = 'a'"},
+		{"SELECT id ?? ? FROM t WHERE name = ?", []interface{}{0, "a"}, "SELECT id ?? 0 FROM t WHERE name = 'a'"},
+		{"SELECT CAST(? AS Int32?)", []interface{}{1}, "SELECT CAST(1 AS Int32?)"},
+		{"SELECT ? + ?", []interface{}{time.Second, "?"}, "SELECT Interval(\"PT1.000000S\") + '?'"},
+	} {
+		if got := dialector.Explain(tt.sql, tt.vars...); got != tt.want {
+			t.Errorf("Explain(%q):\n got %s\nwant %s", tt.sql, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package ydb
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"gorm.io/gorm"
+)
+
+// RateLimit configures client side limiter of statements executed through gorm
+type RateLimit struct {
+	// QPS max rate of statements, zero disables rate limiting
+	QPS float64
+	// Burst max count of statements executed above QPS rate, defaults to 1
+	Burst int
+	// MaxInFlight max count of concurrently executed statements, zero disables the cap
+	MaxInFlight int
+	// PerTable applies limits to statements of every table separately instead of globally
+	PerTable bool
+	// Adaptive halves rate on overload errors of YDB and restores it gradually on successes
+	Adaptive bool
+}
+
+type rateLimiter struct {
+	config RateLimit
+
+	mu       sync.Mutex
+	limiters map[string]*limiter
+}
+
+const (
+	rateLimitKey        = "ydb:rate_limit"
+	rateLimitReleaseKey = "ydb:rate_limit_release"
+)
+
+func (config RateLimit) registerCallbacks(db *gorm.DB) error {
+	r := &rateLimiter{config: config, limiters: map[string]*limiter{}}
+	callback := db.Callback()
+	if err := callback.Create().Before("gorm:create").Register(rateLimitKey, r.acquire); err != nil {
+		return err
+	}
+	if err := callback.Create().After("gorm:create").Register(rateLimitReleaseKey, r.release); err != nil {
+		return err
+	}
+	if err := callback.Query().Before("gorm:query").Register(rateLimitKey, r.acquire); err != nil {
+		return err
+	}
+	if err := callback.Query().After("gorm:query").Register(rateLimitReleaseKey, r.release); err != nil {
+		return err
+	}
+	if err := callback.Update().Before("gorm:update").Register(rateLimitKey, r.acquire); err != nil {
+		return err
+	}
+	if err := callback.Update().After("gorm:update").Register(rateLimitReleaseKey, r.release); err != nil {
+		return err
+	}
+	if err := callback.Delete().Before("gorm:delete").Register(rateLimitKey, r.acquire); err != nil {
+		return err
+	}
+	if err := callback.Delete().After("gorm:delete").Register(rateLimitReleaseKey, r.release); err != nil {
+		return err
+	}
+	if err := callback.Row().Before("gorm:row").Register(rateLimitKey, r.acquire); err != nil {
+		return err
+	}
+	if err := callback.Row().After("gorm:row").Register(rateLimitReleaseKey, r.release); err != nil {
+		return err
+	}
+	if err := callback.Raw().Before("gorm:raw").Register(rateLimitKey, r.acquire); err != nil {
+		return err
+	}
+	return callback.Raw().After("gorm:raw").Register(rateLimitReleaseKey, r.release)
+}
+
+func (r *rateLimiter) limiter(table string) *limiter {
+	if !r.config.PerTable {
+		table = ""
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	l, ok := r.limiters[table]
+	if !ok {
+		l = newLimiter(r.config)
+		r.limiters[table] = l
+	}
+	return l
+}
+
+// rateLimitSlotKey marks context of statement holding slot of limiter, statements executed by
+// callbacks of the statement with its context (e.g. by plugins) don't acquire own slots, otherwise
+// they could wait for slot held by the statement forever
+type rateLimitSlotKey struct{}
+
+// rateLimitSlot slot of limiter held by statement, released slot doesn't mark context anymore
+type rateLimitSlot struct {
+	limiter  *limiter
+	released int32
+}
+
+func (r *rateLimiter) acquire(db *gorm.DB) {
+	if db.Error != nil {
+		return
+	}
+	if slot, ok := db.Statement.Context.Value(rateLimitSlotKey{}).(*rateLimitSlot); ok &&
+		atomic.LoadInt32(&slot.released) == 0 {
+		return
+	}
+	l := r.limiter(db.Statement.Table)
+	if db.AddError(l.wait(db.Statement.Context)) == nil {
+		slot := &rateLimitSlot{limiter: l}
+		db.InstanceSet(rateLimitKey, slot)
+		db.Statement.Context = context.WithValue(db.Statement.Context, rateLimitSlotKey{}, slot)
+	}
+}
+
+func (r *rateLimiter) release(db *gorm.DB) {
+	if v, ok := db.InstanceGet(rateLimitKey); ok {
+		db.Statement.Settings.Delete(fmt.Sprintf("%p", db.Statement) + rateLimitKey)
+		slot := v.(*rateLimitSlot)
+		atomic.StoreInt32(&slot.released, 1)
+		slot.limiter.done(db.Error)
+	}
+}
+
+// limiter is token bucket with optional in flight cap
+type limiter struct {
+	config RateLimit
+
+	mu       sync.Mutex
+	rate     float64
+	tokens   float64
+	last     time.Time
+	inFlight chan struct{}
+}
+
+func newLimiter(config RateLimit) *limiter {
+	if config.Burst <= 0 {
+		config.Burst = 1
+	}
+	l := &limiter{config: config, rate: config.QPS, tokens: float64(config.Burst), last: time.Now()}
+	if config.MaxInFlight > 0 {
+		l.inFlight = make(chan struct{}, config.MaxInFlight)
+	}
+	return l
+}
+
+func (l *limiter) wait(ctx context.Context) error {
+	for l.config.QPS > 0 {
+		l.mu.Lock()
+		now := time.Now()
+		l.tokens = math.Min(float64(l.config.Burst), l.tokens+now.Sub(l.last).Seconds()*l.rate)
+		l.last = now
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			break
+		}
+		delay := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	if l.inFlight != nil {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case l.inFlight <- struct{}{}:
+		}
+	}
+	return nil
+}
+
+func (l *limiter) done(err error) {
+	if l.inFlight != nil {
+		<-l.inFlight
+	}
+	if !l.config.Adaptive || l.config.QPS <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if ydb.IsOperationErrorOverloaded(err) || ydb.IsRatelimiterAcquireError(err) {
+		l.rate = math.Max(l.rate/2, l.config.QPS/100)
+	} else if err == nil {
+		l.rate = math.Min(l.rate+l.config.QPS/100, l.config.QPS)
+	}
+}
@@ -0,0 +1,31 @@
+package ydb
+
+import (
+	"context"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+	"gorm.io/gorm"
+)
+
+// Param returns typed parameter of raw YQL query, name may be given with or without $
+func Param(name string, value types.Value) table.ParameterOption {
+	return table.ValueParam(name, value)
+}
+
+// Raw returns gorm.DB executing hand-written YQL with typed parameters, DECLARE of parameters
+// not declared by query is generated from their values. Query is passed as is, so it may use
+// any YQL syntax, result is scanned with Scan, Find, Rows and other gorm finishers
+//
+//	var users []User
+//	ydb.Raw(ctx, db, "SELECT * FROM users WHERE id IN $ids",
+//		ydb.Param("$ids", types.ListValue(types.Uint64Value(1), types.Uint64Value(2))),
+//	).Find(&users)
+func Raw(ctx context.Context, db *gorm.DB, query string, params ...table.ParameterOption) *gorm.DB {
+	tx := db.WithContext(ctx).Raw("")
+	tx.Statement.SQL.WriteString(query)
+	for _, param := range params {
+		tx.Statement.Vars = append(tx.Statement.Vars, param)
+	}
+	return tx
+}
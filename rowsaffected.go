@@ -0,0 +1,99 @@
+package ydb
+
+import (
+	"reflect"
+
+	"gorm.io/gorm"
+)
+
+// RowsAffectedMode defines how RowsAffected of write statements is computed
+type RowsAffectedMode int
+
+const (
+	// RowsAffectedApproximate reports affected rows of the driver, creates report count of inserted rows
+	RowsAffectedApproximate RowsAffectedMode = iota
+	// RowsAffectedExact counts rows matching conditions of UPDATE and DELETE statements before write,
+	// the count is exact inside transactions and costs an extra query per statement
+	RowsAffectedExact
+)
+
+func (mode RowsAffectedMode) registerCallbacks(db *gorm.DB) error {
+	callback := db.Callback()
+	if err := callback.Create().After("gorm:create").Register("ydb:rows_affected", insertedRows); err != nil {
+		return err
+	}
+	if update := callback.Update().Get("gorm:update"); update != nil {
+		if err := callback.Update().Replace("gorm:update", mode.countConditional(update)); err != nil {
+			return err
+		}
+	}
+	if mode != RowsAffectedExact {
+		return nil
+	}
+	if del := callback.Delete().Get("gorm:delete"); del != nil {
+		if err := callback.Delete().Replace("gorm:delete", countAffected(del)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertedRows sets RowsAffected of create to count of inserted rows when driver doesn't report it,
+// INSERT fails on existing keys, so every row is written
+func insertedRows(db *gorm.DB) {
+	if db.Error != nil || db.DryRun || db.RowsAffected != 0 || Replayed(db) {
+		return
+	}
+	if _, ok := db.Statement.Clauses["RETURNING"]; ok {
+		return
+	}
+	switch rv := db.Statement.ReflectValue; rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		db.RowsAffected = int64(rv.Len())
+	case reflect.Struct:
+		db.RowsAffected = 1
+	}
+}
+
+// countAffected counts rows matching WHERE of statement built by write in dry run mode
+// and executes write reporting the count as RowsAffected
+func countAffected(write func(*gorm.DB)) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Error != nil || db.DryRun || db.Statement.SQL.Len() > 0 {
+			write(db)
+			return
+		}
+
+		dry := dryRun(db, write)
+		if dry == nil {
+			write(db)
+			return
+		}
+
+		var count int64
+		tx := db.Session(&gorm.Session{NewDB: true}).Table(db.Statement.Table)
+		if where, ok := dry.Statement.Clauses["WHERE"]; ok {
+			tx = tx.Clauses(where.Expression)
+		}
+		if err := tx.Count(&count).Error; err != nil {
+			db.AddError(err)
+			return
+		}
+
+		write(db)
+		if db.Error == nil {
+			db.RowsAffected = count
+		}
+	}
+}
+
+// dryRun builds statement of write in dry run mode, so its clauses complemented by write
+// (e.g. primary key conditions of model) can be inspected, returns nil if nothing is built
+func dryRun(db *gorm.DB, write func(*gorm.DB)) *gorm.DB {
+	dry := db.Session(&gorm.Session{DryRun: true, Context: db.Statement.Context})
+	write(dry)
+	if dry.Error != nil || dry.Statement.SQL.Len() == 0 {
+		return nil
+	}
+	return dry
+}
@@ -0,0 +1,27 @@
+package ydb
+
+import (
+	"context"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"gorm.io/gorm"
+)
+
+// ExecuteScript executes multi-statement YQL script (DDL and DML statements may be mixed)
+// with scripting service, result sets of script are streamed, rows of current result set are
+// iterated with Rows.Next and result sets are switched with Rows.NextResultSet
+//
+//	rows, err := ydb.ExecuteScript(ctx, db, `
+//		UPSERT INTO users (id, name) VALUES (1, "alice");
+//		SELECT * FROM users;
+//		SELECT COUNT(*) AS cnt FROM orders;
+//	`)
+func ExecuteScript(ctx context.Context, db *gorm.DB, script string, params ...table.ParameterOption) (*Rows, error) {
+	tx := Raw(ydb.WithQueryMode(ctx, ydb.ScriptingQueryMode), db, script, params...)
+	rows, err := tx.Rows()
+	if err != nil {
+		return nil, err
+	}
+	return &Rows{db: tx, rows: rows}, nil
+}
@@ -0,0 +1,245 @@
+package ydb
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// SearchColumn returns name of shadow column of case-insensitive search of column
+func SearchColumn(column string) string {
+	return column + "_search"
+}
+
+// NormalizeSearch returns lowercased s without diacritical marks, value of shadow column of s
+func NormalizeSearch(s string) string {
+	var sb strings.Builder
+	sb.Grow(len(s))
+	for _, r := range norm.NFD.String(s) {
+		if !unicode.Is(unicode.Mn, r) {
+			sb.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return sb.String()
+}
+
+// Search is a gorm plugin of case-insensitive search, YDB indexes can't serve ILIKE or LOWER
+// conditions, so string fields tagged with search have shadow columns (see SearchColumn) of
+// normalized values (see NormalizeSearch) written by creates and updates, and conditions
+// `LOWER(column) = ?`, `LOWER(column) LIKE ?` and `column ILIKE ?` of queries are rewritten to
+// conditions of shadow columns indexed by async indexes created by Migrate
+//
+//	type User struct {
+//		ID    uint64
+//		Email string `gorm:"search"`
+//	}
+//
+//	search := &ydb.Search{}
+//	db.Use(search)
+//	search.Migrate(ctx, db, &User{})
+//	db.Where("email ILIKE ?", "John.Doe@example.com").Find(&users)
+type Search struct{}
+
+func (s *Search) Name() string {
+	return "ydb:search"
+}
+
+func (s *Search) Initialize(db *gorm.DB) error {
+	db.ClauseBuilders["VALUES"] = buildSearchValues
+	db.ClauseBuilders["SET"] = buildSearchSet
+	callback := db.Callback()
+	if err := callback.Query().Before("gorm:query").Register("ydb:search", rewriteSearch); err != nil {
+		return err
+	}
+	if err := callback.Row().Before("gorm:row").Register("ydb:search", rewriteSearch); err != nil {
+		return err
+	}
+	if err := callback.Update().Before("gorm:update").Register("ydb:search", rewriteSearch); err != nil {
+		return err
+	}
+	return callback.Delete().Before("gorm:delete").Register("ydb:search", rewriteSearch)
+}
+
+var searchFieldsCache sync.Map // *schema.Schema -> map[string]*schema.Field
+
+// searchFields returns fields tagged with search by column
+func searchFields(s *schema.Schema) map[string]*schema.Field {
+	if s == nil {
+		return nil
+	}
+	if v, ok := searchFieldsCache.Load(s); ok {
+		return v.(map[string]*schema.Field)
+	}
+	fields := map[string]*schema.Field{}
+	for _, field := range s.Fields {
+		if _, ok := field.TagSettings["SEARCH"]; ok && field.DBName != "" {
+			fields[field.DBName] = field
+		}
+	}
+	searchFieldsCache.Store(s, fields)
+	return fields
+}
+
+// searchValue returns normalized value of search column, nil for NULL
+func searchValue(v interface{}) (interface{}, error) {
+	switch v := unwrapSensitive(v).(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return NormalizeSearch(v), nil
+	case *string:
+		if v == nil {
+			return nil, nil
+		}
+		return NormalizeSearch(*v), nil
+	case []byte:
+		return NormalizeSearch(string(v)), nil
+	}
+	return nil, fmt.Errorf("ydb: search column value of type %T is not a string", v)
+}
+
+// buildSearchValues adds shadow columns of search columns to values of creates
+func buildSearchValues(c clause.Clause, builder clause.Builder) {
+	stmt, ok := builder.(*gorm.Statement)
+	values, isValues := c.Expression.(clause.Values)
+	if !ok || !isValues || len(searchFields(stmt.Schema)) == 0 {
+		c.Build(builder)
+		return
+	}
+	fields := searchFields(stmt.Schema)
+	extended := clause.Values{Columns: append([]clause.Column{}, values.Columns...), Values: make([][]interface{}, len(values.Values))}
+	for i := range values.Values {
+		extended.Values[i] = append([]interface{}{}, values.Values[i]...)
+	}
+	for idx, column := range values.Columns {
+		if _, ok := fields[column.Name]; !ok {
+			continue
+		}
+		extended.Columns = append(extended.Columns, clause.Column{Name: SearchColumn(column.Name)})
+		for i, row := range values.Values {
+			v, err := searchValue(row[idx])
+			if err != nil {
+				stmt.DB.AddError(err)
+			}
+			extended.Values[i] = append(extended.Values[i], v)
+		}
+	}
+	c.Expression = extended
+	c.Build(builder)
+}
+
+// buildSearchSet adds assignments of shadow columns to assignments of search columns of updates
+func buildSearchSet(c clause.Clause, builder clause.Builder) {
+	stmt, ok := builder.(*gorm.Statement)
+	set, isSet := c.Expression.(clause.Set)
+	if !ok || !isSet || len(searchFields(stmt.Schema)) == 0 {
+		c.Build(builder)
+		return
+	}
+	fields := searchFields(stmt.Schema)
+	extended := append(clause.Set{}, set...)
+	for _, assignment := range set {
+		if _, ok := fields[assignment.Column.Name]; !ok {
+			continue
+		}
+		v, err := searchValue(assignment.Value)
+		if err != nil {
+			stmt.DB.AddError(err)
+		}
+		extended = append(extended, clause.Assignment{Column: clause.Column{Name: SearchColumn(assignment.Column.Name)}, Value: v})
+	}
+	c.Expression = extended
+	c.Build(builder)
+}
+
+var (
+	lowerCondition = regexp.MustCompile("^\\s*(?i:lower)\\(\\s*`?(\\w+)`?\\s*\\)\\s*(=|(?i:like))\\s*\\?\\s*$")
+	ilikeCondition = regexp.MustCompile("^\\s*`?(\\w+)`?\\s+(?i:ilike)\\s+\\?\\s*$")
+)
+
+// rewriteSearch replaces case-insensitive conditions of search columns by conditions of their
+// shadow columns
+func rewriteSearch(db *gorm.DB) {
+	fields := searchFields(db.Statement.Schema)
+	where, ok := db.Statement.Clauses["WHERE"].Expression.(clause.Where)
+	if db.Error != nil || len(fields) == 0 || !ok {
+		return
+	}
+	exprs := make([]clause.Expression, len(where.Exprs))
+	for i, expr := range where.Exprs {
+		exprs[i] = expr
+		e, ok := expr.(clause.Expr)
+		if !ok || len(e.Vars) != 1 {
+			continue
+		}
+		var column, operator string
+		if m := lowerCondition.FindStringSubmatch(e.SQL); m != nil {
+			column, operator = m[1], strings.ToUpper(m[2])
+		} else if m := ilikeCondition.FindStringSubmatch(e.SQL); m != nil {
+			column, operator = m[1], "LIKE"
+		}
+		if _, ok := fields[column]; !ok {
+			continue
+		}
+		v, err := searchValue(e.Vars[0])
+		if err != nil {
+			continue
+		}
+		exprs[i] = clause.Expr{SQL: "? " + operator + " ?", Vars: []interface{}{clause.Column{Name: SearchColumn(column)}, v}}
+	}
+	where.Exprs = exprs
+	c := db.Statement.Clauses["WHERE"]
+	c.Expression = where
+	db.Statement.Clauses["WHERE"] = c
+}
+
+// Migrate adds missing shadow columns of search columns of models and async indexes on them,
+// existing rows are not backfilled, update them (e.g. by Save) to fill shadow columns
+func (s *Search) Migrate(ctx context.Context, db *gorm.DB, models ...interface{}) error {
+	m, ok := db.WithContext(ctx).Migrator().(Migrator)
+	if !ok {
+		return ErrNativeDriverUnavailable
+	}
+	for _, model := range models {
+		err := m.RunWithValue(model, func(stmt *gorm.Statement) error {
+			desc, err := m.describeTable(stmt.Table)
+			if err != nil {
+				return err
+			}
+			columns, indexes := map[string]bool{}, map[string]bool{}
+			for _, column := range desc.Columns {
+				columns[column.Name] = true
+			}
+			for _, index := range desc.Indexes {
+				indexes[index.Name] = true
+			}
+			for column := range searchFields(stmt.Schema) {
+				shadow := SearchColumn(column)
+				if !columns[shadow] {
+					if err = m.execScheme("ALTER TABLE ? ADD COLUMN ? Utf8", m.CurrentTable(stmt), clause.Column{Name: shadow}); err != nil {
+						return err
+					}
+				}
+				if index := "idx_" + stmt.Table + "_" + shadow; !indexes[index] {
+					if err = m.execScheme("ALTER TABLE ? ADD INDEX ? GLOBAL ASYNC ON (?)",
+						m.CurrentTable(stmt), clause.Column{Name: index}, clause.Column{Name: shadow}); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package ydb
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+
+	"gorm.io/gorm/schema"
+)
+
+// maskedValue is written instead of sensitive values by Explain and fmt
+const maskedValue = "***"
+
+// Sensitive marks value of parameter as sensitive, so Explain (and logger of gorm using it)
+// and fmt render it masked, value is sent to database as is
+//
+//	db.Where("email = ?", ydb.Sensitive(email)).First(&user)
+//
+// Values of fields tagged with sensitive are marked automatically
+//
+//	type User struct {
+//		ID    uint64
+//		Email string `gorm:"sensitive"`
+//	}
+func Sensitive(v interface{}) interface{} {
+	return sensitive{value: v}
+}
+
+type sensitive struct {
+	value interface{}
+}
+
+var _ driver.Valuer = sensitive{}
+
+func (s sensitive) Value() (driver.Value, error) {
+	if valuer, ok := s.value.(driver.Valuer); ok {
+		return valuer.Value()
+	}
+	return s.value, nil
+}
+
+func (s sensitive) String() string {
+	return maskedValue
+}
+
+// unwrapSensitive returns value marked by Sensitive
+func unwrapSensitive(v interface{}) interface{} {
+	if s, ok := v.(sensitive); ok {
+		return s.value
+	}
+	return v
+}
+
+func isSensitiveField(field *schema.Field) bool {
+	_, ok := field.TagSettings["SENSITIVE"]
+	return ok
+}
+
+// patchSensitiveField marks values of field as sensitive
+func patchSensitiveField(field *schema.Field) {
+	valueOf := field.ValueOf
+	field.ValueOf = func(ctx context.Context, rv reflect.Value) (interface{}, bool) {
+		v, isZero := valueOf(ctx, rv)
+		if v == nil {
+			return v, isZero
+		}
+		return sensitive{value: v}, isZero
+	}
+}
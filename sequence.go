@@ -0,0 +1,170 @@
+package ydb
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb"
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/options"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+var (
+	// SequenceTable name of table keeping values of sequences, created on first use
+	SequenceTable = "ydb_sequences"
+	// SequenceBlockSize count of ids allocated by the process at once
+	SequenceBlockSize uint64 = 100
+	// SequenceRetries max count of retries of allocation of block conflicting with other process
+	SequenceRetries = 10
+)
+
+// sequenceBlock is range of ids (next, end] allocated by the process
+type sequenceBlock struct {
+	mu   sync.Mutex
+	next uint64
+	end  uint64
+}
+
+var sequenceBlocks sync.Map // sequence table path and name -> *sequenceBlock
+
+type sequenceValue struct {
+	Name  string `gorm:"primaryKey"`
+	Value uint64
+}
+
+func (sequenceValue) TableName() string {
+	return SequenceTable
+}
+
+// NextID returns next id of sequence name. Ids are allocated by blocks of SequenceBlockSize
+// stored in SequenceTable, so ids are unique and increasing within the process, but ids of
+// concurrent processes interleave and ids of blocks not used up before exit are skipped
+//
+//	id, err := ydb.NextID(ctx, db, "orders")
+func NextID(ctx context.Context, db *gorm.DB, name string) (uint64, error) {
+	nativeDriver, err := Unwrap(db)
+	if err != nil {
+		return 0, err
+	}
+	v, _ := sequenceBlocks.LoadOrStore(tablePath(nativeDriver, SequenceTable)+"#"+name, &sequenceBlock{})
+	block := v.(*sequenceBlock)
+
+	block.mu.Lock()
+	defer block.mu.Unlock()
+	if block.next >= block.end {
+		end, err := allocateSequenceBlock(ctx, db, name)
+		if err != nil {
+			return 0, err
+		}
+		block.next, block.end = end-SequenceBlockSize, end
+	}
+	block.next++
+	return block.next, nil
+}
+
+// allocateSequenceBlock advances value of sequence by SequenceBlockSize in serializable
+// transaction retried on conflicts with other processes, returns new value
+func allocateSequenceBlock(ctx context.Context, db *gorm.DB, name string) (end uint64, err error) {
+	if err = createTable(ctx, db, SequenceTable,
+		options.WithColumn("name", types.Optional(types.TypeUTF8)),
+		options.WithColumn("value", types.Optional(types.TypeUint64)),
+		options.WithPrimaryKeyColumn("name"),
+	); err != nil {
+		return 0, err
+	}
+
+	db = db.Session(&gorm.Session{NewDB: true, Context: ctx})
+	db.Statement.ConnPool = db.ConnPool // blocks are allocated outside of transaction of caller
+	for attempt := 0; ; attempt++ {
+		err = db.Transaction(func(tx *gorm.DB) error {
+			var current sequenceValue
+			if err := tx.Where("name = ?", name).Take(&current).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			end = current.Value + SequenceBlockSize
+			return tx.Exec("UPSERT INTO ? (name, value) VALUES (?, ?)",
+				clause.Table{Name: SequenceTable}, name, end).Error
+		})
+		if err == nil || attempt >= SequenceRetries || !ydb.IsOperationError(err, Ydb.StatusIds_ABORTED) {
+			return end, err
+		}
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(time.Duration(attempt+1) * 10 * time.Millisecond):
+		}
+	}
+}
+
+// Sequences is a gorm plugin filling zero auto increment fields of created rows with ids of
+// NextID, sequence of field is named by its table and column or by sequence tag
+//
+//	type Order struct {
+//		ID uint64 `gorm:"primaryKey;autoIncrement;sequence:orders"`
+//	}
+//
+//	db.Use(&ydb.Sequences{})
+type Sequences struct{}
+
+func (Sequences) Name() string {
+	return "ydb:sequences"
+}
+
+func (s Sequences) Initialize(db *gorm.DB) error {
+	// ids are assigned before hash shards of keys are computed
+	return db.Callback().Create().Before("ydb:hash_shard").Register("ydb:sequences", s.assign)
+}
+
+func sequenceName(field *schema.Field) string {
+	if name := field.TagSettings["SEQUENCE"]; name != "" {
+		return name
+	}
+	return field.Schema.Table + "_" + field.DBName
+}
+
+// assign sets zero auto increment fields of created rows
+func (Sequences) assign(db *gorm.DB) {
+	if db.Error != nil || db.DryRun || db.Statement.Schema == nil {
+		return
+	}
+	var fields []*schema.Field
+	for _, field := range db.Statement.Schema.Fields {
+		if field.AutoIncrement && field.Creatable {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 0 {
+		return
+	}
+
+	ctx := db.Statement.Context
+	assign := func(rv reflect.Value) {
+		for _, field := range fields {
+			if _, isZero := field.ValueOf(ctx, rv); !isZero {
+				continue
+			}
+			id, err := NextID(ctx, db, sequenceName(field))
+			if db.AddError(err) != nil {
+				return
+			}
+			db.AddError(field.Set(ctx, rv, id))
+		}
+	}
+	switch rv := reflect.Indirect(db.Statement.ReflectValue); rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len() && db.Error == nil; i++ {
+			if elem := reflect.Indirect(rv.Index(i)); elem.Kind() == reflect.Struct {
+				assign(elem)
+			}
+		}
+	case reflect.Struct:
+		assign(rv)
+	}
+}
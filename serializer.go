@@ -0,0 +1,405 @@
+package ydb
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"google.golang.org/protobuf/proto"
+	"gorm.io/gorm/schema"
+)
+
+// ErrSerializedValue returned by scans of malformed values of protobuf and msgpack serializers
+var ErrSerializedValue = errors.New("ydb: malformed serialized value")
+
+// RegisterSerializers registers serializers "protobuf" and "msgpack" of binary payloads kept in
+// String columns. Serializers must be registered before models are parsed
+//
+//	ydb.RegisterSerializers()
+//
+//	type Device struct {
+//		ID       uint64
+//		Settings *pb.Settings          `gorm:"serializer:protobuf;type:String"`
+//		Labels   map[string]interface{} `gorm:"serializer:msgpack;type:String"`
+//	}
+func RegisterSerializers() {
+	schema.RegisterSerializer("protobuf", ProtobufSerializer{})
+	schema.RegisterSerializer("msgpack", MsgpackSerializer{})
+}
+
+// ProtobufSerializer serializes fields of proto.Message pointer types in binary wire format,
+// deterministically, so equal messages have equal values. Unknown fields of messages written by
+// newer schemas are kept by scans and written back by updates
+type ProtobufSerializer struct{}
+
+func (ProtobufSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	if fieldValue == nil {
+		return nil, nil
+	}
+	if rv := reflect.ValueOf(fieldValue); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil, nil
+	}
+	message, ok := fieldValue.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("ydb: field %s of type %T is not proto.Message", field.Name, fieldValue)
+	}
+	return proto.MarshalOptions{Deterministic: true}.Marshal(message)
+}
+
+func (ProtobufSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	data, isNull, err := serializedBytes(field, dbValue)
+	if err != nil || isNull {
+		return err
+	}
+	fieldValue := reflect.New(field.IndirectFieldType)
+	message, ok := fieldValue.Interface().(proto.Message)
+	if !ok {
+		return fmt.Errorf("ydb: field %s of type %s is not proto.Message", field.Name, field.FieldType)
+	}
+	if err = proto.Unmarshal(data, message); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrSerializedValue, field.Name, err)
+	}
+	if field.FieldType.Kind() != reflect.Ptr {
+		fieldValue = fieldValue.Elem()
+	}
+	field.ReflectValueOf(ctx, dst).Set(fieldValue)
+	return nil
+}
+
+// MsgpackSerializer serializes fields in MessagePack format. Values are encoded as their JSON
+// would be, so structs are maps keyed by JSON names of fields: fields added to models are zero
+// in values written before and values of removed fields are ignored by scans
+type MsgpackSerializer struct{}
+
+func (MsgpackSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	if fieldValue == nil {
+		return nil, nil
+	}
+	if rv := reflect.ValueOf(fieldValue); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil, nil
+	}
+	data, err := json.Marshal(fieldValue)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var v interface{}
+	if err = decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err = encodeMsgpack(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (MsgpackSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	data, isNull, err := serializedBytes(field, dbValue)
+	if err != nil || isNull {
+		return err
+	}
+	v, rest, err := decodeMsgpack(data)
+	if err == nil && len(rest) > 0 {
+		err = errors.New("trailing bytes")
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrSerializedValue, field.Name, err)
+	}
+	if data, err = json.Marshal(v); err != nil {
+		return err
+	}
+	fieldValue := reflect.New(field.IndirectFieldType)
+	if err = json.Unmarshal(data, fieldValue.Interface()); err != nil {
+		return err
+	}
+	if field.FieldType.Kind() != reflect.Ptr {
+		fieldValue = fieldValue.Elem()
+	}
+	field.ReflectValueOf(ctx, dst).Set(fieldValue)
+	return nil
+}
+
+// serializedBytes returns bytes of value scanned from column, zeroes field of NULL value
+func serializedBytes(field *schema.Field, dbValue interface{}) (data []byte, isNull bool, err error) {
+	switch v := dbValue.(type) {
+	case nil:
+		return nil, true, nil
+	case []byte:
+		return v, false, nil
+	case string:
+		return []byte(v), false, nil
+	}
+	return nil, false, fmt.Errorf("%w: unsupported value %T of %s", ErrSerializedValue, dbValue, field.Name)
+}
+
+// encodeMsgpack writes v decoded from JSON with json.Number numbers in MessagePack format
+func encodeMsgpack(buf *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+	case bool:
+		if v {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case json.Number:
+		if i, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			encodeMsgpackInt(buf, i)
+		} else if u, err := strconv.ParseUint(string(v), 10, 64); err == nil {
+			buf.WriteByte(0xcf)
+			_ = binary.Write(buf, binary.BigEndian, u)
+		} else {
+			f, err := v.Float64()
+			if err != nil {
+				return err
+			}
+			buf.WriteByte(0xcb)
+			_ = binary.Write(buf, binary.BigEndian, math.Float64bits(f))
+		}
+	case string:
+		switch n := len(v); {
+		case n < 32:
+			buf.WriteByte(0xa0 | byte(n))
+		case n <= math.MaxUint8:
+			buf.WriteByte(0xd9)
+			buf.WriteByte(byte(n))
+		case n <= math.MaxUint16:
+			buf.WriteByte(0xda)
+			_ = binary.Write(buf, binary.BigEndian, uint16(n))
+		default:
+			buf.WriteByte(0xdb)
+			_ = binary.Write(buf, binary.BigEndian, uint32(n))
+		}
+		buf.WriteString(v)
+	case []interface{}:
+		encodeMsgpackLen(buf, len(v), 0x90, 0xdc, 0xdd)
+		for _, item := range v {
+			if err := encodeMsgpack(buf, item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		// keys are sorted, so equal values have equal encodings
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		encodeMsgpackLen(buf, len(v), 0x80, 0xde, 0xdf)
+		for _, key := range keys {
+			if err := encodeMsgpack(buf, key); err != nil {
+				return err
+			}
+			if err := encodeMsgpack(buf, v[key]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("ydb: msgpack of %T is not supported", v)
+	}
+	return nil
+}
+
+func encodeMsgpackInt(buf *bytes.Buffer, i int64) {
+	switch {
+	case i >= 0 && i <= math.MaxInt8, i < 0 && i >= -32:
+		buf.WriteByte(byte(i))
+	case i >= math.MinInt8 && i <= math.MaxInt8:
+		buf.WriteByte(0xd0)
+		buf.WriteByte(byte(i))
+	case i >= math.MinInt16 && i <= math.MaxInt16:
+		buf.WriteByte(0xd1)
+		_ = binary.Write(buf, binary.BigEndian, int16(i))
+	case i >= math.MinInt32 && i <= math.MaxInt32:
+		buf.WriteByte(0xd2)
+		_ = binary.Write(buf, binary.BigEndian, int32(i))
+	default:
+		buf.WriteByte(0xd3)
+		_ = binary.Write(buf, binary.BigEndian, i)
+	}
+}
+
+// encodeMsgpackLen writes header of array or map of n items
+func encodeMsgpackLen(buf *bytes.Buffer, n int, fix, len16, len32 byte) {
+	switch {
+	case n < 16:
+		buf.WriteByte(fix | byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(len16)
+		_ = binary.Write(buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(len32)
+		_ = binary.Write(buf, binary.BigEndian, uint32(n))
+	}
+}
+
+var errMsgpackShort = errors.New("unexpected end of value")
+
+// decodeMsgpack decodes first value of data into value encodable as JSON, returns rest of data
+func decodeMsgpack(data []byte) (v interface{}, rest []byte, err error) {
+	if len(data) == 0 {
+		return nil, nil, errMsgpackShort
+	}
+	b, data := data[0], data[1:]
+	// take returns next n bytes of data
+	take := func(n int) ([]byte, error) {
+		if n < 0 || len(data) < n {
+			return nil, errMsgpackShort
+		}
+		taken := data[:n]
+		data = data[n:]
+		return taken, nil
+	}
+	// readUint reads big-endian unsigned integer of n bytes
+	readUint := func(n int) (uint64, error) {
+		bs, err := take(n)
+		if err != nil {
+			return 0, err
+		}
+		var u uint64
+		for _, c := range bs {
+			u = u<<8 | uint64(c)
+		}
+		return u, nil
+	}
+	// size reads length of string, array or map of n bytes
+	size := func(n int) (int, error) {
+		l, err := readUint(n)
+		return int(l), err
+	}
+
+	switch {
+	case b <= 0x7f:
+		return int64(b), data, nil
+	case b >= 0xe0:
+		return int64(int8(b)), data, nil
+	case b&0xe0 == 0xa0:
+		s, err := take(int(b & 0x1f))
+		return string(s), data, err
+	case b&0xf0 == 0x90:
+		return decodeMsgpackArray(data, int(b&0x0f))
+	case b&0xf0 == 0x80:
+		return decodeMsgpackMap(data, int(b&0x0f))
+	}
+
+	switch b {
+	case 0xc0:
+		return nil, data, nil
+	case 0xc2:
+		return false, data, nil
+	case 0xc3:
+		return true, data, nil
+	case 0xc4, 0xc5, 0xc6, 0xd9, 0xda, 0xdb:
+		n := map[byte]int{0xc4: 1, 0xc5: 2, 0xc6: 4, 0xd9: 1, 0xda: 2, 0xdb: 4}[b]
+		l, err := size(n)
+		if err != nil {
+			return nil, nil, err
+		}
+		s, err := take(l)
+		if b <= 0xc6 {
+			return s, data, err
+		}
+		return string(s), data, err
+	case 0xca:
+		bs, err := take(4)
+		if err != nil {
+			return nil, nil, err
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(bs))), data, nil
+	case 0xcb:
+		bs, err := take(8)
+		if err != nil {
+			return nil, nil, err
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(bs)), data, nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		u, err := readUint(1 << (b - 0xcc))
+		if err != nil {
+			return nil, nil, err
+		}
+		if u > math.MaxInt64 {
+			return u, data, nil
+		}
+		return int64(u), data, nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		bs, err := take(1 << (b - 0xd0))
+		if err != nil {
+			return nil, nil, err
+		}
+		var i int64
+		switch len(bs) {
+		case 1:
+			i = int64(int8(bs[0]))
+		case 2:
+			i = int64(int16(binary.BigEndian.Uint16(bs)))
+		case 4:
+			i = int64(int32(binary.BigEndian.Uint32(bs)))
+		default:
+			i = int64(binary.BigEndian.Uint64(bs))
+		}
+		return i, data, nil
+	case 0xdc, 0xdd:
+		n, err := size(2 << (b - 0xdc))
+		if err != nil {
+			return nil, nil, err
+		}
+		return decodeMsgpackArray(data, n)
+	case 0xde, 0xdf:
+		n, err := size(2 << (b - 0xde))
+		if err != nil {
+			return nil, nil, err
+		}
+		return decodeMsgpackMap(data, n)
+	}
+	return nil, nil, fmt.Errorf("unsupported type 0x%x", b)
+}
+
+func decodeMsgpackArray(data []byte, n int) (interface{}, []byte, error) {
+	if n > len(data) {
+		return nil, nil, errMsgpackShort
+	}
+	items := make([]interface{}, n)
+	for i := range items {
+		var err error
+		if items[i], data, err = decodeMsgpack(data); err != nil {
+			return nil, nil, err
+		}
+	}
+	return items, data, nil
+}
+
+func decodeMsgpackMap(data []byte, n int) (interface{}, []byte, error) {
+	if n > len(data) {
+		return nil, nil, errMsgpackShort
+	}
+	m := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		key, rest, err := decodeMsgpack(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		var v interface{}
+		if v, data, err = decodeMsgpack(rest); err != nil {
+			return nil, nil, err
+		}
+		switch key := key.(type) {
+		case string:
+			m[key] = v
+		case []byte:
+			m[string(key)] = v
+		default:
+			m[fmt.Sprint(key)] = v
+		}
+	}
+	return m, data, nil
+}
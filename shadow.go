@@ -0,0 +1,233 @@
+package ydb
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Shadow is a gorm plugin mirroring writes (creates, updates and deletes) executed through gorm
+// to Secondary database asynchronously, e.g. to YDB while migrating from other database.
+// Statements are replayed from their clauses, so the secondary may use other dialector
+//
+//	shadow := &ydb.Shadow{Secondary: ydbDB}
+//	postgresDB.Use(shadow)
+//	defer shadow.Close()
+//
+// Writes are mirrored after commit of default transactions of statements and of transactions
+// opened by Begin, writes of rolled back transactions are not mirrored. Writes of transactions
+// opened by gorm Begin and Transaction are counted as Skipped and not mirrored, since gorm has
+// no hooks of their commit. Raw statements (Exec) are not mirrored since their SQL is specific
+// to primary database
+type Shadow struct {
+	// Secondary database receiving mirrored writes
+	Secondary *gorm.DB
+	// QueueSize max count of writes waiting for mirroring, writes are dropped when queue is full,
+	// defaults to 1024
+	QueueSize int
+	// Workers count of concurrently mirrored writes, defaults to 1 keeping order of writes
+	Workers int
+	// OnError called with errors of mirrored writes
+	OnError func(err error)
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan shadowWrite
+	wg     sync.WaitGroup
+	stats  ShadowStats
+}
+
+// ShadowStats counters of mirrored writes
+type ShadowStats struct {
+	// Mirrored count of writes executed on secondary
+	Mirrored int64
+	// Failed count of writes failed on secondary
+	Failed int64
+	// Dropped count of writes dropped on full queue
+	Dropped int64
+	// Diverged count of writes affected other count of rows on secondary than on primary
+	Diverged int64
+	// Skipped count of writes of transactions opened by gorm Begin and Transaction
+	Skipped int64
+}
+
+type shadowWrite struct {
+	write        func(tx *gorm.DB) *gorm.DB
+	rowsAffected int64
+}
+
+func (s *Shadow) Name() string {
+	return "ydb:shadow"
+}
+
+func (s *Shadow) Initialize(db *gorm.DB) error {
+	queueSize, workers := s.QueueSize, s.Workers
+	if queueSize <= 0 {
+		queueSize = 1024
+	}
+	if workers <= 0 {
+		workers = 1
+	}
+	s.queue = make(chan shadowWrite, queueSize)
+	for i := 0; i < workers; i++ {
+		s.wg.Add(1)
+		go s.mirror()
+	}
+
+	// default transactions of statements are committed or rolled back already
+	const committed = "gorm:commit_or_rollback_transaction"
+	callback := db.Callback()
+	if err := callback.Create().After(committed).Register("ydb:shadow", s.enqueue(createWrite)); err != nil {
+		return err
+	}
+	if err := callback.Update().After(committed).Register("ydb:shadow", s.enqueue(updateWrite)); err != nil {
+		return err
+	}
+	return callback.Delete().After(committed).Register("ydb:shadow", s.enqueue(deleteWrite))
+}
+
+// Stats returns counters of mirrored writes
+func (s *Shadow) Stats() ShadowStats {
+	return ShadowStats{
+		Mirrored: atomic.LoadInt64(&s.stats.Mirrored),
+		Failed:   atomic.LoadInt64(&s.stats.Failed),
+		Dropped:  atomic.LoadInt64(&s.stats.Dropped),
+		Diverged: atomic.LoadInt64(&s.stats.Diverged),
+		Skipped:  atomic.LoadInt64(&s.stats.Skipped),
+	}
+}
+
+// Close stops accepting writes and waits until queued writes are mirrored
+func (s *Shadow) Close() {
+	s.mu.Lock()
+	if !s.closed && s.queue != nil {
+		close(s.queue)
+	}
+	s.closed = true
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+func (s *Shadow) enqueue(replay func(stmt *gorm.Statement) func(tx *gorm.DB) *gorm.DB) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Error != nil || db.DryRun {
+			return
+		}
+		write := replay(db.Statement)
+		if write == nil {
+			return
+		}
+		w := shadowWrite{write: write, rowsAffected: db.RowsAffected}
+		if tx := txOf(db.Statement.ConnPool); tx != nil {
+			tx.afterCommit = append(tx.afterCommit, func() { s.push(w) })
+			return
+		}
+		if _, inTx := db.Statement.ConnPool.(gorm.TxCommitter); inTx {
+			atomic.AddInt64(&s.stats.Skipped, 1)
+			return
+		}
+		s.push(w)
+	}
+}
+
+// push queues committed write
+func (s *Shadow) push(w shadowWrite) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		atomic.AddInt64(&s.stats.Dropped, 1)
+		return
+	}
+	select {
+	case s.queue <- w:
+	default:
+		atomic.AddInt64(&s.stats.Dropped, 1)
+	}
+}
+
+func (s *Shadow) mirror() {
+	defer s.wg.Done()
+	for w := range s.queue {
+		tx := w.write(s.Secondary.Session(&gorm.Session{NewDB: true, SkipHooks: true}))
+		switch {
+		case tx.Error != nil:
+			atomic.AddInt64(&s.stats.Failed, 1)
+			if s.OnError != nil {
+				s.OnError(tx.Error)
+			}
+		case tx.RowsAffected != w.rowsAffected:
+			atomic.AddInt64(&s.stats.Mirrored, 1)
+			atomic.AddInt64(&s.stats.Diverged, 1)
+		default:
+			atomic.AddInt64(&s.stats.Mirrored, 1)
+		}
+	}
+}
+
+// createWrite replays INSERT by values of statement
+func createWrite(stmt *gorm.Statement) func(tx *gorm.DB) *gorm.DB {
+	values, ok := stmt.Clauses["VALUES"].Expression.(clause.Values)
+	if !ok || len(values.Values) == 0 {
+		return nil
+	}
+	rows := make([]map[string]interface{}, 0, len(values.Values))
+	for _, v := range values.Values {
+		row := make(map[string]interface{}, len(values.Columns))
+		for i, column := range values.Columns {
+			row[column.Name] = v[i]
+		}
+		rows = append(rows, row)
+	}
+	table := stmt.Table
+	onConflict, upsert := stmt.Clauses["ON CONFLICT"]
+	return func(tx *gorm.DB) *gorm.DB {
+		tx = tx.Table(table)
+		if upsert {
+			tx = tx.Clauses(onConflict.Expression)
+		}
+		return tx.Create(&rows)
+	}
+}
+
+// updateWrite replays UPDATE by assignments and conditions of statement
+func updateWrite(stmt *gorm.Statement) func(tx *gorm.DB) *gorm.DB {
+	set, ok := stmt.Clauses["SET"].Expression.(clause.Set)
+	if !ok || len(set) == 0 {
+		return nil
+	}
+	values := make(map[string]interface{}, len(set))
+	for _, assignment := range set {
+		values[assignment.Column.Name] = assignment.Value
+	}
+	table := stmt.Table
+	where, conditional := stmt.Clauses["WHERE"]
+	return func(tx *gorm.DB) *gorm.DB {
+		tx = tx.Table(table)
+		if conditional {
+			tx = tx.Clauses(where.Expression)
+		} else {
+			tx = tx.Session(&gorm.Session{AllowGlobalUpdate: true})
+		}
+		return tx.Updates(values)
+	}
+}
+
+// deleteWrite replays DELETE by conditions of statement, soft deletes are replayed as updates
+func deleteWrite(stmt *gorm.Statement) func(tx *gorm.DB) *gorm.DB {
+	if _, softDelete := stmt.Clauses["SET"]; softDelete {
+		return updateWrite(stmt)
+	}
+	table := stmt.Table
+	where, conditional := stmt.Clauses["WHERE"]
+	return func(tx *gorm.DB) *gorm.DB {
+		tx = tx.Table(table)
+		if conditional {
+			tx = tx.Clauses(where.Expression)
+		} else {
+			tx = tx.Session(&gorm.Session{AllowGlobalUpdate: true})
+		}
+		return tx.Delete(map[string]interface{}{})
+	}
+}
@@ -0,0 +1,109 @@
+package ydb
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// ErrShutdown returned for statements started after Shutdown
+var ErrShutdown = errors.New("ydb: database is shutting down")
+
+// drainer counts statements in flight and rejects new ones after shutdown
+type drainer struct {
+	mu       sync.Mutex
+	closing  bool
+	closed   bool
+	inFlight int
+	idle     chan struct{}
+}
+
+func newDrainer() *drainer {
+	return &drainer{idle: make(chan struct{})}
+}
+
+// enter registers statement in flight, statements of open transactions are accepted during
+// shutdown until statements in flight are done, so the transactions can be finished
+func (d *drainer) enter(inTx bool) error {
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closing && (!inTx || d.closed) {
+		return ErrShutdown
+	}
+	d.inFlight++
+	return nil
+}
+
+func (d *drainer) leave() {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.inFlight--; d.inFlight == 0 && d.closing {
+		d.closeIdle()
+	}
+}
+
+// closeIdle closes idle once, mu must be held
+func (d *drainer) closeIdle() {
+	if !d.closed {
+		d.closed = true
+		close(d.idle)
+	}
+}
+
+// close rejects new statements and returns channel closed when statements in flight are done
+func (d *drainer) close() <-chan struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.closing {
+		d.closing = true
+		if d.inFlight == 0 {
+			d.closeIdle()
+		}
+	}
+	return d.idle
+}
+
+// Shutdown stops accepting new statements (they fail with ErrShutdown), waits until statements
+// in flight are done or ctx is done, then closes sessions of the connection pool and native
+// driver opened by the dialector (shared Config.NativeDriver is left open). Statements of
+// transactions open at shutdown are still accepted until the wait ends
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second) // before SIGKILL
+//	defer cancel()
+//	err := ydb.Shutdown(ctx, db)
+func Shutdown(ctx context.Context, db *gorm.DB) error {
+	config := configOf(db)
+	if config == nil || config.drainer == nil {
+		return ErrNativeDriverUnavailable
+	}
+
+	var err error
+	select {
+	case <-config.drainer.close():
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	if sqlDB, dbErr := db.DB(); dbErr == nil {
+		if closeErr := sqlDB.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if config.lazy != nil {
+		config.lazy.cancel()
+	}
+	if nativeDriver, unwrapErr := Unwrap(db); unwrapErr == nil && config.NativeDriver == nil {
+		if closeErr := nativeDriver.Close(context.Background()); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
@@ -0,0 +1,218 @@
+package ydb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/options"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/stats"
+	"gorm.io/gorm"
+)
+
+// SlowQuery reports statements executed longer than Threshold with execution statistics
+// collected by YDB and query plan, unlike slow SQL warning of gorm logger with duration only.
+// Data queries are executed with basic statistics collection, plan of slow statement is
+// explained by one more request
+//
+//	db, err := gorm.Open(ydb.New(ydb.Config{
+//		DSN:       dsn,
+//		SlowQuery: &ydb.SlowQuery{Threshold: 500 * time.Millisecond},
+//	}))
+type SlowQuery struct {
+	// Threshold duration of statement above which it is reported
+	Threshold time.Duration
+	// WithoutPlan skips explaining slow statements
+	WithoutPlan bool
+	// OnSlowQuery called with record of slow statement, records are logged by logger of gorm
+	// with warn level when nil
+	OnSlowQuery func(ctx context.Context, record SlowQueryRecord)
+}
+
+// SlowQueryRecord describes slow statement
+type SlowQueryRecord struct {
+	// SQL of statement with explained vars, sensitive values are masked
+	SQL          string
+	Table        string
+	Duration     time.Duration
+	RowsAffected int64
+	Error        error
+	// Fingerprint of statement, see Fingerprint
+	Fingerprint string
+	// Plan of statement in JSON, empty when it was not explained
+	Plan string
+	// Stats of last request of statement, nil when YDB returned no statistics (e.g. scan queries)
+	Stats *SlowQueryStats
+}
+
+// SlowQueryStats execution statistics of statement
+type SlowQueryStats struct {
+	ProcessCPUTime       time.Duration
+	CompilationDuration  time.Duration
+	CompilationFromCache bool
+	// Duration and CPUTime total of execution phases
+	Duration       time.Duration
+	CPUTime        time.Duration
+	AffectedShards uint64
+	// Tables accessed by statement with rows and bytes read, updated and deleted
+	Tables []stats.TableAccess
+}
+
+func newSlowQueryStats(s stats.QueryStats) *SlowQueryStats {
+	if s == nil {
+		return nil
+	}
+	st := &SlowQueryStats{ProcessCPUTime: s.ProcessCPUTime()}
+	if c := s.Compilation(); c != nil {
+		st.CompilationDuration, st.CompilationFromCache = c.Duration, c.FromCache
+	}
+	for phase, ok := s.NextPhase(); ok; phase, ok = s.NextPhase() {
+		st.Duration += phase.Duration()
+		st.CPUTime += phase.CPUTime()
+		st.AffectedShards += phase.AffectedShards()
+		for access, ok := phase.NextTableAccess(); ok; access, ok = phase.NextTableAccess() {
+			st.Tables = append(st.Tables, *access)
+		}
+	}
+	return st
+}
+
+type slowQueryKey struct{}
+
+// slowQueryExplainKey marks context of explaining slow statement, it isn't tracked itself
+type slowQueryExplainKey struct{}
+
+// slowQueryHolder keeps start of statement and statistics of its requests captured by trace
+// of native driver, it is passed with context of statement
+type slowQueryHolder struct {
+	start  time.Time
+	stats  stats.QueryStats
+	parent context.Context
+}
+
+// captureStats keeps statistics of result of request executed with ctx
+func captureStats(ctx context.Context, s stats.QueryStats) {
+	if holder, ok := ctx.Value(slowQueryKey{}).(*slowQueryHolder); ok && s != nil {
+		holder.stats = s
+	}
+}
+
+// connectorOptions returns options of native connector collecting statistics of data queries
+func (q *SlowQuery) connectorOptions() []ydb.ConnectorOption {
+	if q == nil {
+		return nil
+	}
+	return []ydb.ConnectorOption{ydb.WithDefaultDataQueryOptions(options.WithCollectStatsModeBasic())}
+}
+
+const (
+	slowQueryStartKey = "ydb:slow_query_start"
+	slowQueryKeyName  = "ydb:slow_query"
+)
+
+func (q *SlowQuery) registerCallbacks(db *gorm.DB) error {
+	callback := db.Callback()
+	if err := callback.Create().Before("gorm:create").Register(slowQueryStartKey, q.start); err != nil {
+		return err
+	}
+	if err := callback.Create().After("gorm:create").Register(slowQueryKeyName, q.done); err != nil {
+		return err
+	}
+	if err := callback.Query().Before("gorm:query").Register(slowQueryStartKey, q.start); err != nil {
+		return err
+	}
+	if err := callback.Query().After("gorm:query").Register(slowQueryKeyName, q.done); err != nil {
+		return err
+	}
+	if err := callback.Update().Before("gorm:update").Register(slowQueryStartKey, q.start); err != nil {
+		return err
+	}
+	if err := callback.Update().After("gorm:update").Register(slowQueryKeyName, q.done); err != nil {
+		return err
+	}
+	if err := callback.Delete().Before("gorm:delete").Register(slowQueryStartKey, q.start); err != nil {
+		return err
+	}
+	if err := callback.Delete().After("gorm:delete").Register(slowQueryKeyName, q.done); err != nil {
+		return err
+	}
+	if err := callback.Row().Before("gorm:row").Register(slowQueryStartKey, q.start); err != nil {
+		return err
+	}
+	if err := callback.Row().After("gorm:row").Register(slowQueryKeyName, q.done); err != nil {
+		return err
+	}
+	if err := callback.Raw().Before("gorm:raw").Register(slowQueryStartKey, q.start); err != nil {
+		return err
+	}
+	return callback.Raw().After("gorm:raw").Register(slowQueryKeyName, q.done)
+}
+
+func (q *SlowQuery) start(db *gorm.DB) {
+	if db.Statement.Context.Value(slowQueryExplainKey{}) != nil {
+		return
+	}
+	holder := &slowQueryHolder{start: time.Now(), parent: db.Statement.Context}
+	db.Statement.Context = context.WithValue(db.Statement.Context, slowQueryKey{}, holder)
+}
+
+func (q *SlowQuery) done(db *gorm.DB) {
+	if db.Statement.Context.Value(slowQueryExplainKey{}) != nil {
+		return
+	}
+	holder, ok := db.Statement.Context.Value(slowQueryKey{}).(*slowQueryHolder)
+	if !ok {
+		return
+	}
+	ctx := holder.parent
+	db.Statement.Context = ctx
+	elapsed := time.Since(holder.start)
+	if elapsed < q.Threshold || db.DryRun || db.Statement.SQL.Len() == 0 {
+		return
+	}
+
+	sql := db.Statement.SQL.String()
+	record := SlowQueryRecord{
+		SQL:          db.Dialector.Explain(sql, db.Statement.Vars...),
+		Fingerprint:  Fingerprint(sql),
+		Table:        db.Statement.Table,
+		Duration:     elapsed,
+		RowsAffected: db.RowsAffected,
+		Error:        db.Error,
+		Stats:        newSlowQueryStats(holder.stats),
+	}
+	if !q.WithoutPlan {
+		explainCtx := context.WithValue(ydb.WithQueryMode(ctx, ydb.ExplainQueryMode), slowQueryExplainKey{}, true)
+		explain := db.Session(&gorm.Session{NewDB: true, Context: explainCtx})
+		// explained outside of transaction of statement
+		explain.Statement.ConnPool = db.ConnPool
+		var ast string
+		if err := explain.Raw(sql, db.Statement.Vars...).Row().Scan(&ast, &record.Plan); err != nil {
+			db.Logger.Warn(ctx, "ydb: failed to explain slow query: %v", err)
+		}
+	}
+
+	if q.OnSlowQuery != nil {
+		q.OnSlowQuery(ctx, record)
+		return
+	}
+	db.Logger.Warn(ctx, "slow query [%s] %s (%s, rows: %d)\n%s",
+		record.Fingerprint, record.SQL, record.Duration, record.RowsAffected, record.formatStats())
+}
+
+func (record SlowQueryRecord) formatStats() string {
+	s := "stats: unavailable"
+	if st := record.Stats; st != nil {
+		s = fmt.Sprintf("stats: cpu %s, compilation %s (cached: %t), shards %d",
+			st.ProcessCPUTime+st.CPUTime, st.CompilationDuration, st.CompilationFromCache, st.AffectedShards)
+		for _, t := range st.Tables {
+			s += fmt.Sprintf(", %s: read %d rows, updated %d rows, deleted %d rows",
+				t.Name, t.Reads.Rows, t.Updates.Rows, t.Deletes.Rows)
+		}
+	}
+	if record.Plan != "" {
+		s += "\nplan: " + record.Plan
+	}
+	return s
+}
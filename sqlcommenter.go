@@ -0,0 +1,88 @@
+package ydb
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// SQLCommenter appends sqlcommenter comments with tags of requests to queries, so statements in
+// top queries and query statistics views of YDB are correlated back to application endpoints:
+//
+//	SELECT * FROM `users` WHERE id = $p1
+//	/*application='billing',route='%2Fusers%2F%3Aid'*/
+//
+// Tags are labels of statements and Config.Labels (e.g. route set by WithLabel), application and
+// tags of context. Server caches compiled queries by their text, so tags must have few distinct
+// values, per request values make every query compiled again
+type SQLCommenter struct {
+	// Application is value of application tag
+	Application string
+	// TraceID adds trace id of WithTraceID as trace_id tag, it disables query cache of server
+	TraceID bool
+	// Tags returns additional tags of context of statement, e.g. route of HTTP handler
+	Tags func(ctx context.Context) map[string]string
+}
+
+type traceIDKey struct{}
+
+// commentQuery appends sqlcommenter comment with tags of ctx to query
+func (config *Config) commentQuery(ctx context.Context, query string) string {
+	if config == nil || config.SQLCommenter == nil {
+		return query
+	}
+	commenter := config.SQLCommenter
+
+	tags := make(map[string]string, len(config.Labels))
+	for k, v := range config.Labels {
+		tags[k] = v
+	}
+	if labels, ok := ctx.Value(labelsKey{}).(map[string]string); ok {
+		for k, v := range labels {
+			tags[k] = v
+		}
+	}
+	if commenter.Application != "" {
+		tags["application"] = commenter.Application
+	}
+	if traceID, ok := ctx.Value(traceIDKey{}).(string); ok && commenter.TraceID {
+		tags["trace_id"] = traceID
+	}
+	if commenter.Tags != nil {
+		for k, v := range commenter.Tags(ctx) {
+			tags[k] = v
+		}
+	}
+	if len(tags) == 0 {
+		return query
+	}
+
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	sb.Grow(len(query) + 32*len(keys))
+	// on its own line, so line comment at the end of query doesn't swallow it
+	sb.WriteString(strings.TrimRight(query, " \n"))
+	sb.WriteString("\n/*")
+	for i, k := range keys {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(commentEscape(k))
+		sb.WriteString("='")
+		sb.WriteString(commentEscape(tags[k]))
+		sb.WriteByte('\'')
+	}
+	sb.WriteString("*/")
+	return sb.String()
+}
+
+// commentEscape URL encodes s as sqlcommenter specification requires, encoding also escapes
+// quotes and sequences closing comment
+func commentEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
@@ -0,0 +1,186 @@
+package ydb
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AllowStale returns statement modifier executing query with stale read-only transaction,
+// served by follower replicas of tables with read replicas settings, when tables of query
+// were not written through the db for maxStaleness, otherwise query reads from leaders.
+// Writes of other processes may be missed by stale reads, see ServedStale, Staleness and StaleReads
+//
+//	db.Clauses(ydb.AllowStale(5 * time.Second)).Find(&products)
+func AllowStale(maxStaleness time.Duration) clause.Expression {
+	return allowStale(maxStaleness)
+}
+
+type allowStale time.Duration
+
+type allowStaleKey struct{}
+
+func (maxStaleness allowStale) ModifyStatement(stmt *gorm.Statement) {
+	if stmt.Context == nil {
+		stmt.Context = context.Background()
+	}
+	stmt.Context = context.WithValue(stmt.Context, allowStaleKey{}, time.Duration(maxStaleness))
+}
+
+func (allowStale) Build(clause.Builder) {}
+
+// StaleReadStats counters of queries allowed to read stale data
+type StaleReadStats struct {
+	// Stale count of queries executed with stale read-only transaction
+	Stale int64
+	// Fresh count of queries read from leaders since their tables were written recently
+	Fresh int64
+}
+
+// writeClock keeps time of last writes of tables executed through the db
+type writeClock struct {
+	mu      sync.Mutex
+	started time.Time
+	tables  map[string]time.Time
+	raw     time.Time // last raw statement writing unknown tables
+	stats   StaleReadStats
+}
+
+const servedStaleKey = "ydb:served_stale"
+
+// writtenTables matches tables written by YQL statement
+var writtenTables = regexp.MustCompile("(?i)\\b(?:(?:INSERT|UPSERT|REPLACE)\\s+INTO|UPDATE|DELETE\\s+FROM)\\s+(`[^`]+`|[\\w/.]+)")
+
+func (c *writeClock) registerCallbacks(db *gorm.DB) error {
+	callback := db.Callback()
+	if err := callback.Query().Before("gorm:query").Register("ydb:allow_stale", c.route); err != nil {
+		return err
+	}
+	if err := callback.Row().Before("gorm:row").Register("ydb:allow_stale", c.route); err != nil {
+		return err
+	}
+	if err := callback.Create().After("gorm:create").Register("ydb:write_clock", c.record); err != nil {
+		return err
+	}
+	if err := callback.Update().After("gorm:update").Register("ydb:write_clock", c.record); err != nil {
+		return err
+	}
+	if err := callback.Delete().After("gorm:delete").Register("ydb:write_clock", c.record); err != nil {
+		return err
+	}
+	return callback.Raw().After("gorm:raw").Register("ydb:write_clock", c.record)
+}
+
+func (c *writeClock) record(db *gorm.DB) {
+	if db.DryRun {
+		return
+	}
+	tables := []string{db.Statement.Table}
+	if db.Statement.Table == "" {
+		tables = rawWrittenTables(db.Statement.SQL.String())
+	}
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, table := range tables {
+		if table == "" {
+			c.raw = now
+		} else {
+			c.tables[table] = now
+		}
+	}
+}
+
+// rawWrittenTables returns tables written by raw statement, e.g. by Exec of plugins writing own
+// tables, or empty table when they are unknown, reads and schema statements write no tables
+func rawWrittenTables(sql string) (tables []string) {
+	for _, m := range writtenTables.FindAllStringSubmatch(sql, -1) {
+		tables = append(tables, strings.Trim(m[1], "`"))
+	}
+	if len(tables) > 0 {
+		return tables
+	}
+	switch strings.ToUpper(firstWord(strings.TrimSpace(sql))) {
+	case "SELECT", "CREATE", "ALTER", "DROP":
+		return nil
+	}
+	return []string{""}
+}
+
+// route executes query allowed to read stale data by stale read-only transaction when its tables
+// were not written for allowed staleness
+func (c *writeClock) route(db *gorm.DB) {
+	maxStaleness, ok := db.Statement.Context.Value(allowStaleKey{}).(time.Duration)
+	if !ok || db.Error != nil {
+		return
+	}
+	if _, inTx := db.Statement.ConnPool.(gorm.TxCommitter); inTx {
+		return
+	}
+
+	c.mu.Lock()
+	last := c.raw
+	for _, table := range readTables(db.Statement) {
+		if written := c.tables[table]; written.After(last) {
+			last = written
+		}
+	}
+	c.mu.Unlock()
+
+	if time.Since(last) < maxStaleness {
+		atomic.AddInt64(&c.stats.Fresh, 1)
+		return
+	}
+	atomic.AddInt64(&c.stats.Stale, 1)
+	db.Statement.Context = ydb.WithTxControl(db.Statement.Context,
+		table.TxControl(table.BeginTx(table.WithStaleReadOnly()), table.CommitTx()))
+	if last.Before(c.started) {
+		last = c.started
+	}
+	db.InstanceSet(servedStaleKey, time.Since(last))
+}
+
+// ServedStale reports whether query of db executed with AllowStale read stale data from followers
+//
+//	tx := db.Clauses(ydb.AllowStale(5 * time.Second)).Find(&products)
+//	if ydb.ServedStale(tx) {
+//		w.Header().Set("X-Data-Freshness", "stale")
+//	}
+func ServedStale(db *gorm.DB) bool {
+	_, served := Staleness(db)
+	return served
+}
+
+// Staleness returns time since tables of query of db served stale were last written through the
+// db (or since the db was opened), writes made through the db earlier are visible unless followers
+// lag behind more, writes of other processes may be missed anyway
+//
+//	tx := db.Clauses(ydb.AllowStale(5 * time.Second)).Find(&products)
+//	if staleness, ok := ydb.Staleness(tx); ok {
+//		w.Header().Set("Age", strconv.Itoa(int(staleness.Seconds())))
+//	}
+func Staleness(db *gorm.DB) (staleness time.Duration, served bool) {
+	v, _ := db.InstanceGet(servedStaleKey)
+	staleness, served = v.(time.Duration)
+	return staleness, served
+}
+
+// StaleReads returns counters of queries executed with AllowStale through db
+func StaleReads(db *gorm.DB) StaleReadStats {
+	config := configOf(db)
+	if config == nil || config.writeClock == nil {
+		return StaleReadStats{}
+	}
+	return StaleReadStats{
+		Stale: atomic.LoadInt64(&config.writeClock.stats.Stale),
+		Fresh: atomic.LoadInt64(&config.writeClock.stats.Fresh),
+	}
+}
@@ -0,0 +1,94 @@
+package ydb
+
+import (
+	"context"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/options"
+	"gorm.io/gorm"
+)
+
+// TableStatistics table size and partitioning info
+type TableStatistics struct {
+	Partitions       uint64
+	RowsEstimate     uint64
+	StoreSize        uint64
+	PartitionStats   []PartitionStatistics
+	CreationTime     time.Time
+	ModificationTime time.Time
+}
+
+// PartitionStatistics single partition size info
+type PartitionStatistics struct {
+	RowsEstimate uint64
+	StoreSize    uint64
+}
+
+// TableStats describes table with statistics, tableName is a name relative to database root or an absolute path
+func TableStats(ctx context.Context, db *gorm.DB, tableName string) (*TableStatistics, error) {
+	nativeDriver, err := Unwrap(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var desc options.Description
+	err = nativeDriver.Table().Do(ctx, func(ctx context.Context, s table.Session) (err error) {
+		desc, err = s.DescribeTable(ctx, tablePath(nativeDriver, tableName),
+			options.WithTableStats(),
+			options.WithPartitionStats(),
+		)
+		return err
+	}, table.WithIdempotent())
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &TableStatistics{}
+	if desc.Stats != nil {
+		stats.Partitions = desc.Stats.Partitions
+		stats.RowsEstimate = desc.Stats.RowsEstimate
+		stats.StoreSize = desc.Stats.StoreSize
+		stats.CreationTime = desc.Stats.CreationTime
+		stats.ModificationTime = desc.Stats.ModificationTime
+		for _, p := range desc.Stats.PartitionStats {
+			stats.PartitionStats = append(stats.PartitionStats, PartitionStatistics{
+				RowsEstimate: p.RowsEstimate,
+				StoreSize:    p.StoreSize,
+			})
+		}
+	}
+	return stats, nil
+}
+
+// EstimatedCount returns approximate rows count of model's table from table statistics,
+// falls back to COUNT(*) executed as scan query when statistics are unavailable
+func EstimatedCount(db *gorm.DB, model interface{}) (count int64, err error) {
+	stmt := &gorm.Statement{DB: db}
+	if err = stmt.Parse(model); err != nil {
+		return 0, err
+	}
+
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if stats, err := TableStats(ctx, db, stmt.Table); err == nil && stats.RowsEstimate > 0 {
+		return int64(stats.RowsEstimate), nil
+	}
+
+	err = db.Session(&gorm.Session{NewDB: true, Context: ydb.WithQueryMode(ctx, ydb.ScanQueryMode)}).
+		Table(stmt.Table).Count(&count).Error
+	return count, err
+}
+
+func tablePath(nativeDriver ydb.Connection, table string) string {
+	if strings.HasPrefix(table, "/") {
+		return table
+	}
+	return path.Join(nativeDriver.Name(), table)
+}
@@ -0,0 +1,68 @@
+package ydb
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"gorm.io/gorm"
+)
+
+// Rows is lazy iterator over streamed result, rows are fetched from server
+// while iterating, so memory usage does not depend on result size
+//
+//	rows, err := ydb.Stream(ctx, db.Model(&User{}).Where("age > ?", 18))
+//	if err != nil {
+//		return err
+//	}
+//	defer rows.Close()
+//	for rows.Next() {
+//		var user User
+//		if err := rows.Scan(&user); err != nil {
+//			return err
+//		}
+//	}
+//	return rows.Err()
+type Rows struct {
+	db   *gorm.DB
+	rows *sql.Rows
+}
+
+// Stream executes query built by db as scan query and returns rows iterator. It isn't
+// Stream[T] returning iter.Seq2[T, error]: go.mod targets Go 1.14, which has neither type
+// parameters nor range over functions, so rows are iterated by Next and decoded into models of
+// any type by Scan like with sql.Rows
+func Stream(ctx context.Context, db *gorm.DB) (*Rows, error) {
+	tx := db.WithContext(ydb.WithQueryMode(ctx, ydb.ScanQueryMode))
+	rows, err := tx.Rows()
+	if err != nil {
+		return nil, err
+	}
+	return &Rows{db: tx, rows: rows}, nil
+}
+
+// Next prepares next row for Scan, returns false when rows exhausted or on error
+func (r *Rows) Next() bool {
+	return r.rows.Next()
+}
+
+// Scan decodes current row into dest model
+func (r *Rows) Scan(dest interface{}) error {
+	return r.db.ScanRows(r.rows, dest)
+}
+
+// NextResultSet prepares next result set of multi result query (e.g. script) for reading,
+// returns false when there are no more result sets
+func (r *Rows) NextResultSet() bool {
+	return r.rows.NextResultSet()
+}
+
+// Err returns error occurred during iteration
+func (r *Rows) Err() error {
+	return r.rows.Err()
+}
+
+// Close stops streaming and releases session
+func (r *Rows) Close() error {
+	return r.rows.Close()
+}
@@ -0,0 +1,120 @@
+package ydb
+
+import (
+	"context"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"gorm.io/gorm"
+)
+
+// PartitionStat is row of .sys/partition_stats, counters are accumulated since partition start
+type PartitionStat struct {
+	Path                 string     `gorm:"column:Path"`
+	PartIdx              uint64     `gorm:"column:PartIdx"`
+	DataSize             uint64     `gorm:"column:DataSize"`
+	RowCount             uint64     `gorm:"column:RowCount"`
+	IndexSize            uint64     `gorm:"column:IndexSize"`
+	CPUCores             float64    `gorm:"column:CPUCores"`
+	NodeID               uint32     `gorm:"column:NodeId"`
+	StartTime            *time.Time `gorm:"column:StartTime"`
+	AccessTime           *time.Time `gorm:"column:AccessTime"`
+	UpdateTime           *time.Time `gorm:"column:UpdateTime"`
+	RowReads             uint64     `gorm:"column:RowReads"`
+	RowUpdates           uint64     `gorm:"column:RowUpdates"`
+	RowDeletes           uint64     `gorm:"column:RowDeletes"`
+	RangeReads           uint64     `gorm:"column:RangeReads"`
+	RangeReadRows        uint64     `gorm:"column:RangeReadRows"`
+	InFlightTxCount      uint64     `gorm:"column:InFlightTxCount"`
+	TxRejectedByOverload uint64     `gorm:"column:TxRejectedByOverload"`
+	TxRejectedBySpace    uint64     `gorm:"column:TxRejectedBySpace"`
+	TxCompleteLagMsec    uint64     `gorm:"column:TxCompleteLagMsec"`
+}
+
+// TopQuery is row of .sys/top_queries_* views
+type TopQuery struct {
+	IntervalEnd     time.Time     `gorm:"column:IntervalEnd"`
+	Rank            uint32        `gorm:"column:Rank"`
+	QueryText       string        `gorm:"column:QueryText"`
+	Duration        time.Duration `gorm:"column:Duration"`
+	EndTime         time.Time     `gorm:"column:EndTime"`
+	ReadRows        uint64        `gorm:"column:ReadRows"`
+	ReadBytes       uint64        `gorm:"column:ReadBytes"`
+	UpdateRows      uint64        `gorm:"column:UpdateRows"`
+	UpdateBytes     uint64        `gorm:"column:UpdateBytes"`
+	DeleteRows      uint64        `gorm:"column:DeleteRows"`
+	Partitions      uint64        `gorm:"column:Partitions"`
+	UserSID         string        `gorm:"column:UserSID"`
+	CompileDuration time.Duration `gorm:"column:CompileDuration"`
+	FromQueryCache  bool          `gorm:"column:FromQueryCache"`
+	// CPUTime in microseconds
+	CPUTime uint64 `gorm:"column:CPUTime"`
+}
+
+// QueryMetric is row of .sys/query_metrics_one_minute, metrics of queries with the same text
+// executed in one minute interval, CPU times are in microseconds
+type QueryMetric struct {
+	IntervalEnd   time.Time     `gorm:"column:IntervalEnd"`
+	QueryText     string        `gorm:"column:QueryText"`
+	Count         uint64        `gorm:"column:Count"`
+	SumCPUTime    uint64        `gorm:"column:SumCPUTime"`
+	MaxCPUTime    uint64        `gorm:"column:MaxCPUTime"`
+	SumDuration   time.Duration `gorm:"column:SumDuration"`
+	MaxDuration   time.Duration `gorm:"column:MaxDuration"`
+	SumReadRows   uint64        `gorm:"column:SumReadRows"`
+	SumReadBytes  uint64        `gorm:"column:SumReadBytes"`
+	SumUpdateRows uint64        `gorm:"column:SumUpdateRows"`
+	SumDeleteRows uint64        `gorm:"column:SumDeleteRows"`
+}
+
+// TopQueriesBy is metric ranking queries of TopQueries
+type TopQueriesBy string
+
+const (
+	TopQueriesByDuration  TopQueriesBy = "duration"
+	TopQueriesByCPUTime   TopQueriesBy = "cpu_time"
+	TopQueriesByReadBytes TopQueriesBy = "read_bytes"
+)
+
+// sysQuery returns session of db reading system view by scan query
+func sysQuery(db *gorm.DB, view string) *gorm.DB {
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return db.Session(&gorm.Session{NewDB: true, Context: ydb.WithQueryMode(ctx, ydb.ScanQueryMode)}).Table(view)
+}
+
+// PartitionStats returns partitions of table from .sys/partition_stats, tableName is a name
+// relative to database root or an absolute path, all tables when empty
+func PartitionStats(db *gorm.DB, tableName string) (stats []PartitionStat, err error) {
+	query := sysQuery(db, ".sys/partition_stats")
+	if tableName != "" {
+		nativeDriver, err := Unwrap(db)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("Path = ?", tablePath(nativeDriver, tableName))
+	}
+	return stats, query.Order("Path").Order("PartIdx").Find(&stats).Error
+}
+
+// TopQueries returns the most expensive queries by metric of one minute intervals, or of one
+// hour intervals when hourly, from .sys/top_queries_by_<metric>_one_minute (one_hour) views
+//
+//	queries, err := ydb.TopQueries(db.WithContext(ctx), ydb.TopQueriesByCPUTime, false)
+func TopQueries(db *gorm.DB, by TopQueriesBy, hourly bool) (queries []TopQuery, err error) {
+	interval := "one_minute"
+	if hourly {
+		interval = "one_hour"
+	}
+	return queries, sysQuery(db, ".sys/top_queries_by_"+string(by)+"_"+interval).
+		Order("IntervalEnd DESC").Order("Rank").Find(&queries).Error
+}
+
+// QueryMetrics returns metrics of queries from .sys/query_metrics_one_minute since time,
+// the most CPU consuming first
+func QueryMetrics(db *gorm.DB, since time.Time) (metrics []QueryMetric, err error) {
+	return metrics, sysQuery(db, ".sys/query_metrics_one_minute").
+		Where("IntervalEnd >= ?", since).Order("SumCPUTime DESC").Find(&metrics).Error
+}
@@ -0,0 +1,157 @@
+package ydb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrTenantRequired returned by statements without tenant in context when it is required
+	ErrTenantRequired = errors.New("ydb: tenant is required")
+	// ErrInvalidTenant returned for tenants which are not valid path segments
+	ErrInvalidTenant = errors.New("ydb: invalid tenant")
+)
+
+type tenantKey struct{}
+
+// WithTenant returns context of requests of tenant
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns tenant of context set by WithTenant
+func TenantFromContext(ctx context.Context) (tenant string, ok bool) {
+	if ctx == nil {
+		return "", false
+	}
+	tenant, ok = ctx.Value(tenantKey{}).(string)
+	return tenant, ok
+}
+
+// TenantPaths is a gorm plugin isolating tenants by table paths: tables of statements executed
+// with tenant in context (see WithTenant) are replaced by tables of tenant directory,
+// e.g. users by tenants/42/users, including queries of Row and Rows. SQL of Raw and Exec isn't
+// parsed, so it's neither rewritten nor checked for Required tenant, and tables of joined
+// associations are not rewritten
+//
+//	db.Use(&ydb.TenantPaths{Required: true, Shared: []string{"tenants"}})
+//	ydb.ProvisionTenant(ctx, db, "42", &User{}, &Order{})
+//	db.WithContext(ydb.WithTenant(ctx, "42")).Find(&users)
+type TenantPaths struct {
+	// Template of table path relative to database, {tenant} and {table} are replaced by tenant
+	// and table name, defaults to tenants/{tenant}/{table}
+	Template string
+	// Required fails statements without tenant in context with ErrTenantRequired
+	Required bool
+	// Shared tables are common for all tenants and never rewritten
+	Shared []string
+}
+
+const tenantPathsKey = "ydb:tenant_paths"
+
+func (p *TenantPaths) Name() string {
+	return tenantPathsKey
+}
+
+func (p *TenantPaths) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+	if err := callback.Create().Before("*").Register(tenantPathsKey, p.rewrite); err != nil {
+		return err
+	}
+	if err := callback.Query().Before("*").Register(tenantPathsKey, p.rewrite); err != nil {
+		return err
+	}
+	if err := callback.Row().Before("*").Register(tenantPathsKey, p.rewrite); err != nil {
+		return err
+	}
+	if err := callback.Update().Before("*").Register(tenantPathsKey, p.rewrite); err != nil {
+		return err
+	}
+	return callback.Delete().Before("*").Register(tenantPathsKey, p.rewrite)
+}
+
+// Path returns path of table of tenant
+func (p *TenantPaths) Path(tenant, table string) (string, error) {
+	if tenant == "" || strings.ContainsAny(tenant, "/.`") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidTenant, tenant)
+	}
+	template := p.Template
+	if template == "" {
+		template = "tenants/{tenant}/{table}"
+	}
+	return strings.NewReplacer("{tenant}", tenant, "{table}", table).Replace(template), nil
+}
+
+func (p *TenantPaths) shared(table string) bool {
+	for _, shared := range p.Shared {
+		if shared == table {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *TenantPaths) rewrite(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Table == "" || db.Statement.TableExpr != nil || p.shared(db.Statement.Table) {
+		return
+	}
+	if db.Statement.SQL.Len() > 0 {
+		// SQL of Raw executed by Row
+		return
+	}
+	if _, ok := db.InstanceGet(tenantPathsKey); ok {
+		return
+	}
+	tenant, ok := TenantFromContext(db.Statement.Context)
+	if !ok {
+		if p.Required {
+			db.AddError(fmt.Errorf("%w: table %s", ErrTenantRequired, db.Statement.Table))
+		}
+		return
+	}
+	table, err := p.Path(tenant, db.Statement.Table)
+	if db.AddError(err) != nil {
+		return
+	}
+	db.Statement.Table = table
+	db.InstanceSet(tenantPathsKey, true)
+}
+
+// ProvisionTenant creates directory of tenant and migrates tables of models in it,
+// db must use TenantPaths plugin
+func ProvisionTenant(ctx context.Context, db *gorm.DB, tenant string, models ...interface{}) error {
+	p, ok := db.Config.Plugins[tenantPathsKey].(*TenantPaths)
+	if !ok {
+		return errors.New("ydb: TenantPaths plugin is not used")
+	}
+	nativeDriver, err := Unwrap(db)
+	if err != nil {
+		return err
+	}
+	db = db.Session(&gorm.Session{NewDB: true, Context: ctx})
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return err
+		}
+		if p.shared(stmt.Table) {
+			continue
+		}
+		table, err := p.Path(tenant, stmt.Table)
+		if err != nil {
+			return err
+		}
+		if err := nativeDriver.Scheme().MakeDirectory(ctx, path.Dir(tablePath(nativeDriver, table))); err != nil {
+			return err
+		}
+		if err := db.Table(table).AutoMigrate(model); err != nil {
+			return err
+		}
+	}
+	return nil
+}
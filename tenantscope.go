@@ -0,0 +1,222 @@
+package ydb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrCrossTenant returned on create of row of other tenant than tenant of context
+var ErrCrossTenant = errors.New("ydb: row belongs to other tenant")
+
+// TenantScope is a gorm plugin isolating tenants by rows: queries, updates and deletes of models
+// with tenant column are restricted by condition on tenant of context (see WithTenant) and
+// creates set tenant column. Tables of associations with tenant column joined by Joins are
+// restricted by the tenant too, queries of Preload are restricted as queries of their models,
+// updates changing tenant column to other tenant fail with ErrCrossTenant. Statements of such models without tenant in context fail with
+// ErrTenantRequired unless AllTenants is used. SQL of Raw and Exec isn't parsed, so it's neither
+// restricted nor checked for tenant, also when executed by Row or Scan
+//
+//	type Order struct {
+//		TenantID string `gorm:"primaryKey;tenant"`
+//		ID       uint64 `gorm:"primaryKey"`
+//	}
+//
+//	db.Use(&ydb.TenantScope{})
+//	db.WithContext(ydb.WithTenant(ctx, "42")).Find(&orders) // ... WHERE tenant_id = "42"
+type TenantScope struct {
+	// Column of tenant of models without field tagged with tenant, defaults to tenant_id
+	Column string
+}
+
+const tenantScopeKey = "ydb:tenant_scope"
+
+// AllTenants returns statement modifier disabling TenantScope for the statement,
+// e.g. for administrative reports over all tenants
+//
+//	db.Clauses(ydb.AllTenants()).Find(&orders)
+func AllTenants() clause.Expression {
+	return allTenants{}
+}
+
+type allTenants struct{}
+
+type allTenantsKey struct{}
+
+func (allTenants) ModifyStatement(stmt *gorm.Statement) {
+	if stmt.Context == nil {
+		stmt.Context = context.Background()
+	}
+	stmt.Context = context.WithValue(stmt.Context, allTenantsKey{}, true)
+}
+
+func (allTenants) Build(clause.Builder) {}
+
+func (s *TenantScope) Name() string {
+	return tenantScopeKey
+}
+
+func (s *TenantScope) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+	if err := callback.Create().Before("gorm:create").Register(tenantScopeKey, s.assign); err != nil {
+		return err
+	}
+	if err := callback.Query().Before("gorm:query").Register(tenantScopeKey, s.restrict); err != nil {
+		return err
+	}
+	if err := callback.Row().Before("gorm:row").Register(tenantScopeKey, s.restrict); err != nil {
+		return err
+	}
+	if err := callback.Update().Before("gorm:update").Register(tenantScopeKey, s.restrictUpdate); err != nil {
+		return err
+	}
+	return callback.Delete().Before("gorm:delete").Register(tenantScopeKey, s.restrict)
+}
+
+// tenantField returns field of tenant of schema
+func (s *TenantScope) tenantField(sch *schema.Schema) *schema.Field {
+	if sch == nil {
+		return nil
+	}
+	for _, field := range sch.Fields {
+		if _, ok := field.TagSettings["TENANT"]; ok {
+			return field
+		}
+	}
+	column := s.Column
+	if column == "" {
+		column = "tenant_id"
+	}
+	return sch.LookUpField(column)
+}
+
+// tenant returns tenant of statement converted to type of field, scoped is false for statements
+// not restricted by tenant
+func (s *TenantScope) tenant(db *gorm.DB) (field *schema.Field, tenant interface{}, scoped bool) {
+	if db.Error != nil || db.Statement.SQL.Len() > 0 {
+		return nil, nil, false
+	}
+	if field = s.tenantField(db.Statement.Schema); field == nil {
+		return nil, nil, false
+	}
+	ctx := db.Statement.Context
+	if all, _ := ctx.Value(allTenantsKey{}).(bool); all {
+		return nil, nil, false
+	}
+	id, ok := TenantFromContext(ctx)
+	if !ok {
+		db.AddError(fmt.Errorf("%w: table %s", ErrTenantRequired, db.Statement.Table))
+		return nil, nil, false
+	}
+
+	rv := reflect.New(db.Statement.Schema.ModelType).Elem()
+	if err := field.Set(ctx, rv, id); err != nil {
+		db.AddError(err)
+		return nil, nil, false
+	}
+	tenant, _ = field.ValueOf(ctx, rv)
+	return field, tenant, true
+}
+
+// restrict restricts statement by tenant of context
+func (s *TenantScope) restrict(db *gorm.DB) {
+	if field, tenant, scoped := s.tenant(db); scoped {
+		s.scope(db, field, tenant)
+	}
+}
+
+// scope adds condition on tenant column of field to statement and to joins of associations with
+// tenant column
+func (s *TenantScope) scope(db *gorm.DB, field *schema.Field, tenant interface{}) {
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: tenant},
+	}})
+
+	for i, join := range db.Statement.Joins {
+		rel, ok := db.Statement.Schema.Relationships.Relations[join.Name]
+		if !ok {
+			continue
+		}
+		joined := s.tenantField(rel.FieldSchema)
+		if joined == nil {
+			continue
+		}
+		// conditions of join are built with joined table as current one
+		on := clause.Where{}
+		if join.On != nil {
+			on.Exprs = append(on.Exprs, join.On.Exprs...)
+		}
+		on.Exprs = append(on.Exprs, clause.Eq{
+			Column: clause.Column{Table: clause.CurrentTable, Name: joined.DBName}, Value: tenant,
+		})
+		db.Statement.Joins[i].On = &on
+	}
+}
+
+// restrictUpdate restricts update by tenant, updates assigning other tenant are rejected
+func (s *TenantScope) restrictUpdate(db *gorm.DB) {
+	field, tenant, scoped := s.tenant(db)
+	if !scoped {
+		return
+	}
+	ctx := db.Statement.Context
+	switch dest := db.Statement.Dest.(type) {
+	case map[string]interface{}:
+		for column, v := range dest {
+			if f := db.Statement.Schema.LookUpField(column); f != field {
+				continue
+			}
+			rv := reflect.New(db.Statement.Schema.ModelType).Elem()
+			if err := field.Set(ctx, rv, v); err != nil {
+				db.AddError(fmt.Errorf("%w: %s is set to %v", ErrCrossTenant, field.DBName, v))
+				return
+			}
+			if assigned, _ := field.ValueOf(ctx, rv); !reflect.DeepEqual(assigned, tenant) {
+				db.AddError(fmt.Errorf("%w: %s is set to %v", ErrCrossTenant, field.DBName, v))
+				return
+			}
+		}
+	default:
+		rv := reflect.Indirect(reflect.ValueOf(dest))
+		if rv.Kind() == reflect.Struct && rv.Type() == db.Statement.Schema.ModelType {
+			if v, isZero := field.ValueOf(ctx, rv); !isZero && !reflect.DeepEqual(v, tenant) {
+				db.AddError(fmt.Errorf("%w: %s is set to %v", ErrCrossTenant, field.DBName, v))
+				return
+			}
+		}
+	}
+	s.scope(db, field, tenant)
+}
+
+// assign sets tenant column of created rows, rows of other tenants are rejected
+func (s *TenantScope) assign(db *gorm.DB) {
+	field, tenant, scoped := s.tenant(db)
+	if !scoped {
+		return
+	}
+	ctx := db.Statement.Context
+	set := func(rv reflect.Value) {
+		v, isZero := field.ValueOf(ctx, rv)
+		switch {
+		case isZero:
+			db.AddError(field.Set(ctx, rv, tenant))
+		case !reflect.DeepEqual(v, tenant):
+			db.AddError(fmt.Errorf("%w: %v", ErrCrossTenant, v))
+		}
+	}
+	switch rv := reflect.Indirect(db.Statement.ReflectValue); rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len() && db.Error == nil; i++ {
+			if elem := reflect.Indirect(rv.Index(i)); elem.Kind() == reflect.Struct {
+				set(elem)
+			}
+		}
+	case reflect.Struct:
+		set(rv)
+	}
+}
@@ -0,0 +1,125 @@
+package ydb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+type scopedCustomer struct {
+	TenantID string `gorm:"primaryKey;tenant"`
+	ID       int64  `gorm:"primaryKey;autoIncrement:false"`
+	Name     string
+}
+
+type scopedOrder struct {
+	TenantID   string `gorm:"primaryKey;tenant"`
+	ID         int64  `gorm:"primaryKey;autoIncrement:false"`
+	CustomerID int64
+	Customer   scopedCustomer `gorm:"foreignKey:TenantID,CustomerID;references:TenantID,ID"`
+	Amount     int64
+}
+
+func openTenantScopeDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := openDryRunDB(t, Config{})
+	if err := db.Use(&TenantScope{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestTenantScope(t *testing.T) {
+	db := openTenantScopeDB(t).WithContext(WithTenant(context.Background(), "42"))
+	for _, tt := range []struct {
+		name  string
+		query func(tx *gorm.DB) *gorm.DB
+		want  string
+		vars  string
+	}{
+		{"query", func(tx *gorm.DB) *gorm.DB {
+			return tx.Where("amount > ?", 10).Find(&[]scopedOrder{})
+		}, "SELECT * FROM `scoped_orders` WHERE amount > ? AND `scoped_orders`.`tenant_id` = ?", "[10 42]"},
+		{"update", func(tx *gorm.DB) *gorm.DB {
+			return tx.Model(&scopedOrder{}).Where("id = ?", 1).Update("amount", 20)
+		}, "UPDATE `scoped_orders` SET `amount`=? WHERE id = ? AND `scoped_orders`.`tenant_id` = ?", "[20 1 42]"},
+		{"delete", func(tx *gorm.DB) *gorm.DB {
+			return tx.Where("id = ?", 1).Delete(&scopedOrder{})
+		}, "DELETE FROM `scoped_orders` WHERE id = ? AND `scoped_orders`.`tenant_id` = ?", "[1 42]"},
+		{"join", func(tx *gorm.DB) *gorm.DB {
+			return tx.Joins("Customer").Find(&[]scopedOrder{})
+		}, "SELECT `scoped_orders`.`tenant_id`,`scoped_orders`.`id`,`scoped_orders`.`customer_id`,`scoped_orders`.`amount`,`Customer`.`tenant_id` AS `Customer__tenant_id`,`Customer`.`id` AS `Customer__id`,`Customer`.`name` AS `Customer__name` FROM `scoped_orders` AS `scoped_orders` LEFT JOIN `scoped_customers` AS `Customer` ON `scoped_orders`.`tenant_id` = `Customer`.`tenant_id` AND `scoped_orders`.`customer_id` = `Customer`.`id` AND `Customer`.`tenant_id` = ? WHERE `scoped_orders`.`tenant_id` = ?", "[42 42]"},
+		{"all tenants", func(tx *gorm.DB) *gorm.DB {
+			return tx.Clauses(AllTenants()).Find(&[]scopedOrder{})
+		}, "SELECT * FROM `scoped_orders`", "[]"},
+	} {
+		tx := tt.query(db)
+		if tx.Error != nil {
+			t.Errorf("%s: %v", tt.name, tx.Error)
+		}
+		if got := tx.Statement.SQL.String(); got != tt.want {
+			t.Errorf("%s:\n got %s\nwant %s", tt.name, got, tt.want)
+		}
+		if vars := fmt.Sprint(tx.Statement.Vars); vars != tt.vars {
+			t.Errorf("%s: vars %s, want %s", tt.name, vars, tt.vars)
+		}
+	}
+}
+
+func TestTenantScopeCreate(t *testing.T) {
+	db := openTenantScopeDB(t).WithContext(WithTenant(context.Background(), "42"))
+
+	order := scopedOrder{ID: 1, Amount: 10}
+	if err := db.Create(&order).Error; err != nil {
+		t.Fatal(err)
+	}
+	if order.TenantID != "42" {
+		t.Errorf("tenant of created order %q, want 42", order.TenantID)
+	}
+
+	orders := []scopedOrder{{TenantID: "42", ID: 2}, {TenantID: "7", ID: 3}}
+	if err := db.Create(&orders).Error; !errors.Is(err, ErrCrossTenant) {
+		t.Errorf("create of order of other tenant: %v, want ErrCrossTenant", err)
+	}
+	if err := db.Clauses(AllTenants()).Create(&scopedOrder{TenantID: "7", ID: 4}).Error; err != nil {
+		t.Errorf("create of order of other tenant with AllTenants: %v", err)
+	}
+}
+
+func TestTenantScopeUpdateTenant(t *testing.T) {
+	db := openTenantScopeDB(t).WithContext(WithTenant(context.Background(), "42"))
+	for name, update := range map[string]func(tx *gorm.DB) *gorm.DB{
+		"column": func(tx *gorm.DB) *gorm.DB {
+			return tx.Model(&scopedOrder{}).Where("id = ?", 1).Update("tenant_id", "7")
+		},
+		"map": func(tx *gorm.DB) *gorm.DB {
+			return tx.Model(&scopedOrder{}).Where("id = ?", 1).Updates(map[string]interface{}{"TenantID": "7"})
+		},
+		"struct": func(tx *gorm.DB) *gorm.DB {
+			return tx.Model(&scopedOrder{}).Where("id = ?", 1).Updates(&scopedOrder{TenantID: "7", Amount: 1})
+		},
+	} {
+		if err := update(db).Error; !errors.Is(err, ErrCrossTenant) {
+			t.Errorf("%s: %v, want ErrCrossTenant", name, err)
+		}
+	}
+	if err := db.Model(&scopedOrder{}).Where("id = ?", 1).Update("tenant_id", "42").Error; err != nil {
+		t.Errorf("update of tenant to the same tenant: %v", err)
+	}
+}
+
+func TestTenantScopeRequired(t *testing.T) {
+	db := openTenantScopeDB(t)
+	if err := db.Find(&[]scopedOrder{}).Error; !errors.Is(err, ErrTenantRequired) {
+		t.Errorf("query without tenant: %v, want ErrTenantRequired", err)
+	}
+	if err := db.Clauses(AllTenants()).Find(&[]scopedOrder{}).Error; err != nil {
+		t.Errorf("query of all tenants without tenant: %v", err)
+	}
+	if err := db.Find(&[]joinUser{}).Error; err != nil {
+		t.Errorf("query of model without tenant column: %v", err)
+	}
+}
@@ -0,0 +1,170 @@
+package ydb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb"
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WithTimeout returns statement modifier forwarding timeout to YDB operation parameters
+// (operation timeout and cancel after), so server cancels query running longer than d,
+// it overrides Config.OperationTimeout and Config.OperationCancelAfter
+//
+//	db.Clauses(ydb.WithTimeout(500 * time.Millisecond)).Find(&users)
+func WithTimeout(d time.Duration) clause.Expression {
+	return operationTimeout(d)
+}
+
+type operationTimeout time.Duration
+
+func (t operationTimeout) ModifyStatement(stmt *gorm.Statement) {
+	stmt.Context = withOperationTimeouts(stmt.Context, time.Duration(t), time.Duration(t))
+}
+
+func (t operationTimeout) Build(clause.Builder) {}
+
+type operationTimeoutKey struct{}
+
+func withOperationTimeouts(ctx context.Context, timeout, cancelAfter time.Duration) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if timeout > 0 {
+		ctx = ydb.WithOperationTimeout(ctx, timeout)
+	}
+	if cancelAfter > 0 {
+		ctx = ydb.WithOperationCancelAfter(ctx, cancelAfter)
+	}
+	return context.WithValue(ctx, operationTimeoutKey{}, true)
+}
+
+// operationContext applies default operation timeouts of config unless statement sets its own
+func (config *Config) operationContext(ctx context.Context) context.Context {
+	if config == nil || (config.OperationTimeout <= 0 && config.OperationCancelAfter <= 0) {
+		return ctx
+	}
+	if ctx.Value(operationTimeoutKey{}) != nil {
+		return ctx
+	}
+	return withOperationTimeouts(ctx, config.OperationTimeout, config.OperationCancelAfter)
+}
+
+// deadlineContext returns ctx with default timeout of config for reads, writes or scheme
+// statements by kind of query, unless ctx has deadline. Cancel must be called after results of
+// query are read
+func (config *Config) deadlineContext(ctx context.Context, query string) (context.Context, context.CancelFunc) {
+	if config == nil || config.ReadTimeout <= 0 && config.WriteTimeout <= 0 && config.DDLTimeout <= 0 {
+		return ctx, func() {}
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	var timeout time.Duration
+	switch leadingKeyword(query) {
+	case "SELECT", "EXPLAIN":
+		timeout = config.ReadTimeout
+	case "CREATE", "ALTER", "DROP":
+		timeout = config.DDLTimeout
+	default:
+		timeout = config.WriteTimeout
+	}
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// leadingKeyword returns first keyword of query in upper case, skipping comments, DECLARE and
+// PRAGMA statements
+func leadingKeyword(query string) string {
+	for {
+		query = strings.TrimLeft(query, " \t\r\n;")
+		switch {
+		case strings.HasPrefix(query, "--"):
+			if i := strings.IndexByte(query, '\n'); i >= 0 {
+				query = query[i:]
+				continue
+			}
+			return ""
+		case strings.HasPrefix(query, "/*"):
+			if i := strings.Index(query, "*/"); i >= 0 {
+				query = query[i+2:]
+				continue
+			}
+			return ""
+		}
+		end := strings.IndexFunc(query, func(r rune) bool { return !unicode.IsLetter(r) })
+		if end < 0 {
+			end = len(query)
+		}
+		keyword := strings.ToUpper(query[:end])
+		if keyword != "DECLARE" && keyword != "PRAGMA" {
+			return keyword
+		}
+		i := strings.IndexByte(query, ';')
+		if i < 0 {
+			return ""
+		}
+		query = query[i:]
+	}
+}
+
+var (
+	// ErrQueryTimeout matches errors of statements exceeded deadline of context or cancelled by
+	// server after operation timeout
+	ErrQueryTimeout = errors.New("ydb: query timed out")
+	// ErrClientCanceled matches errors of statements whose context was canceled by client
+	ErrClientCanceled = errors.New("ydb: query canceled by client")
+)
+
+// QueryError is error of statement which timed out or was canceled, it matches ErrQueryTimeout
+// or ErrClientCanceled and wraps original error
+//
+//	var queryErr *ydb.QueryError
+//	if errors.Is(err, ydb.ErrQueryTimeout) && errors.As(err, &queryErr) {
+//		slowQueries.WithLabelValues(queryErr.Fingerprint).Inc()
+//	}
+type QueryError struct {
+	// Kind is ErrQueryTimeout or ErrClientCanceled
+	Kind error
+	// Fingerprint of statement, see Fingerprint
+	Fingerprint string
+	Err         error
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("%v (query %s): %v", e.Kind, e.Fingerprint, e.Err)
+}
+
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
+
+func (e *QueryError) Is(target error) bool {
+	return target == e.Kind
+}
+
+// queryError wraps err of query executed with ctx into QueryError when it timed out or was canceled
+func queryError(ctx context.Context, query string, err error) error {
+	var kind error
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, context.Canceled) || ctx.Err() == context.Canceled:
+		kind = ErrClientCanceled
+	case errors.Is(err, context.DeadlineExceeded) || ctx.Err() == context.DeadlineExceeded,
+		ydb.IsOperationError(err, Ydb.StatusIds_TIMEOUT, Ydb.StatusIds_CANCELLED):
+		kind = ErrQueryTimeout
+	default:
+		return err
+	}
+	return &QueryError{Kind: kind, Fingerprint: Fingerprint(query), Err: err}
+}
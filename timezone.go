@@ -0,0 +1,50 @@
+package ydb
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+)
+
+// parseTimeZone returns location of TimeZone (or time_zone) DSN parameter, nil if not specified
+func parseTimeZone(dsn string) (*time.Location, error) {
+	result := timeZoneMatcher.FindStringSubmatch(dsn)
+	if len(result) <= 2 || result[2] == "" {
+		return nil, nil
+	}
+	name, err := url.QueryUnescape(result[2])
+	if err != nil {
+		return nil, err
+	}
+	return time.LoadLocation(name)
+}
+
+// convertTime converts time parameters to configured time zone and Tz types
+func (config *Config) convertTime(v interface{}) interface{} {
+	if config.location == nil && !config.UseTzTypes {
+		return v
+	}
+	switch t := v.(type) {
+	case time.Time:
+		return config.timeValue(t)
+	case *time.Time:
+		if t != nil {
+			return config.timeValue(*t)
+		}
+		if config.UseTzTypes {
+			return types.NullValue(types.TypeTzTimestamp)
+		}
+	}
+	return v
+}
+
+func (config *Config) timeValue(t time.Time) interface{} {
+	if config.location != nil {
+		t = t.In(config.location)
+	}
+	if config.UseTzTypes {
+		return types.TzTimestampValueFromTime(t)
+	}
+	return t
+}
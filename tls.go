@@ -0,0 +1,62 @@
+package ydb
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+)
+
+// tlsOptions returns option of TLS config of native driver built from TLS, CA and client
+// certificate files of config or of ca_file, cert_file and key_file DSN parameters
+func (config *Config) tlsOptions() ([]ydb.Option, error) {
+	caFile, certFile, keyFile := config.CAFile, config.CertFile, config.KeyFile
+	if uri, err := url.Parse(config.DSN); err == nil {
+		query := uri.Query()
+		if caFile == "" {
+			caFile = query.Get("ca_file")
+		}
+		if certFile == "" {
+			certFile = query.Get("cert_file")
+		}
+		if keyFile == "" {
+			keyFile = query.Get("key_file")
+		}
+	}
+	if config.TLS == nil && caFile == "" && certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if config.TLS != nil {
+		tlsConfig = config.TLS.Clone()
+	}
+	if caFile != "" {
+		pem, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("ydb: failed to read CA file: %w", err)
+		}
+		if tlsConfig.RootCAs == nil {
+			if tlsConfig.RootCAs, err = x509.SystemCertPool(); err != nil {
+				tlsConfig.RootCAs = x509.NewCertPool()
+			}
+		}
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("ydb: no certificates in CA file %s", caFile)
+		}
+	}
+	if certFile != "" || keyFile != "" {
+		if certFile == "" || keyFile == "" {
+			return nil, fmt.Errorf("ydb: client certificate requires both cert_file and key_file")
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("ydb: failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = append(tlsConfig.Certificates, cert)
+	}
+	return []ydb.Option{ydb.WithTLSConfig(tlsConfig)}, nil
+}
@@ -0,0 +1,99 @@
+package ydb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/topic/topicoptions"
+	"github.com/ydb-platform/ydb-go-sdk/v3/topic/topicreader"
+	"github.com/ydb-platform/ydb-go-sdk/v3/topic/topicsugar"
+	"github.com/ydb-platform/ydb-go-sdk/v3/topic/topicwriter"
+	"gorm.io/gorm"
+)
+
+// TopicWriter writes models serialized as JSON to topic through native driver of db,
+// values are interface{} instead of type parameter, because module targets Go without generics
+//
+//	w, err := ydb.NewTopicWriter(db, "orders-service", "orders/events")
+//	err = w.Write(ctx, &OrderCreated{ID: 1})
+type TopicWriter struct {
+	writer *topicwriter.Writer
+}
+
+// NewTopicWriter starts write session to topic at path
+func NewTopicWriter(db *gorm.DB, producerID, path string, opts ...topicoptions.WriterOption) (*TopicWriter, error) {
+	nativeDriver, err := Unwrap(db)
+	if err != nil {
+		return nil, err
+	}
+	writer, err := nativeDriver.Topic().StartWriter(producerID, path, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &TopicWriter{writer: writer}, nil
+}
+
+// Write serializes values as JSON and writes them to topic as separate messages
+func (w *TopicWriter) Write(ctx context.Context, values ...interface{}) error {
+	messages := make([]topicwriter.Message, 0, len(values))
+	for _, value := range values {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		messages = append(messages, topicwriter.Message{Data: bytes.NewReader(data)})
+	}
+	return w.writer.Write(ctx, messages...)
+}
+
+// Close flushes written messages and stops write session
+func (w *TopicWriter) Close(ctx context.Context) error {
+	return w.writer.Close(ctx)
+}
+
+// TopicReader reads models serialized as JSON from topic through native driver of db
+//
+//	r, err := ydb.NewTopicReader(db, "billing", "orders/events")
+//	var event OrderCreated
+//	msg, err := r.Read(ctx, &event)
+//	err = r.Commit(ctx, msg)
+type TopicReader struct {
+	reader *topicreader.Reader
+}
+
+// NewTopicReader starts read session of consumer from topic at path
+func NewTopicReader(db *gorm.DB, consumer, path string, opts ...topicoptions.ReaderOption) (*TopicReader, error) {
+	nativeDriver, err := Unwrap(db)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := nativeDriver.Topic().StartReader(consumer, topicoptions.ReadTopic(path), opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &TopicReader{reader: reader}, nil
+}
+
+// Read waits for next message and decodes it into dest (pointer to model),
+// returned message should be committed after processing
+func (r *TopicReader) Read(ctx context.Context, dest interface{}) (*topicreader.Message, error) {
+	msg, err := r.reader.ReadMessage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = topicsugar.JSONUnmarshal(msg, dest); err != nil {
+		return msg, err
+	}
+	return msg, nil
+}
+
+// Commit marks message as processed by consumer
+func (r *TopicReader) Commit(ctx context.Context, msg *topicreader.Message) error {
+	return r.reader.Commit(ctx, msg)
+}
+
+// Close stops read session
+func (r *TopicReader) Close(ctx context.Context) error {
+	return r.reader.Close(ctx)
+}
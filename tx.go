@@ -0,0 +1,328 @@
+package ydb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/options"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result/indexed"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+	"gorm.io/gorm"
+)
+
+// ErrNestedTx returned on begin of transaction inside of transaction opened by Begin
+var ErrNestedTx = errors.New("ydb: nested transactions are not supported")
+
+// Tx is interactive serializable read-write transaction, which may be passed across function
+// boundaries and commits with the last statement in single round trip
+//
+//	tx, err := ydb.Begin(ctx, db)
+//	if err != nil {
+//		return err
+//	}
+//	defer tx.Rollback(ctx)
+//	if err := reserve(tx.DB(), order); err != nil {
+//		return err
+//	}
+//	return tx.CommitWith(func(db *gorm.DB) error {
+//		return db.Create(&order).Error
+//	})
+type Tx struct {
+	db   *gorm.DB
+	conn *sql.Conn
+	tx   *interactiveTx
+	done bool
+	// afterCommit called after successful commit
+	afterCommit []func()
+}
+
+// transactions opened by Begin by their connections
+var transactions sync.Map
+
+// txOf returns transaction opened by Begin executing statements of pool or nil
+func txOf(pool gorm.ConnPool) *Tx {
+	conn, ok := pool.(*sql.Conn)
+	if !ok {
+		return nil
+	}
+	if tx, ok := transactions.Load(conn); ok {
+		return tx.(*Tx)
+	}
+	return nil
+}
+
+// Begin opens interactive transaction on dedicated session, statements of Tx.DB are executed
+// in the transaction until it is committed or rolled back
+func Begin(ctx context.Context, db *gorm.DB) (*Tx, error) {
+	nativeDriver, err := Unwrap(db)
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlConn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	session, err := nativeDriver.Table().CreateSession(ctx) //nolint:staticcheck
+	if err != nil {
+		_ = sqlConn.Close()
+		return nil, err
+	}
+	transaction, err := session.BeginTransaction(ctx, table.TxSettings(table.WithSerializableReadWrite()))
+	if err != nil {
+		_ = session.Close(ctx)
+		_ = sqlConn.Close()
+		return nil, err
+	}
+
+	tx := &Tx{conn: sqlConn, tx: &interactiveTx{session: session, tx: transaction}}
+	if err := tx.attach(tx.tx); err != nil {
+		_ = transaction.Rollback(ctx)
+		_ = session.Close(ctx)
+		_ = sqlConn.Close()
+		return nil, err
+	}
+	tx.db = db.Session(&gorm.Session{Context: ctx, SkipDefaultTransaction: true})
+	tx.db.Statement.ConnPool = sqlConn
+	transactions.Store(sqlConn, tx)
+	return tx, nil
+}
+
+// DB returns db executing statements in the transaction
+func (tx *Tx) DB() *gorm.DB {
+	return tx.db
+}
+
+// Commit commits transaction
+func (tx *Tx) Commit(ctx context.Context) error {
+	if tx.done {
+		return sql.ErrTxDone
+	}
+	var err error
+	if !tx.tx.committed {
+		_, err = tx.tx.tx.CommitTx(ctx)
+	}
+	if err == nil {
+		tx.runAfterCommit()
+	}
+	return tx.close(ctx, err)
+}
+
+// CommitWith executes statement of fc with commit of transaction, so commit doesn't need separate
+// round trip. fc is expected to execute single statement, transaction is committed by separate
+// request when fc executes none, statements executed after the first one fail with sql.ErrTxDone
+func (tx *Tx) CommitWith(fc func(db *gorm.DB) error) error {
+	if tx.done {
+		return sql.ErrTxDone
+	}
+	ctx := tx.db.Statement.Context
+	tx.tx.commitNext = true
+	err := fc(tx.db)
+	tx.tx.commitNext = false
+	switch {
+	case err != nil && !tx.tx.committed:
+		_ = tx.tx.tx.Rollback(ctx)
+	case err == nil && !tx.tx.committed:
+		_, err = tx.tx.tx.CommitTx(ctx)
+	}
+	if err == nil {
+		tx.runAfterCommit()
+	}
+	return tx.close(ctx, err)
+}
+
+// Rollback rolls transaction back, it is no-op for committed transaction
+func (tx *Tx) Rollback(ctx context.Context) error {
+	if tx.done {
+		return sql.ErrTxDone
+	}
+	var err error
+	if !tx.tx.committed {
+		err = tx.tx.tx.Rollback(ctx)
+	}
+	return tx.close(ctx, err)
+}
+
+// runAfterCommit calls afterCommit of committed transaction
+func (tx *Tx) runAfterCommit() {
+	for _, f := range tx.afterCommit {
+		f()
+	}
+}
+
+// close releases session and connection of transaction
+func (tx *Tx) close(ctx context.Context, err error) error {
+	tx.done = true
+	tx.afterCommit = nil
+	transactions.Delete(tx.conn)
+	if detachErr := tx.attach(nil); err == nil {
+		err = detachErr
+	}
+	if closeErr := tx.tx.session.Close(ctx); err == nil {
+		err = closeErr
+	}
+	if closeErr := tx.conn.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// attach routes statements of connection of tx to itx
+func (tx *Tx) attach(itx *interactiveTx) error {
+	return tx.conn.Raw(func(driverConn interface{}) error {
+		c, ok := driverConn.(*conn)
+		if !ok {
+			return fmt.Errorf("ydb: unsupported connection %T, transaction requires connection opened by dialector", driverConn)
+		}
+		c.tx = itx
+		return nil
+	})
+}
+
+// interactiveTx executes statements of connection in transaction of native session
+type interactiveTx struct {
+	session    table.ClosableSession
+	tx         table.Transaction
+	commitNext bool
+	committed  bool
+}
+
+func (t *interactiveTx) execute(ctx context.Context, query string, args []driver.NamedValue) (result.Result, error) {
+	if t.committed {
+		return nil, sql.ErrTxDone
+	}
+	params, err := queryParams(args)
+	if err != nil {
+		return nil, err
+	}
+	var opts []options.ExecuteDataQueryOption
+	if t.commitNext {
+		opts = append(opts, options.WithCommit())
+	}
+	res, err := t.tx.Execute(ctx, query, params, opts...)
+	if err != nil {
+		return nil, err
+	}
+	t.committed = t.commitNext
+	return res, res.Err()
+}
+
+func (t *interactiveTx) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	res, err := t.execute(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return driver.ResultNoRows, res.Close()
+}
+
+func (t *interactiveTx) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	res, err := t.execute(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &txRows{result: res}, nil
+}
+
+// queryParams converts args checked by native driver to query parameters
+func queryParams(args []driver.NamedValue) (*table.QueryParameters, error) {
+	opts := make([]table.ParameterOption, 0, len(args))
+	for _, arg := range args {
+		switch v := arg.Value.(type) {
+		case types.Value:
+			opts = append(opts, table.ValueParam(arg.Name, v))
+		case table.ParameterOption:
+			opts = append(opts, v)
+		case *table.QueryParameters:
+			if len(args) != 1 {
+				return nil, errors.New("ydb: *table.QueryParameters must be the only arg of query")
+			}
+			return v, nil
+		default:
+			return nil, fmt.Errorf("ydb: unsupported arg %T", arg.Value)
+		}
+	}
+	return table.NewQueryParameters(opts...), nil
+}
+
+// txRows reads result of statement executed in interactiveTx
+type txRows struct {
+	result  result.Result
+	nextSet sync.Once
+	err     error
+}
+
+var _ driver.RowsNextResultSet = &txRows{}
+
+func (r *txRows) init() error {
+	r.nextSet.Do(func() {
+		r.err = r.result.NextResultSetErr(context.Background())
+	})
+	return r.err
+}
+
+func (r *txRows) Columns() []string {
+	if r.init() != nil {
+		return nil
+	}
+	columns := make([]string, 0, r.result.CurrentResultSet().ColumnCount())
+	r.result.CurrentResultSet().Columns(func(column options.Column) {
+		columns = append(columns, column.Name)
+	})
+	return columns
+}
+
+func (r *txRows) Next(dest []driver.Value) error {
+	if err := r.init(); err != nil {
+		return err
+	}
+	if !r.result.NextRow() {
+		if err := r.result.Err(); err != nil {
+			return err
+		}
+		return io.EOF
+	}
+	values := make([]indexed.RequiredOrOptional, len(dest))
+	for i := range values {
+		values[i] = &anyValue{}
+	}
+	if err := r.result.Scan(values...); err != nil {
+		return err
+	}
+	for i := range values {
+		dest[i] = values[i].(*anyValue).v
+	}
+	return nil
+}
+
+func (r *txRows) HasNextResultSet() bool {
+	return r.result.HasNextResultSet()
+}
+
+func (r *txRows) NextResultSet() error {
+	r.nextSet.Do(func() {})
+	return r.result.NextResultSetErr(context.Background())
+}
+
+func (r *txRows) Close() error {
+	return r.result.Close()
+}
+
+// anyValue scans YDB value as go value
+type anyValue struct {
+	v interface{}
+}
+
+func (a *anyValue) UnmarshalYDB(raw types.RawValue) error {
+	a.v = raw.Any()
+	return nil
+}
@@ -0,0 +1,177 @@
+package ydb
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// TypeEncoder converts value of registered Go type to query parameter value
+// (Go primitive or types.Value)
+type TypeEncoder func(value interface{}) (interface{}, error)
+
+// TypeDecoder assigns database value src to dst, dst is a pointer to registered Go type
+type TypeDecoder func(src interface{}, dst interface{}) error
+
+type typeMapping struct {
+	yqlType string
+	encode  TypeEncoder
+	decode  TypeDecoder
+}
+
+var (
+	typeMappings sync.Map // reflect.Type -> *typeMapping
+	patchMu      sync.Mutex
+)
+
+// schemaPatches keeps schemas patched for database of Config, every gorm.DB caches own schemas,
+// so they are tracked per Config and released with it
+type schemaPatches struct {
+	mapped      sync.Map // *schema.Schema -> struct{}
+	nullStrings sync.Map // *schema.Schema -> struct{}
+}
+
+// RegisterTypeMapping registers YQL column type, encoder and decoder for Go type of value,
+// mapping is used by DataTypeOf, parameters binding and scanning. Mappings should be
+// registered before models using them are parsed, e.g. in init function
+//
+//	ydb.RegisterTypeMapping(Money{}, "Int64",
+//		func(v interface{}) (interface{}, error) { return v.(Money).Cents(), nil },
+//		func(src interface{}, dst interface{}) error { *dst.(*Money) = MoneyFromCents(src.(int64)); return nil },
+//	)
+func RegisterTypeMapping(value interface{}, yqlType string, encode TypeEncoder, decode TypeDecoder) {
+	typeMappings.Store(reflect.Indirect(reflect.ValueOf(value)).Type(), &typeMapping{
+		yqlType: yqlType,
+		encode:  encode,
+		decode:  decode,
+	})
+}
+
+func lookupTypeMapping(t reflect.Type) (*typeMapping, bool) {
+	if t == nil {
+		return nil, false
+	}
+	if m, ok := typeMappings.Load(t); ok {
+		return m.(*typeMapping), true
+	}
+	return nil, false
+}
+
+// encodeMapped encodes parameter of registered type, other values returned as is
+func encodeMapped(v interface{}) (interface{}, error) {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return v, nil
+	}
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return v, nil
+		}
+		rv = rv.Elem()
+	}
+	if m, ok := lookupTypeMapping(rv.Type()); ok && m.encode != nil {
+		return m.encode(rv.Interface())
+	}
+	return v, nil
+}
+
+func registerTypeMappingCallbacks(db *gorm.DB, patches *schemaPatches) error {
+	callback := db.Callback()
+	if err := callback.Create().Before("gorm:create").Register("ydb:type_mappings", patches.patchMappedFields); err != nil {
+		return err
+	}
+	if err := callback.Query().Before("gorm:query").Register("ydb:type_mappings", patches.patchMappedFields); err != nil {
+		return err
+	}
+	if err := callback.Update().Before("gorm:update").Register("ydb:type_mappings", patches.patchMappedFields); err != nil {
+		return err
+	}
+	return callback.Row().Before("gorm:row").Register("ydb:type_mappings", patches.patchMappedFields)
+}
+
+// patchMappedFields makes fields of registered types decodable by mapping decoder
+func (patches *schemaPatches) patchMappedFields(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+	if _, ok := patches.mapped.Load(db.Statement.Schema); ok {
+		return
+	}
+
+	patchMu.Lock()
+	defer patchMu.Unlock()
+	patches.patchSchema(db.Statement.Schema)
+	for _, rel := range db.Statement.Schema.Relationships.Relations {
+		if rel.FieldSchema != nil {
+			patches.patchSchema(rel.FieldSchema)
+		}
+	}
+}
+
+func (patches *schemaPatches) patchSchema(s *schema.Schema) {
+	if _, ok := patches.mapped.Load(s); ok {
+		return
+	}
+	for _, field := range s.Fields {
+		if m, ok := lookupTypeMapping(field.IndirectFieldType); ok && m.decode != nil {
+			patchField(field, m.decode)
+		} else if isJSONField(field) {
+			patchJSONField(field)
+		}
+		if isUint8BoolField(field) {
+			patchUint8BoolField(field)
+		}
+		if isSensitiveField(field) {
+			patchSensitiveField(field)
+		}
+	}
+	patches.mapped.Store(s, struct{}{})
+}
+
+func patchField(field *schema.Field, decode TypeDecoder) {
+	set := field.Set
+	field.NewValuePool = mappedValuePool
+	field.Set = func(ctx context.Context, rv reflect.Value, v interface{}) error {
+		mv, ok := v.(*mappedValue)
+		if !ok {
+			return set(ctx, rv, v)
+		}
+		if mv.src == nil {
+			return set(ctx, rv, nil)
+		}
+		dst := reflect.New(field.IndirectFieldType)
+		if err := decode(mv.src, dst.Interface()); err != nil {
+			return err
+		}
+		return set(ctx, rv, dst.Interface())
+	}
+}
+
+// mappedValue holds raw database value of mapped field until decoding
+type mappedValue struct {
+	src interface{}
+}
+
+var _ sql.Scanner = &mappedValue{}
+
+func (v *mappedValue) Scan(src interface{}) error {
+	v.src = src
+	return nil
+}
+
+type mappedValuePoolType struct {
+	sync.Pool
+}
+
+func (p *mappedValuePoolType) Put(v interface{}) {
+	v.(*mappedValue).src = nil
+	p.Pool.Put(v)
+}
+
+var mappedValuePool = &mappedValuePoolType{Pool: sync.Pool{
+	New: func() interface{} { return &mappedValue{} },
+}}
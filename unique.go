@@ -0,0 +1,194 @@
+package ydb
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// uniqueIndexVersion is the first YDB version supporting unique secondary indexes
+var uniqueIndexVersion = [2]int{24, 1}
+
+// ErrUniqueIndexUnsupported returned by migrator creating unique index on server without unique
+// secondary indexes when UniqueIndexes plugin is not used by db
+var ErrUniqueIndexUnsupported = errors.New("ydb: unique indexes are not supported by server, use UniqueIndexes plugin")
+
+// ErrDuplicatedKey returned by creates violating unique indexes enforced by UniqueIndexes, it has
+// message of gorm.ErrDuplicatedKey of newer gorm versions
+var ErrDuplicatedKey = errors.New("duplicated key not allowed")
+
+// UniqueIndexes is a gorm plugin enforcing unique indexes declared by uniqueIndex tags on servers
+// without unique secondary indexes, where migrator creates them as non-unique global indexes
+// (servers since 24.1 enforce unique indexes created by migrator themselves): before insert rows
+// with values of columns of unique index are selected through the index in transaction of
+// create, and create fails with ErrDuplicatedKey when they belong to other rows. Transactions are
+// serializable, so concurrent creates of the same values conflict and one of them fails with
+// retryable error. Indexes must exist as global indexes, rows with NULL values of index columns
+// aren't checked, updates aren't checked and creates executed with
+// gorm.Config.SkipDefaultTransaction outside of transactions aren't atomic
+//
+//	type User struct {
+//		ID    uint64
+//		Email string `gorm:"uniqueIndex"`
+//	}
+//
+//	db.Use(ydb.UniqueIndexes{})
+//	if err := db.Create(&user).Error; errors.Is(err, ydb.ErrDuplicatedKey) {
+//		...
+//	}
+type UniqueIndexes struct{}
+
+const uniqueIndexesKey = "ydb:unique_indexes"
+
+func (UniqueIndexes) Name() string {
+	return uniqueIndexesKey
+}
+
+func (UniqueIndexes) Initialize(db *gorm.DB) error {
+	return db.Callback().Create().Before("gorm:create").Register(uniqueIndexesKey, checkUniqueIndexes)
+}
+
+// checkUniqueIndexes fails create with rows whose values of unique indexes exist in other rows
+func checkUniqueIndexes(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || db.DryRun || stmt.Schema == nil || len(stmt.Schema.PrimaryFields) == 0 {
+		return
+	}
+	for _, idx := range uniqueIndexes(stmt.Schema) {
+		if err := checkUniqueIndex(db, idx); err != nil {
+			_ = db.AddError(err)
+			return
+		}
+	}
+}
+
+// uniqueIndexes returns unique indexes of s declared by tags
+func uniqueIndexes(s *schema.Schema) (indexes []schema.Index) {
+	for _, idx := range s.ParseIndexes() {
+		if strings.EqualFold(idx.Class, "UNIQUE") {
+			indexes = append(indexes, idx)
+		}
+	}
+	return indexes
+}
+
+// checkUniqueIndex selects rows with values of idx of created rows through the index
+func checkUniqueIndex(db *gorm.DB, idx schema.Index) error {
+	stmt := db.Statement
+	s := stmt.Schema
+
+	// primary keys of created rows by values of index
+	created := make(map[string]string)
+	var conds []clause.Expression
+	for _, rv := range createdRows(stmt.ReflectValue) {
+		values := make([]interface{}, len(idx.Fields))
+		eqs := make([]clause.Expression, len(idx.Fields))
+		for i, opt := range idx.Fields {
+			v, zero := opt.Field.ValueOf(stmt.Context, rv)
+			if zero && opt.Field.FieldType.Kind() == reflect.Ptr {
+				values = nil
+				break
+			}
+			values[i] = v
+			eqs[i] = clause.Eq{Column: clause.Column{Name: opt.DBName}, Value: v}
+		}
+		if values == nil {
+			continue
+		}
+		pk := make([]interface{}, len(s.PrimaryFields))
+		for i, field := range s.PrimaryFields {
+			pk[i], _ = field.ValueOf(stmt.Context, rv)
+		}
+		key := uniqueKey(values)
+		if _, ok := created[key]; ok {
+			return fmt.Errorf("%w: rows with the same values of unique index %s of %s", ErrDuplicatedKey, idx.Name, stmt.Table)
+		}
+		created[key] = uniqueKey(pk)
+		conds = append(conds, clause.And(eqs...))
+	}
+	if len(conds) == 0 {
+		return nil
+	}
+
+	vars := make([]interface{}, 0, len(idx.Fields)+len(s.PrimaryFields)+3)
+	for _, opt := range idx.Fields {
+		vars = append(vars, clause.Column{Name: opt.DBName})
+	}
+	for _, field := range s.PrimaryFields {
+		vars = append(vars, clause.Column{Name: field.DBName})
+	}
+	sql := "SELECT " + strings.Repeat("?,", len(vars)-1) + "? FROM ? VIEW ? WHERE ?"
+	vars = append(vars, clause.Table{Name: stmt.Table}, clause.Column{Name: idx.Name}, clause.Or(conds...))
+	var existing []map[string]interface{}
+	if err := db.Session(&gorm.Session{NewDB: true}).Raw(sql, vars...).Scan(&existing).Error; err != nil {
+		return err
+	}
+	for _, row := range existing {
+		values := make([]interface{}, len(idx.Fields))
+		for i, opt := range idx.Fields {
+			values[i] = row[opt.DBName]
+		}
+		pk := make([]interface{}, len(s.PrimaryFields))
+		for i, field := range s.PrimaryFields {
+			pk[i] = row[field.DBName]
+		}
+		// UPSERT of row may keep its own values
+		if created[uniqueKey(values)] != uniqueKey(pk) {
+			return fmt.Errorf("%w: unique index %s of %s", ErrDuplicatedKey, idx.Name, stmt.Table)
+		}
+	}
+	return nil
+}
+
+// createdRows returns structs of created value
+func createdRows(rv reflect.Value) (rows []reflect.Value) {
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if row := reflect.Indirect(rv.Index(i)); row.Kind() == reflect.Struct {
+				rows = append(rows, row)
+			}
+		}
+	case reflect.Struct:
+		rows = append(rows, rv)
+	}
+	return rows
+}
+
+// uniqueKey returns comparable key of values of model fields or of scanned columns
+func uniqueKey(values []interface{}) string {
+	var sb strings.Builder
+	for _, v := range values {
+		rv := reflect.ValueOf(v)
+		for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+			rv = rv.Elem()
+		}
+		if rv.IsValid() && rv.Kind() != reflect.Ptr {
+			v = rv.Interface()
+		}
+		switch t := v.(type) {
+		case time.Time:
+			v = t.UTC().Format(time.RFC3339Nano)
+		case []byte:
+			v = string(t)
+		}
+		fmt.Fprintf(&sb, "%v\x00", v)
+	}
+	return sb.String()
+}
+
+// supportsUniqueIndexes reports whether server supports unique secondary indexes
+func (m Migrator) supportsUniqueIndexes() bool {
+	config := configOf(m.DB)
+	if config == nil || config.version == nil {
+		return false
+	}
+	config.version.detect(m.DB)
+	return config.version.atLeast(uniqueIndexVersion)
+}
@@ -0,0 +1,182 @@
+package ydb
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/meta"
+	"github.com/ydb-platform/ydb-go-sdk/v3/trace"
+	"gorm.io/gorm"
+)
+
+// RequestUnits accounts request units consumed by statements of serverless databases, reported
+// by YDB in x-ydb-consumed-units metadata of responses, by model and operation. Units of
+// Config.NativeDriver are reported only when it was opened with option
+// WithTraceDriver(RequestUnitsTrace()) of native driver
+//
+//	units := &ydb.RequestUnits{OnConsumed: func(ctx context.Context, usage ydb.RequestUnitsUsage) {
+//		requestUnits.WithLabelValues(usage.Model, usage.Operation).Add(float64(usage.Units))
+//	}}
+//	db, err := gorm.Open(ydb.New(ydb.Config{DSN: dsn, RequestUnits: units}))
+type RequestUnits struct {
+	// OnConsumed called after every statement consumed units
+	OnConsumed func(ctx context.Context, usage RequestUnitsUsage)
+
+	mu     sync.Mutex
+	totals map[requestUnitsKey]uint64
+}
+
+// RequestUnitsUsage units consumed by statements of model and operation
+type RequestUnitsUsage struct {
+	// Model name of schema of statement, table name for statements without model, empty for raw
+	// statements
+	Model string
+	// Operation is create, query, update, delete, row or raw
+	Operation string
+	// Fingerprint of statement, see Fingerprint, empty in Totals
+	Fingerprint string
+	Units       uint64
+}
+
+type requestUnitsKey struct {
+	model, operation string
+}
+
+type unitsHolderKey struct{}
+
+// unitsHolder collects units consumed by requests of statement passed with context of statement
+type unitsHolder struct {
+	units  uint64
+	parent context.Context
+}
+
+// RequestUnitsTrace returns driver trace collecting units consumed by requests for RequestUnits,
+// it is set up for driver opened by dialector
+func RequestUnitsTrace() trace.Driver {
+	capture := func(ctx context.Context, md map[string][]string) {
+		if holder, ok := ctx.Value(unitsHolderKey{}).(*unitsHolder); ok {
+			atomic.AddUint64(&holder.units, meta.ConsumedUnits(md))
+		}
+	}
+	return trace.Driver{
+		OnConnInvoke: func(info trace.DriverConnInvokeStartInfo) func(trace.DriverConnInvokeDoneInfo) {
+			ctx := *info.Context
+			return func(info trace.DriverConnInvokeDoneInfo) {
+				capture(ctx, info.Metadata)
+			}
+		},
+		OnConnNewStream: func(info trace.DriverConnNewStreamStartInfo) func(trace.DriverConnNewStreamRecvInfo) func(trace.DriverConnNewStreamDoneInfo) {
+			ctx := *info.Context
+			return func(trace.DriverConnNewStreamRecvInfo) func(trace.DriverConnNewStreamDoneInfo) {
+				return func(info trace.DriverConnNewStreamDoneInfo) {
+					capture(ctx, info.Metadata)
+				}
+			}
+		},
+	}
+}
+
+const (
+	requestUnitsKeyName = "ydb:request_units"
+	requestUnitsDoneKey = "ydb:request_units_done"
+)
+
+func (r *RequestUnits) registerCallbacks(db *gorm.DB) error {
+	r.totals = map[requestUnitsKey]uint64{}
+	callback := db.Callback()
+	if err := callback.Create().Before("gorm:create").Register(requestUnitsKeyName, r.start); err != nil {
+		return err
+	}
+	if err := callback.Create().After("gorm:create").Register(requestUnitsDoneKey, r.done("create")); err != nil {
+		return err
+	}
+	if err := callback.Query().Before("gorm:query").Register(requestUnitsKeyName, r.start); err != nil {
+		return err
+	}
+	if err := callback.Query().After("gorm:query").Register(requestUnitsDoneKey, r.done("query")); err != nil {
+		return err
+	}
+	if err := callback.Update().Before("gorm:update").Register(requestUnitsKeyName, r.start); err != nil {
+		return err
+	}
+	if err := callback.Update().After("gorm:update").Register(requestUnitsDoneKey, r.done("update")); err != nil {
+		return err
+	}
+	if err := callback.Delete().Before("gorm:delete").Register(requestUnitsKeyName, r.start); err != nil {
+		return err
+	}
+	if err := callback.Delete().After("gorm:delete").Register(requestUnitsDoneKey, r.done("delete")); err != nil {
+		return err
+	}
+	if err := callback.Row().Before("gorm:row").Register(requestUnitsKeyName, r.start); err != nil {
+		return err
+	}
+	if err := callback.Row().After("gorm:row").Register(requestUnitsDoneKey, r.done("row")); err != nil {
+		return err
+	}
+	if err := callback.Raw().Before("gorm:raw").Register(requestUnitsKeyName, r.start); err != nil {
+		return err
+	}
+	return callback.Raw().After("gorm:raw").Register(requestUnitsDoneKey, r.done("raw"))
+}
+
+func (r *RequestUnits) start(db *gorm.DB) {
+	holder := &unitsHolder{parent: db.Statement.Context}
+	db.Statement.Context = context.WithValue(db.Statement.Context, unitsHolderKey{}, holder)
+}
+
+func (r *RequestUnits) done(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		holder, ok := db.Statement.Context.Value(unitsHolderKey{}).(*unitsHolder)
+		if !ok {
+			return
+		}
+		db.Statement.Context = holder.parent
+		units := atomic.LoadUint64(&holder.units)
+		if units == 0 {
+			return
+		}
+
+		usage := RequestUnitsUsage{
+			Model:       db.Statement.Table,
+			Operation:   operation,
+			Fingerprint: Fingerprint(db.Statement.SQL.String()),
+			Units:       units,
+		}
+		if db.Statement.Schema != nil {
+			usage.Model = db.Statement.Schema.Name
+		}
+		r.mu.Lock()
+		r.totals[requestUnitsKey{model: usage.Model, operation: operation}] += units
+		r.mu.Unlock()
+		if r.OnConsumed != nil {
+			r.OnConsumed(db.Statement.Context, usage)
+		}
+	}
+}
+
+// Totals returns units consumed since start or Reset by model and operation
+func (r *RequestUnits) Totals() []RequestUnitsUsage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	totals := make([]RequestUnitsUsage, 0, len(r.totals))
+	for key, units := range r.totals {
+		totals = append(totals, RequestUnitsUsage{Model: key.model, Operation: key.operation, Units: units})
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Model != totals[j].Model {
+			return totals[i].Model < totals[j].Model
+		}
+		return totals[i].Operation < totals[j].Operation
+	})
+	return totals
+}
+
+// Reset clears totals of consumed units
+func (r *RequestUnits) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.totals = map[requestUnitsKey]uint64{}
+}
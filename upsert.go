@@ -0,0 +1,123 @@
+package ydb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrUpsertAllUnsupported returned by UpsertAll for values whose creates are extended by plugins
+// writing or checking other rows in transaction of create, which single query can't do
+var ErrUpsertAllUnsupported = errors.New("ydb: values can't be written by UpsertAll")
+
+// UpsertAll writes values (pointers to models or slices of models) of several tables by UPSERTs
+// of single data query, which commits in the same request outside of transactions, e.g. to
+// persist aggregate root with its entities in one round trip instead of transaction of creates:
+//
+//	err := ydb.UpsertAll(ctx, db, &order, order.Items, &customer)
+//
+// Values are prepared by create callbacks in dry run mode: ids of generate tags, hash shards and
+// other computed columns are set and auto increment fields are filled by Sequences plugin, but
+// hooks of models aren't called, associations aren't saved and callbacks writing or reading other
+// rows are skipped. Values of models whose creates are extended by such plugins (Audit, History
+// of versioned models, Outbox events, Aggregates of source tables, UniqueIndexes of models with
+// unique indexes, Shadow) are rejected with ErrUpsertAllUnsupported before anything is written.
+// Rows of table should be passed as one slice, query writing the same table twice is rejected by
+// server. Inside of transactions the query is executed in the transaction
+func UpsertAll(ctx context.Context, db *gorm.DB, values ...interface{}) error {
+	var (
+		sql  strings.Builder
+		vars []interface{}
+	)
+	for _, value := range values {
+		if rv := reflect.Indirect(reflect.ValueOf(value)); (rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array) && rv.Len() == 0 {
+			continue
+		}
+		stmt, err := upsertStatement(ctx, db, value)
+		if err != nil {
+			return err
+		}
+		sql.WriteString(stmt.SQL.String())
+		sql.WriteString(";\n")
+		vars = append(vars, stmt.Vars...)
+	}
+	if sql.Len() == 0 {
+		return nil
+	}
+
+	tx := db.Session(&gorm.Session{NewDB: true, Context: ctx})
+	tx.Statement.SQL = sql
+	tx.Statement.Vars = vars
+	return tx.Callback().Raw().Execute(tx).Error
+}
+
+// upsertStatement builds UPSERT of value by create callbacks in dry run mode
+func upsertStatement(ctx context.Context, db *gorm.DB, value interface{}) (*gorm.Statement, error) {
+	// checked before assignSequences, which takes values of sequences
+	parsed := &gorm.Statement{DB: db}
+	if err := parsed.Parse(value); err != nil {
+		return nil, err
+	}
+	if err := checkUpsertAll(db, parsed.Schema); err != nil {
+		return nil, err
+	}
+	if err := assignSequences(ctx, db, value); err != nil {
+		return nil, err
+	}
+	dry := db.Session(&gorm.Session{NewDB: true, DryRun: true, SkipHooks: true, SkipDefaultTransaction: true, Context: ctx}).
+		Set(writeModeKey, WriteUpsert).Omit(clause.Associations).Create(value)
+	if dry.Error != nil {
+		return nil, dry.Error
+	}
+	// without RETURNING, statements of data query can't return results to Exec
+	stmt := dry.Statement
+	stmt.SQL.Reset()
+	stmt.Vars = nil
+	stmt.Build("INSERT", "VALUES")
+	return stmt, stmt.Error
+}
+
+// assignSequences fills auto increment fields of value by Sequences plugin, which skips creates
+// in dry run mode
+func assignSequences(ctx context.Context, db *gorm.DB, value interface{}) error {
+	sequences, ok := db.Config.Plugins[Sequences{}.Name()].(interface{ assign(*gorm.DB) })
+	if !ok {
+		return nil
+	}
+	tx := db.Session(&gorm.Session{NewDB: true, Context: ctx})
+	if err := tx.Statement.Parse(value); err != nil {
+		return err
+	}
+	tx.Statement.ReflectValue = reflect.Indirect(reflect.ValueOf(value))
+	sequences.assign(tx)
+	return tx.Error
+}
+
+// checkUpsertAll returns ErrUpsertAllUnsupported when creates of s are extended by plugins
+// skipped by UpsertAll
+func checkUpsertAll(db *gorm.DB, s *schema.Schema) error {
+	plugins := db.Config.Plugins
+	var plugin string
+	if _, ok := plugins[auditKey]; ok {
+		plugin = "Audit"
+	} else if _, ok := plugins[historyKey]; ok && isVersioned(s) {
+		plugin = "History"
+	} else if _, ok := plugins[outboxKey]; ok && emitsOutboxEvents(s) {
+		plugin = "Outbox"
+	} else if aggregates, ok := plugins[aggregatesKey].(*Aggregates); ok && len(aggregates.bySource[s.Table]) > 0 {
+		plugin = "Aggregates"
+	} else if _, ok := plugins[uniqueIndexesKey]; ok && len(uniqueIndexes(s)) > 0 {
+		plugin = "UniqueIndexes"
+	} else if _, ok := plugins[(&Shadow{}).Name()]; ok {
+		plugin = "Shadow"
+	} else {
+		return nil
+	}
+	return fmt.Errorf("%w: creates of %s are extended by %s plugin", ErrUpsertAllUnsupported, s.Table, plugin)
+}
@@ -0,0 +1,131 @@
+package ydb
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm/schema"
+)
+
+// ErrInvalidSchema is matched by SchemaError
+var ErrInvalidSchema = errors.New("ydb: models use features unsupported by YDB")
+
+// maxIndexNameLength max length of index name, name is a component of path of index table
+const maxIndexNameLength = 255
+
+// SchemaProblem is a feature of model unsupported by YDB
+type SchemaProblem struct {
+	Model string
+	// Field is empty for problems of whole model
+	Field      string
+	Problem    string
+	Suggestion string
+}
+
+func (p SchemaProblem) String() string {
+	name := p.Model
+	if p.Field != "" {
+		name += "." + p.Field
+	}
+	return fmt.Sprintf("%s: %s, %s", name, p.Problem, p.Suggestion)
+}
+
+// SchemaError reports all problems found by ValidateSchema
+type SchemaError struct {
+	Problems []SchemaProblem
+}
+
+func (e *SchemaError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "ydb: %d problems of models:", len(e.Problems))
+	for _, p := range e.Problems {
+		sb.WriteString("\n\t")
+		sb.WriteString(p.String())
+	}
+	return sb.String()
+}
+
+func (e *SchemaError) Is(target error) bool {
+	return target == ErrInvalidSchema
+}
+
+// ValidateSchema checks models for features YDB doesn't have: tables without primary key,
+// unique constraints, auto increment columns without id generator, foreign key
+// actions not emulated by Cascade plugin and too long index names. All problems are reported at
+// once by SchemaError with suggestions of fixes, see also ValidateModels of Config
+//
+//	if err := ydb.ValidateSchema(&User{}, &Order{}); err != nil {
+//		log.Fatal(err)
+//	}
+func ValidateSchema(models ...interface{}) error {
+	return validateSchema(schema.NamingStrategy{}, true, models)
+}
+
+// validateSchema checks models named by namer, foreignKeys reports whether migrator creates
+// foreign key constraints of associations
+func validateSchema(namer schema.Namer, foreignKeys bool, models []interface{}) error {
+	var (
+		problems   []SchemaProblem
+		cacheStore = &sync.Map{}
+	)
+	for _, model := range withoutViews(models) {
+		s, err := schema.Parse(model, cacheStore, namer)
+		if err != nil {
+			return err
+		}
+		report := func(field, problem, suggestion string) {
+			problems = append(problems, SchemaProblem{Model: s.Name, Field: field, Problem: problem, Suggestion: suggestion})
+		}
+
+		if len(s.PrimaryFields) == 0 {
+			report("", "table has no primary key", "tag key fields with primaryKey or add ID field")
+		}
+		for _, field := range s.Fields {
+			if field.DBName == "" {
+				continue
+			}
+			if field.Unique {
+				report(field.Name, "YDB has no unique constraints",
+					"tag field with uniqueIndex, see UniqueIndexes")
+			}
+			_, explicit := field.TagSettings["AUTOINCREMENT"]
+			_, sequence := field.TagSettings["SEQUENCE"]
+			_, generate := field.TagSettings["GENERATE"]
+			if explicit && !sequence && !generate {
+				report(field.Name, "YDB has no auto increment columns",
+					"tag field with generate:snowflake, generate:ulid or sequence (Sequences plugin)")
+			}
+		}
+		for _, idx := range s.ParseIndexes() {
+			if len(idx.Name) > maxIndexNameLength {
+				report("", fmt.Sprintf("index name %s is longer than %d", idx.Name, maxIndexNameLength),
+					"set shorter name by index tag")
+			}
+		}
+		for _, rel := range s.Relationships.Relations {
+			c := rel.ParseConstraint()
+			// relations are also added to schemas of referenced models
+			if c == nil || rel.Schema != s {
+				continue
+			}
+			if _, tagged := rel.Field.TagSettings["CONSTRAINT"]; tagged {
+				for _, action := range []string{c.OnDelete, c.OnUpdate} {
+					if action = strings.ToUpper(action); action != "" && action != "CASCADE" && action != "SET NULL" {
+						report(rel.Field.Name, fmt.Sprintf("foreign key action %s is not supported", action),
+							"use CASCADE or SET NULL emulated by Cascade plugin or check references in transaction")
+					}
+				}
+			}
+			if foreignKeys {
+				report(rel.Field.Name, "YDB has no foreign keys, migrator fails creating constraint "+c.Name,
+					"set DisableForeignKeyConstraintWhenMigrating of gorm.Config")
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return &SchemaError{Problems: problems}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package ydb
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrReadOnlyModel returned by creates, updates and deletes of View models
+var ErrReadOnlyModel = errors.New("ydb: model is read-only view")
+
+// View is implemented by read-only models mapped to query instead of table, queries of such
+// models read from the query as subquery aliased by table name of model and migrator skips them.
+// Query is built by db, new session of the statement, and may be raw YQL
+//
+//	type OrderTotal struct {
+//		CustomerID uint64
+//		Total      uint64
+//	}
+//
+//	func (OrderTotal) ViewQuery(db *gorm.DB) *gorm.DB {
+//		return db.Table("orders").Select("customer_id, SUM(amount) AS total").Group("customer_id")
+//	}
+//
+//	db.Where("total > ?", 1000).Find(&totals)
+type View interface {
+	ViewQuery(db *gorm.DB) *gorm.DB
+}
+
+func isView(s *schema.Schema) (View, bool) {
+	if s == nil {
+		return nil, false
+	}
+	view, ok := reflect.New(s.ModelType).Interface().(View)
+	return view, ok
+}
+
+func registerViewCallbacks(db *gorm.DB) error {
+	callback := db.Callback()
+	if err := callback.Query().Before("gorm:query").Register("ydb:view", substituteView); err != nil {
+		return err
+	}
+	if err := callback.Row().Before("gorm:row").Register("ydb:view", substituteView); err != nil {
+		return err
+	}
+	if err := callback.Create().Before("gorm:create").Register("ydb:view", rejectViewWrite); err != nil {
+		return err
+	}
+	if err := callback.Update().Before("gorm:update").Register("ydb:view", rejectViewWrite); err != nil {
+		return err
+	}
+	return callback.Delete().Before("gorm:delete").Register("ydb:view", rejectViewWrite)
+}
+
+// substituteView replaces table of statement of view model by its query
+func substituteView(db *gorm.DB) {
+	if db.Error != nil || db.Statement.TableExpr != nil || db.Statement.SQL.Len() > 0 {
+		return
+	}
+	view, ok := isView(db.Statement.Schema)
+	if !ok {
+		return
+	}
+	query := view.ViewQuery(db.Session(&gorm.Session{NewDB: true}))
+	if query == nil {
+		db.AddError(fmt.Errorf("ydb: view %s has no query", db.Statement.Schema.Name))
+		return
+	}
+	db.Statement.TableExpr = &clause.Expr{SQL: "(?) AS ?", Vars: []interface{}{query, clause.Table{Name: db.Statement.Table}}}
+}
+
+func rejectViewWrite(db *gorm.DB) {
+	if _, ok := isView(db.Statement.Schema); ok && db.Error == nil {
+		db.AddError(fmt.Errorf("%w: %s", ErrReadOnlyModel, db.Statement.Schema.Name))
+	}
+}
+
+// withoutViews returns models of values which are not View, views have no tables to migrate
+func withoutViews(values []interface{}) []interface{} {
+	models := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		if _, ok := value.(View); ok {
+			continue
+		}
+		if rv := reflect.ValueOf(value); rv.Kind() != reflect.Ptr && rv.IsValid() {
+			if _, ok := reflect.New(rv.Type()).Interface().(View); ok {
+				continue
+			}
+		}
+		models = append(models, value)
+	}
+	return models
+}
@@ -2,14 +2,19 @@ package ydb
 
 import (
 	"context"
+	"crypto/tls"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
 	"os"
+	"reflect"
 	"regexp"
-	"strconv"
 	"strings"
+	"time"
 
 	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
 	"gorm.io/gorm"
 	"gorm.io/gorm/callbacks"
 	"gorm.io/gorm/clause"
@@ -28,6 +33,109 @@ type Config struct {
 	PreferSimpleProtocol bool
 	WithoutReturning     bool
 	Conn                 gorm.ConnPool
+	// Limits enables pre-flight validation of requests size, e.g. &ydb.DefaultLimits
+	Limits *Limits
+	// MaxBatchRows and MaxBatchBytes split slice creates into several INSERT statements
+	// limited by rows count and approximate parameters size, which are committed separately
+	// outside of transactions, so failed create may be saved partially
+	MaxBatchRows  int
+	MaxBatchBytes int
+	// UseTzTypes maps time fields to TzTimestamp and binds time parameters as TzTimestamp
+	// in the TimeZone of DSN
+	UseTzTypes bool
+	// DoubleFloats maps float32 fields to Double columns like float64 fields, Float keeps only
+	// about 7 significant digits
+	DoubleFloats bool
+	// BoolAsUint8 maps bool fields to Uint8 columns of legacy tables keeping booleans in them and
+	// binds bool parameters as Uint8, single fields are mapped by type tag
+	//
+	//	type Feature struct {
+	//		ID      uint64
+	//		Enabled bool `gorm:"type:Uint8"`
+	//	}
+	//
+	// Values of tagged fields are written as 1 and 0 by creates and updates of models and by
+	// conditions of models, bool parameters of raw conditions and of updates by maps are bound as
+	// Bool unless BoolAsUint8 is set. Uint8 values 0 and 1 are scanned into bool fields by
+	// database/sql
+	BoolAsUint8 bool
+	// NullStrings controls writing empty strings and reading NULLs of nullable string fields
+	NullStrings NullStringPolicy
+	// OperationTimeout and OperationCancelAfter are default YDB operation parameters of queries,
+	// server cancels queries exceeding them, see WithTimeout for per statement timeout
+	OperationTimeout     time.Duration
+	OperationCancelAfter time.Duration
+	// ReadTimeout, WriteTimeout and DDLTimeout are client side timeouts of reads, writes and
+	// scheme statements whose context has no deadline, so statements never hang unbounded
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	DDLTimeout   time.Duration
+	// Labels attached to all requests for workload attribution, see WithLabel
+	Labels map[string]string
+	// SQLCommenter appends comments with labels and tags of requests to queries
+	SQLCommenter *SQLCommenter
+	// MetricsRegisterer registers metrics of sessions, retries and truncated results
+	MetricsRegisterer MetricsRegisterer
+	// CircuitBreaker fails requests fast during cluster unavailability
+	CircuitBreaker *CircuitBreaker
+	// RateLimit enables client side limiting of statements rate and concurrency
+	RateLimit *RateLimit
+	// SlowQuery reports slow statements with their plans and execution statistics
+	SlowQuery *SlowQuery
+	// RequestUnits accounts request units consumed by statements
+	RequestUnits *RequestUnits
+	// RowsAffected controls accuracy of RowsAffected reported by write statements
+	RowsAffected RowsAffectedMode
+	// NativeDriver shared by several dialectors instead of driver opened by DSN, so workloads
+	// (e.g. OLTP and reporting) use separate connection pools with own sessions and settings
+	NativeDriver ydb.Connection
+	// ConnectorOptions configure database/sql connector of native driver, e.g. default query mode
+	ConnectorOptions []ydb.ConnectorOption
+	// MaxSessions limits count of sessions used by the connection pool, zero means no limit
+	MaxSessions int
+	// Credentials option of native driver opened by DSN, e.g. ydb.WithStaticCredentials, defaults
+	// to access token of YDB_TOKEN environment variable
+	Credentials ydb.Option
+	// GRPC configures transport of native driver opened by DSN
+	GRPC *GRPCOptions
+	// TLS config of native driver opened by DSN for clusters behind private PKI
+	TLS *tls.Config
+	// CAFile, CertFile and KeyFile are paths of PEM encoded root CAs added to TLS and of client
+	// certificate for mTLS, also set by ca_file, cert_file and key_file DSN parameters
+	CAFile   string
+	CertFile string
+	KeyFile  string
+	// Endpoint overrides endpoint of DSN, e.g. address of load balancer or proxy
+	Endpoint string
+	// SingleEndpoint disables discovery of cluster endpoints, so all requests are sent to endpoint
+	// of DSN (behind load balancers and in restricted networks), also set by single_endpoint DSN
+	// parameter
+	SingleEndpoint bool
+	// LazyConnect connects native driver opened by DSN in background with retries instead of
+	// failing Initialize when cluster is unavailable
+	LazyConnect *LazyConnect
+	// DisallowDestructiveMigrations makes migrator refuse to drop tables and columns and to set or
+	// shorten TTL of tables unless allowed by AllowDestructiveMigrations, protecting data from
+	// accidental migrations
+	DisallowDestructiveMigrations bool
+	// NotNullColumns creates columns of non-pointer fields (except sql.Scanner types, slices and
+	// maps) NOT NULL like primary keys and fields tagged with not null, on YDB 23.1 and newer
+	NotNullColumns bool
+	// WorkerID of the process in ids of fields tagged with generate:snowflake, up to MaxWorkerID
+	WorkerID uint16
+	// ValidateModels are checked by ValidateSchema in Initialize, so features of models unsupported
+	// by YDB fail opening of database instead of migrations and queries
+	ValidateModels []interface{}
+
+	nativeDriver ydb.Connection
+	location     *time.Location
+	snowflake    *Snowflake
+	writeClock   *writeClock
+	drainer      *drainer
+	lazy         *lazyConnector
+	version      *serverVersion
+	metrics      *metrics
+	patches      *schemaPatches
 }
 
 func Open(dsn string) gorm.Dialector {
@@ -38,6 +146,36 @@ func New(config Config) gorm.Dialector {
 	return &Dialector{Config: &config}
 }
 
+// ErrNativeDriverUnavailable returned when gorm.DB was not opened by the dialector itself
+// (Config.Conn or Config.DriverName used), so native YDB clients are unreachable
+var ErrNativeDriverUnavailable = errors.New("ydb: native driver is unavailable")
+
+// Unwrap returns native driver of the connection for access to topic, coordination,
+// scripting and other YDB clients without opening a parallel connection
+func Unwrap(db *gorm.DB) (ydb.Connection, error) {
+	config := configOf(db)
+	if config == nil {
+		return nil, ErrNativeDriverUnavailable
+	}
+	if config.lazy != nil {
+		return config.lazy.connected()
+	}
+	if config.nativeDriver == nil {
+		return nil, ErrNativeDriverUnavailable
+	}
+	return config.nativeDriver, nil
+}
+
+func configOf(db *gorm.DB) *Config {
+	switch dialector := db.Dialector.(type) {
+	case *Dialector:
+		return dialector.Config
+	case Dialector:
+		return dialector.Config
+	}
+	return nil
+}
+
 func (dialector Dialector) Name() string {
 	return "ydb"
 }
@@ -45,6 +183,16 @@ func (dialector Dialector) Name() string {
 var timeZoneMatcher = regexp.MustCompile("(time_zone|TimeZone)=(.*?)($|&| )")
 
 func (dialector Dialector) Initialize(db *gorm.DB) (err error) {
+	if len(dialector.ValidateModels) > 0 {
+		if err = validateSchema(db.NamingStrategy, !db.DisableForeignKeyConstraintWhenMigrating, dialector.ValidateModels); err != nil {
+			return err
+		}
+	}
+
+	if dialector.Config.location, err = parseTimeZone(dialector.DSN); err != nil {
+		return err
+	}
+
 	// register callbacks
 	if !dialector.WithoutReturning {
 		callbacks.RegisterDefaultCallbacks(db, &callbacks.Config{
@@ -52,27 +200,201 @@ func (dialector Dialector) Initialize(db *gorm.DB) (err error) {
 			UpdateClauses: []string{"UPDATE", "SET", "WHERE", "RETURNING"},
 			DeleteClauses: []string{"DELETE", "FROM", "WHERE", "RETURNING"},
 		})
+	} else {
+		callbacks.RegisterDefaultCallbacks(db, &callbacks.Config{
+			CreateClauses: []string{"INSERT", "VALUES", "ON CONFLICT"},
+			UpdateClauses: []string{"UPDATE", "SET", "WHERE"},
+			DeleteClauses: []string{"DELETE", "FROM", "WHERE"},
+		})
+		// database defaults are read back by primary key instead of RETURNING
+		if err = db.Callback().Create().After("gorm:create").Register("ydb:reload_defaults", reloadDefaults); err != nil {
+			return err
+		}
+	}
+
+	db.ClauseBuilders["FROM"] = buildFrom
+	if err = registerHintsCallbacks(db); err != nil {
+		return err
+	}
+
+	if err = dialector.registerAutocommitCallbacks(db); err != nil {
+		return err
+	}
+
+	dialector.Config.patches = &schemaPatches{}
+	if err = registerTypeMappingCallbacks(db, dialector.Config.patches); err != nil {
+		return err
+	}
+
+	dialector.Config.version = &serverVersion{}
+	dialector.Config.snowflake = &Snowflake{WorkerID: dialector.WorkerID}
+	if err = db.Callback().Create().Before("gorm:create").Register("ydb:generate_ids", generateIDs); err != nil {
+		return err
+	}
+
+	if err = registerComputedCallbacks(db); err != nil {
+		return err
+	}
+
+	if err = registerHashShardCallbacks(db); err != nil {
+		return err
+	}
+
+	if err = registerViewCallbacks(db); err != nil {
+		return err
+	}
+
+	if err = registerConsistencyCallbacks(db); err != nil {
+		return err
+	}
+
+	if err = registerSessionAffinityCallbacks(db); err != nil {
+		return err
+	}
+
+	if err = registerBuildTraceCallbacks(db); err != nil {
+		return err
+	}
+
+	if err = registerColumnStoreCallbacks(db); err != nil {
+		return err
+	}
+
+	dialector.Config.writeClock = &writeClock{started: time.Now(), tables: map[string]time.Time{}}
+	if err = dialector.Config.writeClock.registerCallbacks(db); err != nil {
+		return err
+	}
+
+	if dialector.RateLimit != nil {
+		if err = dialector.RateLimit.registerCallbacks(db); err != nil {
+			return err
+		}
+	}
+
+	if dialector.SlowQuery != nil {
+		if err = dialector.SlowQuery.registerCallbacks(db); err != nil {
+			return err
+		}
+	}
+
+	if dialector.RequestUnits != nil {
+		if err = dialector.RequestUnits.registerCallbacks(db); err != nil {
+			return err
+		}
+	}
+
+	if err = dialector.RowsAffected.registerCallbacks(db); err != nil {
+		return err
+	}
+
+	if dialector.NullStrings != NullStringsAsIs {
+		if err = dialector.NullStrings.registerCallbacks(db, dialector.Config.patches); err != nil {
+			return err
+		}
+	}
+
+	if dialector.MaxBatchRows > 0 || dialector.MaxBatchBytes > 0 {
+		if create := db.Callback().Create().Get("gorm:create"); create != nil {
+			if err = db.Callback().Create().Replace("gorm:create", dialector.chunkedCreate(create)); err != nil {
+				return err
+			}
+		}
+	}
+
+	// rows limit is satisfied by chunking when batch rows do not exceed it
+	if limits := dialector.Limits; limits != nil && (dialector.MaxBatchRows <= 0 || dialector.MaxBatchRows > limits.MaxRows) {
+		if err = db.Callback().Create().Before("gorm:create").Register("ydb:check_limits", limits.checkRows); err != nil {
+			return err
+		}
+	}
+
+	if dialector.MetricsRegisterer != nil {
+		dialector.Config.metrics = newMetrics(dialector.MetricsRegisterer)
 	}
 
 	if dialector.Conn != nil {
 		db.ConnPool = dialector.Conn
 	} else if dialector.DriverName != "" {
 		db.ConnPool, err = sql.Open(dialector.DriverName, dialector.Config.DSN)
+	} else if dialector.LazyConnect != nil && dialector.NativeDriver == nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		lazy := &lazyConnector{ready: make(chan struct{}), cancel: cancel}
+		dialector.Config.lazy = lazy
+		dialector.Config.drainer = newDrainer()
+		go lazy.run(ctx, dialector.LazyConnect, dialector.openNative)
+		db.ConnPool = dialector.openDB(lazy)
+		// ping would fail until connected
+		db.DisableAutomaticPing = true
 	} else {
-		nativeDriver, err := ydb.Open(context.TODO(), dialector.Config.DSN, ydb.WithAccessTokenCredentials(os.Getenv("YDB_TOKEN"))) // See many ydb.Option's for configure driver https://pkg.go.dev/github.com/ydb-platform/ydb-go-sdk/v3#Option
+		nativeDriver, nativeConnector, err := dialector.openNative(context.TODO())
 		if err != nil {
 			return err
-			// fallback on error
 		}
-		defer nativeDriver.Close(context.TODO())
-		connector, err := ydb.Connector(nativeDriver) // See ydb.ConnectorOption's for configure connector https://pkg.go.dev/github.com/ydb-platform/ydb-go-sdk/v3#ConnectorOption
+		dialector.Config.nativeDriver = nativeDriver
+		dialector.Config.drainer = newDrainer()
+		db.ConnPool = dialector.openDB(nativeConnector)
+	}
+	return
+}
+
+// openNative opens native driver by DSN, unless shared one is configured, and its connector
+func (dialector Dialector) openNative(ctx context.Context) (ydb.Connection, driver.Connector, error) {
+	nativeDriver := dialector.NativeDriver
+	if nativeDriver == nil {
+		opts, err := dialector.driverOptions()
 		if err != nil {
-			return err
+			return nil, nil, err
+		}
+		if nativeDriver, err = ydb.Open(ctx, dialector.Config.DSN, opts...); err != nil {
+			return nil, nil, err
 		}
-		defer connector.Close()
-		db.ConnPool = sql.OpenDB(connector)
 	}
-	return
+	connectorOptions := append(dialector.SlowQuery.connectorOptions(), dialector.ConnectorOptions...)
+	nativeConnector, err := ydb.Connector(nativeDriver, connectorOptions...) // See ydb.ConnectorOption's for configure connector https://pkg.go.dev/github.com/ydb-platform/ydb-go-sdk/v3#ConnectorOption
+	if err != nil {
+		if dialector.NativeDriver == nil {
+			_ = nativeDriver.Close(context.TODO())
+		}
+		return nil, nil, err
+	}
+	return nativeDriver, nativeConnector, nil
+}
+
+// openDB returns connection pool of native connector
+func (dialector Dialector) openDB(nativeConnector driver.Connector) *sql.DB {
+	sqlDB := sql.OpenDB(&connector{Connector: nativeConnector, config: dialector.Config})
+	if dialector.MaxSessions > 0 {
+		sqlDB.SetMaxOpenConns(dialector.MaxSessions)
+	}
+	return sqlDB
+}
+
+// driverOptions returns options of native driver opened by DSN
+// See many ydb.Option's for configure driver https://pkg.go.dev/github.com/ydb-platform/ydb-go-sdk/v3#Option
+func (dialector Dialector) driverOptions() ([]ydb.Option, error) {
+	credentials := dialector.Credentials
+	if credentials == nil {
+		credentials = ydb.WithAccessTokenCredentials(os.Getenv(EnvToken))
+	}
+	opts := []ydb.Option{
+		credentials,
+		ydb.WithTraceTable(ColumnTypesTrace()),
+		ydb.WithTraceDriver(RequestUnitsTrace()),
+	}
+	if dialector.metrics != nil {
+		opts = append(opts, ydb.WithTraceTable(dialector.metrics.trace()))
+	}
+	tlsOpts, err := dialector.tlsOptions()
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, tlsOpts...)
+	endpointOpts, err := dialector.endpointOptions()
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, endpointOpts...)
+	return append(opts, dialector.GRPC.driverOptions()...), nil
 }
 
 func (dialector Dialector) Migrator(db *gorm.DB) gorm.Migrator {
@@ -88,8 +410,7 @@ func (dialector Dialector) DefaultValueOf(field *schema.Field) clause.Expression
 }
 
 func (dialector Dialector) BindVarTo(writer clause.Writer, stmt *gorm.Statement, v interface{}) {
-	writer.WriteByte('$')
-	writer.WriteString(strconv.Itoa(len(stmt.Vars)))
+	writer.WriteByte('?')
 }
 
 func (dialector Dialector) QuoteTo(writer clause.Writer, str string) {
@@ -140,17 +461,61 @@ func (dialector Dialector) QuoteTo(writer clause.Writer, str string) {
 	writer.WriteByte('`')
 }
 
-var numericPlaceholder = regexp.MustCompile(`\$(\d+)`)
-
 func (dialector Dialector) Explain(sql string, vars ...interface{}) string {
-	return logger.ExplainSQL(sql, numericPlaceholder, `'`, vars...)
+	// durations are bound as Interval, render them as Interval literals, sensitive values are masked
+	var (
+		sb  strings.Builder
+		idx int
+	)
+	for i := 0; i < len(sql); i++ {
+		if sql[i] != '?' || idx >= len(vars) || !isPlaceholder(sql, i) {
+			sb.WriteByte(sql[i])
+			continue
+		}
+		idx++
+		switch v := vars[idx-1].(type) {
+		case sensitive:
+			sb.WriteString("'" + maskedValue + "'")
+			continue
+		case time.Duration:
+			sb.WriteString(types.IntervalValueFromDuration(v).Yql())
+			continue
+		case *time.Duration:
+			if v != nil {
+				sb.WriteString(types.IntervalValueFromDuration(*v).Yql())
+				continue
+			}
+		}
+		// values are explained one by one, ? of ?? and optional types are kept
+		sb.WriteString(logger.ExplainSQL("?", nil, `'`, vars[idx-1]))
+	}
+	return sb.String()
 }
 
+var durationReflectType = reflect.TypeOf(time.Duration(0))
+
 func (dialector Dialector) DataTypeOf(field *schema.Field) string {
+	if m, ok := lookupTypeMapping(field.IndirectFieldType); ok {
+		return m.yqlType
+	}
+	if isJSONField(field) {
+		return jsonDataType(field)
+	}
+	if field.IndirectFieldType == durationReflectType {
+		return "Interval"
+	}
+
 	switch field.DataType {
 	case schema.Bool:
+		if dialector.BoolAsUint8 {
+			return "Uint8"
+		}
 		return "Bool"
 	case schema.Int, schema.Uint:
+		// uint64 values above max int64 don't fit Int64 columns
+		if field.DataType == schema.Uint && field.Size > 32 {
+			return "Uint64"
+		}
 		size := field.Size
 		if field.DataType == schema.Uint {
 			size++
@@ -175,19 +540,26 @@ func (dialector Dialector) DataTypeOf(field *schema.Field) string {
 			}
 		}
 	case schema.Float:
+		// precision tag is binary precision like of SQL float(p), up to 24 bits fit Float
 		if field.Precision > 0 {
-			if field.Scale > 0 {
-				return fmt.Sprintf("numeric(%d, %d)", field.Precision, field.Scale)
+			if field.Precision <= 24 {
+				return "Float"
 			}
-			return fmt.Sprintf("numeric(%d)", field.Precision)
+			return "Double"
 		}
-		return "Float"
+		if field.Size == 32 && !dialector.DoubleFloats {
+			return "Float"
+		}
+		return "Double"
 	case schema.String:
 		if field.Size > 0 {
 			return fmt.Sprintf("varchar(%d)", field.Size)
 		}
 		return "String"
 	case schema.Time:
+		if dialector.UseTzTypes {
+			return "TzTimestamp"
+		}
 		if field.Precision > 0 {
 			return fmt.Sprintf("Timestamp(%d)", field.Precision)
 		}
@@ -199,6 +571,23 @@ func (dialector Dialector) DataTypeOf(field *schema.Field) string {
 	}
 }
 
+// convertFloat binds float32 parameters as Double when float32 fields are mapped to Double
+func (config *Config) convertFloat(v interface{}) interface{} {
+	if !config.DoubleFloats {
+		return v
+	}
+	switch f := v.(type) {
+	case float32:
+		return float64(f)
+	case *float32:
+		if f == nil {
+			return types.NullValue(types.TypeDouble)
+		}
+		return float64(*f)
+	}
+	return v
+}
+
 func (dialector Dialector) getSchemaCustomType(field *schema.Field) string {
 	sqlType := string(field.DataType)
 
@@ -229,16 +618,3 @@ func (dialector Dialector) RollbackTo(tx *gorm.DB, name string) error {
 	tx.Exec("ROLLBACK TO SAVEPOINT " + name)
 	return nil
 }
-
-func getSerialDatabaseType(s string) (dbType string, ok bool) {
-	switch s {
-	case "Int8":
-		return "smallint", true
-	case "Int32":
-		return "integer", true
-	case "Int64":
-		return "bigint", true
-	default:
-		return "", false
-	}
-}
@@ -0,0 +1,85 @@
+package ydb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// openTestDB opens database of environment variables read by NewFromEnv with config changed by
+// configure, tests using it are skipped when EnvConnectionString and EnvEndpoint are not set
+func openTestDB(t *testing.T, configure func(config *Config)) *gorm.DB {
+	t.Helper()
+	if os.Getenv(EnvConnectionString) == "" && os.Getenv(EnvEndpoint) == "" {
+		t.Skipf("%s is not set", EnvConnectionString)
+	}
+	config, err := configFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if configure != nil {
+		configure(&config)
+	}
+	db, err := gorm.Open(New(config), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// migrateTestTables creates tables of models dropped when test completes
+func migrateTestTables(t *testing.T, db *gorm.DB, models ...interface{}) {
+	t.Helper()
+	if err := db.Migrator().DropTable(models...); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(models...); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := db.Migrator().DropTable(models...); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+// dryRunPool is connection pool of databases building statements without executing them
+type dryRunPool struct{}
+
+func (dryRunPool) PrepareContext(context.Context, string) (*sql.Stmt, error) {
+	return nil, errDryRun
+}
+
+func (dryRunPool) ExecContext(context.Context, string, ...interface{}) (sql.Result, error) {
+	return nil, errDryRun
+}
+
+func (dryRunPool) QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error) {
+	return nil, errDryRun
+}
+
+func (dryRunPool) QueryRowContext(context.Context, string, ...interface{}) *sql.Row {
+	return nil
+}
+
+var errDryRun = errors.New("dry run")
+
+// openDryRunDB opens database of config building statements without connection to YDB
+func openDryRunDB(t *testing.T, config Config) *gorm.DB {
+	t.Helper()
+	config.Conn = dryRunPool{}
+	db, err := gorm.Open(New(config), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}